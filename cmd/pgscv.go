@@ -6,6 +6,7 @@ import (
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/pgscv"
 	"gopkg.in/alecthomas/kingpin.v2"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
@@ -19,10 +20,18 @@ func main() {
 	var (
 		showVersion = kingpin.Flag("version", "show version and exit").Default().Bool()
 		logLevel    = kingpin.Flag("log-level", "set log level: debug, info, warn, error").Default("info").Envar("LOG_LEVEL").String()
+		logFormat   = kingpin.Flag("log-format", "set log format: json, text").Default("json").Envar("LOG_FORMAT").Enum("json", "text")
 		configFile  = kingpin.Flag("config-file", "path to config file").Default("").Envar("PGSCV_CONFIG_FILE").String()
+		checkConfig = kingpin.Flag("check-config", "validate config (except SQL of custom queries), show enabled services and collectors and exit").Default().Bool()
+		collectOnce = kingpin.Flag("collect-once", "collect metrics once, print them to stdout and exit").Default().Bool()
 	)
 	kingpin.Parse()
+	// Metrics collected once are printed to stdout, log messages should not be mixed with them.
+	if *collectOnce {
+		log.SetOutput(os.Stderr)
+	}
 	log.SetLevel(*logLevel)
+	log.SetFormat(*logFormat)
 	log.SetApplication(appName)
 
 	if *showVersion {
@@ -43,24 +52,98 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *checkConfig {
+		if err := pgscv.Check(config, os.Stdout); err != nil {
+			log.Errorln("check config failed: ", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	config.BuildInfo = pgscv.BuildInfo{Version: gitTag, Commit: gitCommit, Branch: gitBranch}
+
+	if *collectOnce {
+		if err := pgscv.Collect(config, os.Stdout); err != nil {
+			log.Errorln("collect metrics failed: ", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
-	var doExit = make(chan error, 2)
-	go func() {
-		doExit <- listenSignals()
-		cancel()
-	}()
+	go listenSignals(cancel)
 
-	go func() {
-		doExit <- pgscv.Start(ctx, config)
-		cancel()
-	}()
+	reload := make(chan *pgscv.Config)
+	go listenReloadSignals(ctx, *configFile, config.BuildInfo, reload)
 
-	log.Warnf("received shutdown signal: '%s'", <-doExit)
+	// Run returns when context is cancelled and all in-flight work is finished or when application is failed.
+	if err := pgscv.Run(ctx, config, reload); err != nil {
+		log.Errorln("application failed: ", err)
+		os.Exit(1)
+	}
+
+	log.Infoln("application stopped")
 }
 
-func listenSignals() error {
-	c := make(chan os.Signal, 1)
+// listenSignals waits for shutdown signal and cancels application context. Repeated signal terminates application
+// immediately without waiting for graceful shutdown.
+func listenSignals(cancel context.CancelFunc) {
+	c := make(chan os.Signal, 2)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-	return fmt.Errorf("%s", <-c)
+
+	log.Warnf("received shutdown signal: '%s', shutting down gracefully", <-c)
+	cancel()
+
+	log.Warnf("received shutdown signal: '%s', exit immediately", <-c)
+	os.Exit(1)
+}
+
+// listenReloadSignals waits for SIGHUP, re-reads and validates configuration and sends it to reload channel. Invalid
+// configuration is not sent, application continues running with the current one.
+func listenReloadSignals(ctx context.Context, configFile string, buildInfo pgscv.BuildInfo, reload chan<- *pgscv.Config) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	defer signal.Stop(c)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c:
+		}
+
+		log.Infoln("received SIGHUP, reload configuration")
+
+		config, err := loadConfig(configFile)
+		if err != nil {
+			log.Errorln("reload configuration failed, continue with current configuration: ", err)
+			continue
+		}
+		config.BuildInfo = buildInfo
+
+		select {
+		case reload <- config:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// loadConfig reads configuration and validates it including checks performed by --check-config.
+func loadConfig(configFile string) (*pgscv.Config, error) {
+	config, err := pgscv.NewConfig(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("create config failed: %s", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("validate config failed: %s", err)
+	}
+
+	if err := pgscv.Check(config, io.Discard); err != nil {
+		return nil, fmt.Errorf("check config failed: %s", err)
+	}
+
+	return config, nil
 }
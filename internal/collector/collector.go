@@ -1,14 +1,50 @@
 package collector
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/cherts/pgscv/internal/filter"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// errCollectorTimeout is returned when collector exceeds its timeout.
+var errCollectorTimeout = errors.New("collector timeout exceeded")
+
+// errInsufficientPrivilege is returned by collectors which require privileges the service's role doesn't have.
+var errInsufficientPrivilege = errors.New("insufficient privilege")
+
+// skipReasonInsufficientPrivilege defines reason of skipping collectors due to lack of privileges.
+const skipReasonInsufficientPrivilege = "insufficient_privilege"
+
+const (
+	// connBackoffMin defines delay before retrying connection after the first failed attempt.
+	connBackoffMin = 5 * time.Second
+	// connBackoffMax defines upper limit of delay between connection attempts.
+	connBackoffMax = time.Minute
+)
+
+// OptInCollectors defines collectors which are disabled by default and have to be explicitly enabled in collectors
+// settings, e.g. because they are expensive or require additional tools.
+var OptInCollectors = []string{"system/smart"}
+
+// ManagedUnsupportedCollectors defines collectors which are disabled for managed services (e.g. AWS RDS), because they
+// require access to service's host. System collectors expose metrics of the host where pgscv runs and are not affected.
+// Other collectors use views and functions granted to pg_monitor role, except the following which skip their parts:
+//   - postgres/archiver doesn't count WAL segments waiting for archiving;
+//   - postgres/settings doesn't expose configuration files attributes;
+//   - postgres/storage exposes in-flight temp files only;
+//   - postgres/temp_files doesn't read temp directories.
+var ManagedUnsupportedCollectors = []string{"postgres/logs"}
+
 // Factories defines collector functions which used for collecting metrics.
 type Factories map[string]func(labels, model.CollectorSettings) (Collector, error)
 
@@ -30,6 +66,16 @@ func (f Factories) RegisterSystemCollectors(disabled []string) {
 		"system/network":     NewNetworkCollector,
 		"system/memory":      NewMeminfoCollector,
 		"system/sysconfig":   NewSysconfigCollector,
+		"system/pressure":    NewPressureCollector,
+		"system/hwmon":       NewHwmonCollector,
+		"system/numa":        NewNumaCollector,
+		"system/softnet":     NewSoftnetCollector,
+		"system/systemd":     NewSystemdCollector,
+		"system/filefd":      NewFilefdCollector,
+		"system/entropy":     NewEntropyCollector,
+		"system/timex":       NewTimexCollector,
+		"system/conntrack":   NewConntrackCollector,
+		"system/smart":       NewSmartCollector,
 	}
 
 	for name, fn := range funcs {
@@ -54,21 +100,33 @@ func (f Factories) RegisterPostgresCollectors(disabled []string) {
 		"postgres/pgscv":             NewPgscvServicesCollector,
 		"postgres/activity":          NewPostgresActivityCollector,
 		"postgres/archiver":          NewPostgresWalArchivingCollector,
+		"postgres/backends":          NewPostgresBackendsCollector,
 		"postgres/bgwriter":          NewPostgresBgwriterCollector,
+		"postgres/cache":             NewPostgresCacheCollector,
 		"postgres/conflicts":         NewPostgresConflictsCollector,
+		"postgres/connections":       NewPostgresConnectionsCollector,
+		"postgres/copy":              NewPostgresCopyCollector,
 		"postgres/databases":         NewPostgresDatabasesCollector,
 		"postgres/indexes":           NewPostgresIndexesCollector,
 		"postgres/functions":         NewPostgresFunctionsCollector,
 		"postgres/locks":             NewPostgresLocksCollector,
 		"postgres/logs":              NewPostgresLogsCollector,
+		"postgres/maintenance":       NewPostgresMaintenanceCollector,
+		"postgres/progress":          NewPostgresProgressCollector,
 		"postgres/replication":       NewPostgresReplicationCollector,
 		"postgres/replication_slots": NewPostgresReplicationSlotsCollector,
 		"postgres/statements":        NewPostgresStatementsCollector,
 		"postgres/schemas":           NewPostgresSchemasCollector,
 		"postgres/settings":          NewPostgresSettingsCollector,
+		"postgres/stat_io":           NewPostgresStatIOCollector,
 		"postgres/storage":           NewPostgresStorageCollector,
+		"postgres/subscriptions":     NewPostgresSubscriptionsCollector,
 		"postgres/tables":            NewPostgresTablesCollector,
+		"postgres/temp_files":        NewPostgresTempFilesCollector,
+		"postgres/toast":             NewPostgresToastCollector,
+		"postgres/wait_sampling":     NewPostgresWaitSamplingCollector,
 		"postgres/wal":               NewPostgresWalCollector,
+		"postgres/xid":               NewPostgresXidCollector,
 		"postgres/custom":            NewPostgresCustomCollector,
 	}
 
@@ -130,6 +188,16 @@ func (f Factories) RegisterPatroniCollectors(disabled []string) {
 	}
 }
 
+// Names returns sorted names of registered collectors.
+func (f Factories) Names() []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // register is the generic routine which register any kind of collectors.
 func (f Factories) register(collector string, factory func(labels, model.CollectorSettings) (Collector, error)) {
 	f[collector] = factory
@@ -137,8 +205,9 @@ func (f Factories) register(collector string, factory func(labels, model.Collect
 
 // Collector is the interface a collector has to implement.
 type Collector interface {
-	// Update does collecting new metrics and expose them via prometheus registry.
-	Update(config Config, ch chan<- prometheus.Metric) error
+	// Update does collecting new metrics and expose them via prometheus registry. Passed context is cancelled when
+	// collector's timeout is exceeded.
+	Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error
 }
 
 // PgscvCollector implements the prometheus.Collector interface.
@@ -147,20 +216,157 @@ type PgscvCollector struct {
 	Collectors map[string]Collector
 	// anchorDesc is a metric descriptor used for distinguishing collectors when unregister is required.
 	anchorDesc typedDesc
+	// durationDesc is a metric descriptor for collectors execution time.
+	durationDesc typedDesc
+	// successDesc is a metric descriptor for collectors execution status.
+	successDesc typedDesc
+	// timeoutsDesc is a metric descriptor for number of collectors timeouts.
+	timeoutsDesc typedDesc
+	// timeoutsMu protects timeouts map.
+	timeoutsMu *sync.Mutex
+	// timeouts defines number of timeouts occurred per each collector.
+	timeouts map[string]float64
+	// skippedDesc is a metric descriptor for collectors skipped during scrape.
+	skippedDesc typedDesc
+	// skipsMu protects skips map.
+	skipsMu *sync.Mutex
+	// skips defines collectors which have been skipped at least once, used for logging skips only once.
+	skips map[string]bool
+	// reconnectsDesc is a metric descriptor for number of reconnects to the service.
+	reconnectsDesc typedDesc
+	// upDesc is a metric descriptor for state of the service, it is sent regardless of collectors results.
+	upDesc typedDesc
+	// conn tracks availability of the service between scrapes.
+	conn *connState
+	// scrapesDesc is a metric descriptor for number of scrapes of the service.
+	scrapesDesc typedDesc
+	// scrapeErrorsDesc is a metric descriptor for number of scrapes of the service with failed collectors.
+	scrapeErrorsDesc typedDesc
+	// scrapes tracks number of scrapes of the service.
+	scrapes *scrapeState
+	// cacheAgeDesc is a metric descriptor for age of metrics cached by collectors running in background.
+	cacheAgeDesc typedDesc
+	// status keeps results of the last runs of collectors, used for diagnostic purposes.
+	status *statusState
+	// ctx is the context of the service, it is cancelled when collector is stopped.
+	ctx context.Context
+	// stop cancels ctx, stopping in-flight collectors and background refreshing of cached metrics.
+	stop context.CancelFunc
+}
+
+// connState tracks availability of the service between scrapes.
+type connState struct {
+	mu         sync.Mutex
+	lost       bool      // connection to the service has been lost during previous scrapes
+	reconnects float64   // number of times connection has been restored after loss
+	failures   int       // number of consecutive failed connection attempts
+	retryAt    time.Time // connection attempts are skipped until this time
+}
+
+// update updates state accordingly to result of the connection attempt and returns number of reconnects.
+func (s *connState) update(ok bool) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ok && s.lost {
+		s.reconnects++
+	}
+	s.lost = !ok
+
+	if ok {
+		s.failures = 0
+		s.retryAt = time.Time{}
+	} else {
+		s.failures++
+		s.retryAt = time.Now().Add(connBackoffDelay(s.failures))
+	}
+
+	return s.reconnects
+}
+
+// backoff returns true and remaining delay if connection attempt should be skipped due to previous failures.
+func (s *connState) backoff(now time.Time) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.retryAt.IsZero() || !now.Before(s.retryAt) {
+		return 0, false
+	}
+
+	return s.retryAt.Sub(now), true
+}
+
+// reconnectsTotal returns number of reconnects.
+func (s *connState) reconnectsTotal() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reconnects
+}
+
+// connBackoffDelay returns delay before next connection attempt after passed number of consecutive failures. Delay
+// is doubled after each failure and limited by connBackoffMax.
+func connBackoffDelay(failures int) time.Duration {
+	delay := connBackoffMin
+	for i := 1; i < failures && delay < connBackoffMax; i++ {
+		delay *= 2
+	}
+
+	if delay > connBackoffMax {
+		delay = connBackoffMax
+	}
+
+	return delay
+}
+
+// scrapeState tracks number of scrapes of the service.
+type scrapeState struct {
+	mu     sync.Mutex
+	total  float64 // number of scrapes
+	errors float64 // number of scrapes where at least one collector failed
+}
+
+// update accounts the scrape and returns total number of scrapes and failed scrapes.
+func (s *scrapeState) update(failed bool) (float64, float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.total++
+	if failed {
+		s.errors++
+	}
+
+	return s.total, s.errors
 }
 
 // NewPgscvCollector accepts Factories and creates per-service instance of Collector.
 func NewPgscvCollector(serviceID string, factories Factories, config Config) (*PgscvCollector, error) {
+	config.serviceID = serviceID
+
+	ctx, stop := context.WithCancel(context.Background())
+
+	// Collectors running in background refresh their metrics one by one, not to load the service at once.
+	refreshMu := &sync.Mutex{}
+
 	collectors := make(map[string]Collector)
-	constLabels := labels{"service_id": serviceID}
+	constLabels := labels{}
+	for k, v := range config.Labels {
+		constLabels[k] = v
+	}
+	constLabels["service_id"] = serviceID
 
 	for key := range factories {
 		settings := config.Settings[key]
 
 		collector, err := factories[key](constLabels, settings)
 		if err != nil {
+			stop()
 			return nil, err
 		}
+
+		if settings.Interval > 0 {
+			collector = newCachedCollector(ctx, key, collector, settings.Interval, refreshMu)
+		}
+
 		collectors[key] = collector
 	}
 
@@ -173,7 +379,112 @@ func NewPgscvCollector(serviceID string, factories Factories, config Config) (*P
 		filter.New(),
 	)
 
-	return &PgscvCollector{Config: config, Collectors: collectors, anchorDesc: desc}, nil
+	durationDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "collector", "duration_seconds", "Time spent by collector for collecting metrics, in seconds.", 0},
+		prometheus.GaugeValue,
+		[]string{"collector"}, constLabels,
+		filter.New(),
+	)
+
+	successDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "collector", "success", "Whether the collector succeeded.", 0},
+		prometheus.GaugeValue,
+		[]string{"collector"}, constLabels,
+		filter.New(),
+	)
+
+	timeoutsDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "collector", "timeout_total", "Total number of times when collector exceeded its timeout.", 0},
+		prometheus.CounterValue,
+		[]string{"collector"}, constLabels,
+		filter.New(),
+	)
+
+	skippedDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "collector", "skipped", "Whether the collector has been skipped during scrape, by reason.", 0},
+		prometheus.GaugeValue,
+		[]string{"collector", "reason"}, constLabels,
+		filter.New(),
+	)
+
+	reconnectsDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "postgres", "reconnects_total", "Total number of times connection to the service has been restored after loss.", 0},
+		prometheus.CounterValue,
+		nil, constLabels,
+		filter.New(),
+	)
+
+	upHelp := map[string]string{
+		model.ServiceTypePostgresql: "State of PostgreSQL service: 0 is down, 1 is up.",
+		model.ServiceTypePgbouncer:  "State of Pgbouncer service: 0 is down, 1 is up.",
+	}
+
+	// State of the service is sent only for services checked before collecting, e.g. postgres_up or pgbouncer_up.
+	upDesc := newBuiltinTypedDesc(
+		descOpts{config.ServiceType, "", "up", upHelp[config.ServiceType], 0},
+		prometheus.GaugeValue,
+		nil, constLabels,
+		filter.New(),
+	)
+
+	scrapesDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "", "scrapes_total", "Total number of times metrics have been collected from the service.", 0},
+		prometheus.CounterValue,
+		nil, constLabels,
+		filter.New(),
+	)
+
+	scrapeErrorsDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "", "scrape_errors_total", "Total number of times when at least one collector failed to collect metrics from the service.", 0},
+		prometheus.CounterValue,
+		nil, constLabels,
+		filter.New(),
+	)
+
+	cacheAgeDesc := newBuiltinTypedDesc(
+		descOpts{"pgscv", "collector", "cache_age_seconds", "Age of metrics cached by collector running in background, in seconds.", 0},
+		prometheus.GaugeValue,
+		[]string{"collector"}, constLabels,
+		filter.New(),
+	)
+
+	c := &PgscvCollector{
+		Config:           config,
+		Collectors:       collectors,
+		anchorDesc:       desc,
+		durationDesc:     durationDesc,
+		successDesc:      successDesc,
+		timeoutsDesc:     timeoutsDesc,
+		timeoutsMu:       &sync.Mutex{},
+		timeouts:         map[string]float64{},
+		skippedDesc:      skippedDesc,
+		skipsMu:          &sync.Mutex{},
+		skips:            map[string]bool{},
+		reconnectsDesc:   reconnectsDesc,
+		upDesc:           upDesc,
+		conn:             &connState{},
+		scrapesDesc:      scrapesDesc,
+		scrapeErrorsDesc: scrapeErrorsDesc,
+		scrapes:          &scrapeState{},
+		cacheAgeDesc:     cacheAgeDesc,
+		status:           newStatusState(),
+		ctx:              ctx,
+		stop:             stop,
+	}
+
+	// Labels attached to all metrics must not conflict with labels of particular metrics, including self-metrics.
+	if conflicts := labelConflicts(config.Labels, c); len(conflicts) > 0 {
+		stop()
+		return nil, fmt.Errorf("labels '%s' conflict with labels of collected metrics", strings.Join(conflicts, "', '"))
+	}
+
+	return c, nil
+}
+
+// Stop stops in-flight collectors and background refreshing of cached metrics. It should be called when the service
+// is removed, stopped collector should not be used anymore.
+func (n PgscvCollector) Stop() {
+	n.stop()
 }
 
 // Describe implements the prometheus.Collector interface.
@@ -183,11 +494,19 @@ func (n PgscvCollector) Describe(ch chan<- *prometheus.Desc) {
 
 // Collect implements the prometheus.Collector interface.
 func (n PgscvCollector) Collect(out chan<- prometheus.Metric) {
-	// Update settings of Postgres collectors
-	if n.Config.ServiceType == "postgres" {
-		cfg, err := newPostgresServiceConfig(n.Config.ConnString)
-		if err != nil {
-			log.Errorf("update service config failed: %s, skip collect", err.Error())
+	// Check availability of the service and update settings of Postgres collectors.
+	if n.Config.ServiceType == model.ServiceTypePostgresql || n.Config.ServiceType == model.ServiceTypePgbouncer {
+		cfg, ok := n.checkService(out)
+
+		// Host of managed service is not accessible even when it's resolved into local address, e.g. through tunnel.
+		if n.Config.Managed {
+			cfg.localService = false
+		}
+
+		// Service is not available, mark all collectors as failed and try again during next scrape.
+		if !ok {
+			n.sendFailedCollectorsStats(out)
+			n.sendScrapesStats(true, out)
 			return
 		}
 
@@ -200,11 +519,51 @@ func (n PgscvCollector) Collect(out chan<- prometheus.Metric) {
 	// Create pipe channel used transmitting metrics from collectors to sender.
 	pipelineIn := make(chan prometheus.Metric)
 
+	// Stats about collectors executions during current scrape.
+	statsMu := sync.Mutex{}
+	stats := make(map[string]collectorStat, len(n.Collectors))
+
+	// Limit number of concurrently running collectors if required.
+	var sem chan struct{}
+	if n.Config.Concurrency > 0 {
+		sem = make(chan struct{}, n.Config.Concurrency)
+	}
+
 	// Run collectors.
 	wgCollector.Add(len(n.Collectors))
 	for name, c := range n.Collectors {
 		go func(name string, c Collector) {
-			collect(name, n.Config, c, pipelineIn)
+			if sem != nil {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+			}
+
+			start := time.Now()
+			err := collect(n.ctx, name, n.Config, c, pipelineIn)
+
+			if errors.Is(err, errCollectorTimeout) {
+				n.timeoutsMu.Lock()
+				n.timeouts[name]++
+				n.timeoutsMu.Unlock()
+			}
+
+			reason := skipReason(n.Config, err)
+			if reason != "" {
+				n.skipsMu.Lock()
+				if !n.skips[name] {
+					log.Warnf("%s collector skipped, %s: %s; further skips are logged at debug level", name, reason, err)
+					n.skips[name] = true
+				}
+				n.skipsMu.Unlock()
+			}
+
+			duration := time.Since(start)
+			n.status.update(name, start, duration, err, reason)
+
+			statsMu.Lock()
+			stats[name] = collectorStat{duration: duration, success: err == nil, skipped: reason}
+			statsMu.Unlock()
+
 			wgCollector.Done()
 		}(name, c)
 	}
@@ -216,14 +575,135 @@ func (n PgscvCollector) Collect(out chan<- prometheus.Metric) {
 		wgSender.Done()
 	}()
 
-	// Wait until all collectors have been finished. Close the channel and allow to sender to send metrics.
+	// Wait until all collectors have been finished. Send collectors stats, close the channel and allow to sender to
+	// send metrics.
 	wgCollector.Wait()
+	n.sendCollectorsStats(stats, pipelineIn)
+
+	// Skipped collectors are not considered as failed, they are skipped on every scrape.
+	var failed bool
+	for _, s := range stats {
+		if !s.success && s.skipped == "" {
+			failed = true
+		}
+	}
+	n.sendScrapesStats(failed, pipelineIn)
+
 	close(pipelineIn)
 
 	// Wait until metrics have been sent.
 	wgSender.Wait()
 }
 
+// checkService connects to the service and sends metrics about its state. Postgres settings required by collectors are
+// returned when the service is available. Connection attempts are skipped while backoff after previous failures is
+// not expired.
+func (n PgscvCollector) checkService(out chan<- prometheus.Metric) (postgresServiceConfig, bool) {
+	var cfg postgresServiceConfig
+
+	// Service has been unavailable recently, don't waste scrape time on connection attempts until backoff expires.
+	if delay, ok := n.conn.backoff(time.Now()); ok {
+		log.Debugf("service is unavailable, skip collect, next connection attempt in %s", delay.Round(time.Second))
+		n.sendServiceState(false, n.conn.reconnectsTotal(), out)
+		return cfg, false
+	}
+
+	// Service check is bounded by collectors timeout, stuck service should not hang the whole scrape.
+	ctx, cancel := newCollectorContext(n.ctx, n.Config)
+	defer cancel()
+
+	var err error
+	if n.Config.ServiceType == model.ServiceTypePgbouncer {
+		err = checkPgbouncerService(ctx, n.Config.ConnString)
+	} else {
+		cfg, err = newPostgresServiceConfig(ctx, n.Config.ConnString)
+	}
+
+	if err != nil {
+		log.Errorf("update service config failed: %s, skip collect", err.Error())
+	}
+	n.status.setError(err)
+
+	n.sendServiceState(err == nil, n.conn.update(err == nil), out)
+
+	return cfg, err == nil
+}
+
+// sendServiceState sends metrics about availability of the service.
+func (n PgscvCollector) sendServiceState(up bool, reconnects float64, ch chan<- prometheus.Metric) {
+	var v float64
+	if up {
+		v = 1
+	}
+	ch <- n.upDesc.newConstMetric(v)
+
+	if n.Config.ServiceType == model.ServiceTypePostgresql {
+		ch <- n.reconnectsDesc.newConstMetric(reconnects)
+	}
+}
+
+// skipReason returns reason of skipping the collector if passed error means the collector could not collect metrics
+// due to lack of privileges, empty string is returned otherwise. Privileged queries are not skipped in managed mode.
+func skipReason(config Config, err error) string {
+	if errors.Is(err, errInsufficientPrivilege) || (!config.Managed && isInsufficientPrivilege(err)) {
+		return skipReasonInsufficientPrivilege
+	}
+
+	return ""
+}
+
+// collectorStat defines stats about single collector execution.
+type collectorStat struct {
+	duration time.Duration
+	success  bool
+	skipped  string // reason of skipping the collector, empty if collector has not been skipped
+}
+
+// sendCollectorsStats sends metrics about collectors executions.
+func (n PgscvCollector) sendCollectorsStats(stats map[string]collectorStat, ch chan<- prometheus.Metric) {
+	n.timeoutsMu.Lock()
+	defer n.timeoutsMu.Unlock()
+
+	for name, s := range stats {
+		var success float64
+		if s.success {
+			success = 1
+		}
+
+		ch <- n.durationDesc.newConstMetric(s.duration.Seconds(), name)
+		ch <- n.successDesc.newConstMetric(success, name)
+		ch <- n.timeoutsDesc.newConstMetric(n.timeouts[name], name)
+
+		if s.skipped != "" {
+			ch <- n.skippedDesc.newConstMetric(1, name, s.skipped)
+		}
+
+		if c, ok := n.Collectors[name].(*cachedCollector); ok {
+			if age, ok := c.age(); ok {
+				ch <- n.cacheAgeDesc.newConstMetric(age.Seconds(), name)
+			}
+		}
+	}
+}
+
+// sendFailedCollectorsStats sends metrics about collectors which have not been executed due to service unavailability.
+func (n PgscvCollector) sendFailedCollectorsStats(ch chan<- prometheus.Metric) {
+	stats := make(map[string]collectorStat, len(n.Collectors))
+	for name := range n.Collectors {
+		stats[name] = collectorStat{success: false}
+	}
+
+	n.sendCollectorsStats(stats, ch)
+}
+
+// sendScrapesStats accounts the scrape and sends metrics about scrapes of the service.
+func (n PgscvCollector) sendScrapesStats(failed bool, ch chan<- prometheus.Metric) {
+	total, errs := n.scrapes.update(failed)
+
+	ch <- n.scrapesDesc.newConstMetric(total)
+	ch <- n.scrapeErrorsDesc.newConstMetric(errs)
+}
+
 // send acts like a middleware between metric collector functions which produces metrics and Prometheus who accepts metrics.
 func send(in <-chan prometheus.Metric, out chan<- prometheus.Metric) {
 	for m := range in {
@@ -238,10 +718,60 @@ func send(in <-chan prometheus.Metric, out chan<- prometheus.Metric) {
 	}
 }
 
-// collect runs metric collection function and wraps it into instrumenting logic.
-func collect(name string, config Config, c Collector, ch chan<- prometheus.Metric) {
-	err := c.Update(config, ch)
-	if err != nil {
-		log.Errorf("%s collector failed; %s", name, err)
+// collect runs metric collection function and wraps it into instrumenting logic. Returns error if collector failed
+// or errCollectorTimeout if collector has been timed out. Collector is cancelled when passed context is done.
+func collect(ctx context.Context, name string, config Config, c Collector, ch chan<- prometheus.Metric) error {
+	ctx, cancel := newCollectorContext(ctx, config)
+	defer cancel()
+
+	// Collector sends metrics into its own channel. In case of timeout, the channel is abandoned and drained in background,
+	// this allows to finish collecting without waiting for stuck collector.
+	metricsCh := make(chan prometheus.Metric)
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- c.Update(ctx, config, metricsCh)
+		close(metricsCh)
+	}()
+
+	for {
+		select {
+		case m, ok := <-metricsCh:
+			if !ok {
+				err := <-errCh
+				if errors.Is(err, store.ErrConnect) {
+					log.Errorf("%s collector failed, service is not reachable; %s", name, err)
+				} else if config.Managed && isInsufficientPrivilege(err) {
+					log.Errorf("%s collector failed, privileged query is not allowed in managed mode, disable the collector; %s", name, err)
+				} else if skipReason(config, err) != "" {
+					log.Debugf("%s collector skipped; %s", name, err)
+				} else if err != nil {
+					log.Errorf("%s collector failed; %s", name, err)
+				}
+				return err
+			}
+			ch <- m
+		case <-ctx.Done():
+			go func() {
+				for range metricsCh {
+				}
+			}()
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return ctx.Err()
+			}
+			log.Errorf("%s collector failed; timeout %s exceeded", name, config.CollectorTimeout)
+			return errCollectorTimeout
+		}
 	}
 }
+
+// newCollectorContext returns context for collector derived from passed one. Zero timeout means no timeout.
+// Connections made using the context are pooled on behalf of the service.
+func newCollectorContext(ctx context.Context, config Config) (context.Context, context.CancelFunc) {
+	ctx = store.WithService(ctx, config.serviceID)
+	if config.CollectorTimeout > 0 {
+		return context.WithTimeout(ctx, config.CollectorTimeout)
+	}
+
+	return context.WithCancel(ctx)
+}
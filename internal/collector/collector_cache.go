@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cachedCollector wraps collector which should not run on every scrape. Metrics collected by wrapped collector are
+// cached and served during scrapes. When nothing is cached yet or cached metrics become older than interval, they are
+// refreshed in background, scrapes are served with previously cached metrics meanwhile.
+type cachedCollector struct {
+	name      string
+	collector Collector
+	interval  time.Duration
+	// ctx is the context of the service, refreshing is stopped when it is done.
+	ctx context.Context
+	// refreshMu is shared by cached collectors of the service and serializes their refreshing.
+	refreshMu *sync.Mutex
+
+	mu         sync.Mutex
+	metrics    []prometheus.Metric
+	updatedAt  time.Time
+	refreshing bool
+}
+
+// newCachedCollector creates new cachedCollector which refreshes metrics with passed interval until passed context
+// is done.
+func newCachedCollector(ctx context.Context, name string, c Collector, interval time.Duration, refreshMu *sync.Mutex) *cachedCollector {
+	return &cachedCollector{name: name, collector: c, interval: interval, ctx: ctx, refreshMu: refreshMu}
+}
+
+// Update implements Collector interface and sends cached metrics.
+func (c *cachedCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
+	c.mu.Lock()
+
+	stale := c.updatedAt.IsZero() || time.Since(c.updatedAt) >= c.interval
+	if stale && !c.refreshing && c.ctx.Err() == nil {
+		c.refreshing = true
+		go func() {
+			if err := c.refresh(config); err != nil {
+				log.Warnf("%s collector: refresh cached metrics failed, continue with previously cached metrics; %s", c.name, err)
+			}
+
+			c.mu.Lock()
+			c.refreshing = false
+			c.mu.Unlock()
+		}()
+	}
+
+	metrics := c.metrics
+	c.mu.Unlock()
+
+	for _, m := range metrics {
+		ch <- m
+	}
+
+	return nil
+}
+
+// refresh runs wrapped collector and caches collected metrics if collector succeeded. Wrapped collector runs rarely
+// and may take longer than regular collectors, hence it is limited by its interval instead of collectors timeout.
+func (c *cachedCollector) refresh(config Config) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+
+	config.CollectorTimeout = c.interval
+
+	ch := make(chan prometheus.Metric)
+	done := make(chan []prometheus.Metric)
+
+	go func() {
+		var metrics []prometheus.Metric
+		for m := range ch {
+			if m != nil {
+				metrics = append(metrics, m)
+			}
+		}
+		done <- metrics
+	}()
+
+	err := collect(c.ctx, c.name, config, c.collector, ch)
+	close(ch)
+	metrics := <-done
+
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.metrics = metrics
+	c.updatedAt = time.Now()
+	c.mu.Unlock()
+
+	return nil
+}
+
+// age returns age of cached metrics. Returns false if nothing is cached yet.
+func (c *cachedCollector) age() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.updatedAt.IsZero() {
+		return 0, false
+	}
+
+	return time.Since(c.updatedAt), true
+}
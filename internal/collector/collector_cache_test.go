@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingCollector sends a metric with number of times it has been run.
+type countingCollector struct {
+	runs  int32
+	delay time.Duration
+	desc  typedDesc
+}
+
+func (c *countingCollector) Update(_ context.Context, _ Config, ch chan<- prometheus.Metric) error {
+	time.Sleep(c.delay)
+	ch <- c.desc.newConstMetric(float64(atomic.AddInt32(&c.runs, 1)))
+	return nil
+}
+
+// newCountingCollector creates countingCollector which runs with passed delay.
+func newCountingCollector(delay time.Duration) *countingCollector {
+	return &countingCollector{delay: delay, desc: newBuiltinTypedDesc(
+		descOpts{"test", "", "runs_total", "Test description.", 0},
+		prometheus.CounterValue,
+		nil, nil,
+		filter.New(),
+	)}
+}
+
+func Test_cachedCollector(t *testing.T) {
+	c := newCountingCollector(0)
+	cc := newCachedCollector(context.Background(), "test/counting", c, 50*time.Millisecond, &sync.Mutex{})
+
+	_, ok := cc.age()
+	assert.False(t, ok)
+
+	// First update doesn't wait for collector, metrics are collected in background.
+	assert.Empty(t, updateCachedCollector(t, cc))
+	assert.Eventually(t, func() bool { return len(updateCachedCollector(t, cc)) == 1 }, time.Second, 5*time.Millisecond)
+
+	// Next updates serve cached metrics until interval is passed.
+	assert.Equal(t, []float64{1}, updateCachedCollector(t, cc))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&c.runs))
+
+	age, ok := cc.age()
+	assert.True(t, ok)
+	assert.Less(t, age, 50*time.Millisecond)
+
+	// Stale metrics are still served, but refreshed in background.
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, []float64{1}, updateCachedCollector(t, cc))
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&c.runs) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool { return updateCachedCollector(t, cc)[0] == 2 }, time.Second, 5*time.Millisecond)
+}
+
+func Test_cachedCollector_timeout(t *testing.T) {
+	// Refreshing is limited by interval instead of collector timeout.
+	c := newCountingCollector(30 * time.Millisecond)
+	cc := newCachedCollector(context.Background(), "test/counting", c, time.Second, &sync.Mutex{})
+
+	assert.NoError(t, cc.refresh(Config{CollectorTimeout: 10 * time.Millisecond}))
+	assert.Equal(t, []float64{1}, updateCachedCollector(t, cc))
+}
+
+func Test_cachedCollector_stopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := newCountingCollector(0)
+	cc := newCachedCollector(ctx, "test/counting", c, 10*time.Millisecond, &sync.Mutex{})
+
+	// Metrics are not refreshed when service's context is done.
+	assert.Empty(t, updateCachedCollector(t, cc))
+	assert.ErrorIs(t, cc.refresh(Config{}), context.Canceled)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&c.runs))
+}
+
+// updateCachedCollector runs collector update and returns values of collected metrics.
+func updateCachedCollector(t *testing.T, c *cachedCollector) []float64 {
+	ch := make(chan prometheus.Metric, 10)
+	assert.NoError(t, c.Update(context.Background(), Config{}, ch))
+	close(ch)
+
+	var values []float64
+	for m := range ch {
+		pb := &dto.Metric{}
+		assert.NoError(t, m.Write(pb))
+		values = append(values, pb.GetCounter().GetValue())
+	}
+
+	return values
+}
@@ -1,14 +1,21 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"github.com/jackc/pgx/v4"
 	"github.com/cherts/pgscv/internal/filter"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -16,6 +23,66 @@ import (
 // labels is a local wrapper over prometheus.Labels which is a simple map[string]string.
 type labels prometheus.Labels
 
+// descLabelNames adds names of variable labels of all metric descriptors found in passed value to names. Value is
+// walked recursively through pointers, interfaces, slices, maps and structs; named structs of other packages are
+// skipped.
+func descLabelNames(v reflect.Value, names map[string]bool, seen map[uintptr]bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		descLabelNames(v.Elem(), names, seen)
+	case reflect.Interface:
+		if !v.IsNil() {
+			descLabelNames(v.Elem(), names, seen)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			descLabelNames(v.Index(i), names, seen)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			descLabelNames(iter.Value(), names, seen)
+		}
+	case reflect.Struct:
+		if v.Type() == typedDescType {
+			labelNames := v.FieldByName("labelNames")
+			for i := 0; i < labelNames.Len(); i++ {
+				names[labelNames.Index(i).String()] = true
+			}
+			return
+		}
+
+		if pkg := v.Type().PkgPath(); pkg != "" && pkg != typedDescType.PkgPath() {
+			return
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			descLabelNames(v.Field(i), names, seen)
+		}
+	}
+}
+
+// labelConflicts returns sorted names of passed constant labels which have the same names as variable labels of
+// metric descriptors used by passed collector. Metrics with conflicting labels can't be created.
+func labelConflicts(constLabels map[string]string, collector interface{}) []string {
+	names := map[string]bool{}
+	descLabelNames(reflect.ValueOf(collector), names, map[uintptr]bool{})
+
+	var conflicts []string
+	for name := range constLabels {
+		if names[name] {
+			conflicts = append(conflicts, name)
+		}
+	}
+
+	sort.Strings(conflicts)
+	return conflicts
+}
+
 // typedDesc is the descriptor wrapper with extra properties
 type typedDesc struct {
 	// desc is the descriptor used by every Prometheus Metric.
@@ -37,6 +104,9 @@ type typedDesc struct {
 	filters filter.Filters
 }
 
+// typedDescType is used for looking up metric descriptors in collectors.
+var typedDescType = reflect.TypeOf(typedDesc{})
+
 // descOpts defines metric descriptor options.
 type descOpts struct {
 	namespace string
@@ -105,6 +175,27 @@ func (d *typedDesc) newConstMetric(value float64, labelValues ...string) prometh
 	return m
 }
 
+// newConstMetricWithExemplar is the wrapper on prometheus.NewMetricWithExemplars, it attaches exemplar with passed
+// labels to the counter metric. Exemplars are exposed only when metrics are requested in OpenMetrics format.
+func (d *typedDesc) newConstMetricWithExemplar(value float64, exemplar prometheus.Labels, labelValues ...string) prometheus.Metric {
+	m := d.newConstMetric(value, labelValues...)
+	if m == nil || d.valueType != prometheus.CounterValue || len(exemplar) == 0 {
+		return m
+	}
+
+	if d.factor != 0 {
+		value *= d.factor
+	}
+
+	em, err := prometheus.NewMetricWithExemplars(m, prometheus.Exemplar{Value: value, Labels: exemplar})
+	if err != nil {
+		log.Warnf("attach exemplar failed: %s; continue without exemplar. Metric descriptor: '%s'", err, d.desc.String())
+		return m
+	}
+
+	return em
+}
+
 // hasFilter checks label values against configured filters. Returns true if metric has to be filtered and false otherwise.
 func (d *typedDesc) hasFilter(labelValues []string) bool {
 	for i, key := range d.labelNames {
@@ -126,6 +217,8 @@ type typedDescSet struct {
 	databasesRE *regexp.Regexp // compiled regexp.Regexp object with databases from which metrics should be collected
 	query       string         // query used for requesting stats
 	descs       []typedDesc    // metrics descriptors
+	// columnsChecked defines columns used by metrics have been checked against query text during config validation.
+	columnsChecked bool
 }
 
 // newDeskSetsFromSubsystems parses subsystem object and produces []typedDescSet object.
@@ -166,18 +259,7 @@ func newDescSet(namespace string, subsystemName string, subsystem model.MetricsS
 	var descs []typedDesc
 	for _, m := range subsystem.Metrics {
 
-		// When particular databases specified in user-defined metrics, add 'database' label for metric labels.
-		var labels []string
-		if subsystem.Databases != "" {
-			labels = append([]string{"database"}, m.Labels...)
-		} else {
-			labels = m.Labels
-		}
-
-		// Append label names for labeled values.
-		for k := range m.LabeledValues {
-			labels = append(labels, k)
-		}
+		labels := userMetricLabelNames(subsystem, m)
 
 		if m.Value == "" && m.LabeledValues == nil {
 			log.Warnf("metric '%s' values of 'value' or 'labeledValues' must not be empty; skip", m.ShortName)
@@ -202,27 +284,49 @@ func newDescSet(namespace string, subsystemName string, subsystem model.MetricsS
 		descs = append(descs, d)
 	}
 
+	// Columns of queries with known columns are checked by CheckSubsystemColumns during config validation.
+	_, columnsChecked := queryColumns(subsystem.Query)
+
 	return typedDescSet{
-		namespace:   namespace,
-		subsystem:   subsystemName,
-		databasesRE: databasesRE,
-		query:       subsystem.Query,
-		descs:       descs,
+		namespace:      namespace,
+		subsystem:      subsystemName,
+		databasesRE:    databasesRE,
+		query:          subsystem.Query,
+		descs:          descs,
+		columnsChecked: columnsChecked,
 	}, nil
 }
 
+// userMetricLabelNames returns names of labels of user-defined metric, including labels of labeled values.
+func userMetricLabelNames(subsystem model.MetricsSubsystem, m model.UserMetric) []string {
+	// When particular databases specified in user-defined metrics, add 'database' label for metric labels.
+	var labels []string
+	if subsystem.Databases != "" {
+		labels = append([]string{"database"}, m.Labels...)
+	} else {
+		labels = append(labels, m.Labels...)
+	}
+
+	// Append label names for labeled values.
+	for k := range m.LabeledValues {
+		labels = append(labels, k)
+	}
+
+	return labels
+}
+
 // updateAllDescSets collect metrics for specified desc set.
-func updateAllDescSets(config Config, descSets []typedDescSet, ch chan<- prometheus.Metric) error {
+func updateAllDescSets(ctx context.Context, config Config, descSets []typedDescSet, ch chan<- prometheus.Metric) error {
 	// Collect multiple-databases metrics.
 	if needMultipleUpdate(descSets) {
-		err := updateFromMultipleDatabases(config, descSets, ch)
+		err := updateFromMultipleDatabases(ctx, config, descSets, ch)
 		if err != nil {
 			log.Errorf("collect failed: %s; skip", err)
 		}
 	}
 
 	// Collect once-database metrics.
-	err := updateFromSingleDatabase(config, descSets, ch)
+	err := updateFromSingleDatabase(ctx, config, descSets, ch)
 	if err != nil {
 		log.Errorf("collect failed: %s; skip", err)
 	}
@@ -231,14 +335,15 @@ func updateAllDescSets(config Config, descSets []typedDescSet, ch chan<- prometh
 }
 
 // updateFromMultipleDatabases method visits all requested databases and collects necessary metrics.
-func updateFromMultipleDatabases(config Config, descSets []typedDescSet, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func updateFromMultipleDatabases(ctx context.Context, config Config, descSets []typedDescSet, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
 
-	realDatabases, err := listDatabases(conn)
+	realDatabases, err := listDatabases(ctx, conn)
 	if err != nil {
+		conn.Close()
 		return err
 	}
 
@@ -259,12 +364,12 @@ func updateFromMultipleDatabases(config Config, descSets []typedDescSet, ch chan
 
 			// Connect to the database and update metrics.
 			pgconfig.Database = dbname
-			conn, err := store.NewWithConfig(pgconfig)
+			conn, err := store.NewWithConfigContext(ctx, pgconfig)
 			if err != nil {
 				return err
 			}
 
-			err = updateSingleDescSet(conn, s, ch, true)
+			err = updateSingleDescSet(ctx, conn, s, ch, true)
 			if err != nil {
 				log.Errorf("collect failed: %s; skip", err)
 			}
@@ -278,8 +383,8 @@ func updateFromMultipleDatabases(config Config, descSets []typedDescSet, ch chan
 }
 
 // updateFromSingleDatabase method visit only one database and collect necessary metrics.
-func updateFromSingleDatabase(config Config, descSets []typedDescSet, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func updateFromSingleDatabase(ctx context.Context, config Config, descSets []typedDescSet, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
@@ -295,7 +400,7 @@ func updateFromSingleDatabase(config Config, descSets []typedDescSet, ch chan<-
 			continue
 		}
 
-		err = updateSingleDescSet(conn, s, ch, false)
+		err = updateSingleDescSet(ctx, conn, s, ch, false)
 		if err != nil {
 			log.Errorf("collect failed: %s; skip", err)
 			continue
@@ -306,8 +411,9 @@ func updateFromSingleDatabase(config Config, descSets []typedDescSet, ch chan<-
 }
 
 // updateSingleDescSet requests data using passed connection, parses returned result and update metrics in passed descs.
-func updateSingleDescSet(conn *store.DB, descs typedDescSet, ch chan<- prometheus.Metric, addDatabaseLabel bool) error {
-	res, err := conn.Query(descs.query)
+// Query is cancelled when passed context is done (e.g. collector timeout is exceeded).
+func updateSingleDescSet(ctx context.Context, conn *store.DB, descs typedDescSet, ch chan<- prometheus.Metric, addDatabaseLabel bool) error {
+	res, err := conn.QueryContext(ctx, descs.query)
 	if err != nil {
 		return err
 	}
@@ -322,8 +428,25 @@ func updateSingleDescSet(conn *store.DB, descs typedDescSet, ch chan<- prometheu
 		databaseLabelValue = conn.Conn().Config().Database
 	}
 
+	// Skip metrics which require columns absent in query result. Columns are checked here only if it has not been done
+	// during config validation.
+	var valid []typedDesc
+	for _, d := range descs.descs {
+		if descs.columnsChecked {
+			valid = append(valid, d)
+			continue
+		}
+
+		err := checkDescColumns(d, colnames, addDatabaseLabel)
+		if err != nil {
+			log.Errorf("metric '%s': %s; skip", d.desc.String(), err)
+			continue
+		}
+		valid = append(valid, d)
+	}
+
 	for _, row := range res.Rows {
-		for _, d := range descs.descs {
+		for _, d := range valid {
 			updateMetrics(row, d, colnames, ch, databaseLabelValue)
 		}
 	}
@@ -331,6 +454,41 @@ func updateSingleDescSet(conn *store.DB, descs typedDescSet, ch chan<- prometheu
 	return nil
 }
 
+// checkDescColumns checks that all columns required by metric descriptor (value and labels) are present in query result.
+func checkDescColumns(desc typedDesc, colnames []string, addDatabaseLabel bool) error {
+	var missing []string
+
+	if desc.value != "" && !stringsContains(colnames, desc.value) {
+		missing = append(missing, desc.value)
+	}
+
+	for _, valueCols := range desc.labeledValues {
+		for _, c := range valueCols {
+			sourceName, _ := parseLabeledValue(c)
+			if !stringsContains(colnames, sourceName) {
+				missing = append(missing, sourceName)
+			}
+		}
+	}
+
+	for _, name := range desc.labelNames {
+		// Labels of labeled values are not columns, 'database' label could be filled with name of visited database.
+		if _, ok := desc.labeledValues[name]; ok || (name == "database" && addDatabaseLabel) {
+			continue
+		}
+
+		if !stringsContains(colnames, name) {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("columns not found in query result: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
 // updateMetrics
 func updateMetrics(row []sql.NullString, desc typedDesc, colnames []string, ch chan<- prometheus.Metric, databaseLabelValue string) {
 	// Using the descriptor a many metrics could be produced (with different label values).
@@ -369,13 +527,8 @@ func updateMultipleMetrics(row []sql.NullString, desc typedDesc, colnames []stri
 				sourceName, destName := parseLabeledValue(descColname)
 
 				if sourceName == resColname && !valueOK {
-					// Skip NULL values, metric must not be unknown (NULL).
-					if !row[i].Valid {
-						continue
-					}
-
 					var err error
-					value, err = strconv.ParseFloat(row[i].String, 64)
+					value, err = parseNullableFloat(row[i])
 					if err != nil {
 						log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
 						continue
@@ -435,13 +588,8 @@ func updateSingleMetric(row []sql.NullString, desc typedDesc, colnames []string,
 	for i, colname := range colnames {
 		// Check for value.
 		if colname == desc.value {
-			// Skip NULL values - metric must not be unknown (NULL)
-			if !row[i].Valid {
-				continue
-			}
-
 			var err error
-			value, err = strconv.ParseFloat(row[i].String, 64)
+			value, err = parseNullableFloat(row[i])
 			if err != nil {
 				log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
 				continue
@@ -472,6 +620,15 @@ func updateSingleMetric(row []sql.NullString, desc typedDesc, colnames []string,
 	ch <- desc.newConstMetric(value, labelValues...)
 }
 
+// parseNullableFloat parses value of query result into float64. NULL values are considered as unknown and returned as NaN.
+func parseNullableFloat(v sql.NullString) (float64, error) {
+	if !v.Valid {
+		return math.NaN(), nil
+	}
+
+	return strconv.ParseFloat(v.String, 64)
+}
+
 // needMultipleUpdate returns true if databases regexp has been found.
 func needMultipleUpdate(sets []typedDescSet) bool {
 	for _, set := range sets {
@@ -496,3 +653,19 @@ func parseLabeledValue(s string) (string, string) {
 
 	return ff[0], ff[1]
 }
+
+// readFloatFile reads single numeric value from passed file, e.g. from procfs or sysfs attribute.
+func readFloatFile(path string) (float64, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return 0, err
+	}
+
+	value := strings.TrimSpace(string(data))
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid input, parse '%s' failed: %w", value, err)
+	}
+
+	return v, nil
+}
@@ -1,13 +1,17 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/cherts/pgscv/internal/filter"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"math"
+	"os"
 	"regexp"
 	"strings"
 	"sync"
@@ -28,6 +32,35 @@ func Test_newConstMetric(t *testing.T) {
 	assert.Nil(t, m)
 }
 
+func Test_newConstMetricWithExemplar(t *testing.T) {
+	d := newBuiltinTypedDesc(
+		descOpts{"postgres", "statements", "time_seconds_all_total", "Test description.", .001},
+		prometheus.CounterValue,
+		[]string{"L1"}, nil,
+		filter.New(),
+	)
+
+	m := d.newConstMetricWithExemplar(1500, prometheus.Labels{"queryid": "123"}, "L1")
+	assert.NotNil(t, m)
+
+	pb := &dto.Metric{}
+	assert.NoError(t, m.Write(pb))
+	assert.Equal(t, 1.5, pb.GetCounter().GetValue())
+	assert.Equal(t, 1.5, pb.GetCounter().GetExemplar().GetValue())
+	assert.Equal(t, "queryid", pb.GetCounter().GetExemplar().GetLabel()[0].GetName())
+	assert.Equal(t, "123", pb.GetCounter().GetExemplar().GetLabel()[0].GetValue())
+
+	// Without exemplar labels plain metric is returned.
+	m = d.newConstMetricWithExemplar(1500, nil, "L1")
+	pb = &dto.Metric{}
+	assert.NoError(t, m.Write(pb))
+	assert.Nil(t, pb.GetCounter().GetExemplar())
+
+	// Invalid number of label values.
+	m = d.newConstMetricWithExemplar(1, prometheus.Labels{"queryid": "123"}, "L1", "L2")
+	assert.Nil(t, m)
+}
+
 func Test_typedDesc_hasFilter(t *testing.T) {
 	f := filter.New()
 	f.Add("target", filter.Filter{Exclude: "unwanted"})
@@ -63,6 +96,41 @@ func Test_typedDesc_hasFilter(t *testing.T) {
 	}
 }
 
+func Test_labelConflicts(t *testing.T) {
+	d := newBuiltinTypedDesc(
+		descOpts{"m", "test", "example", "description", 0},
+		prometheus.CounterValue,
+		[]string{"database", "user"}, nil,
+		filter.New(),
+	)
+
+	// Descriptors are found in nested structs, pointers, slices and maps.
+	c := &struct {
+		single typedDesc
+		sets   []typedDescSet
+		descs  map[string]typedDesc
+	}{
+		descs: map[string]typedDesc{"example": d},
+	}
+
+	constLabels := map[string]string{"user": "u", "environment": "prod", "database": "db"}
+	assert.Equal(t, []string{"database", "user"}, labelConflicts(constLabels, c))
+	assert.Nil(t, labelConflicts(map[string]string{"environment": "prod"}, c))
+
+	// Collectors of all service types are walked without conflicts with non-overlapping labels.
+	for _, register := range []func(f Factories){
+		func(f Factories) { f.RegisterSystemCollectors([]string{}) },
+		func(f Factories) { f.RegisterPostgresCollectors([]string{}) },
+		func(f Factories) { f.RegisterPgbouncerCollectors([]string{}) },
+		func(f Factories) { f.RegisterPatroniCollectors([]string{}) },
+	} {
+		f := Factories{}
+		register(f)
+		_, err := NewPgscvCollector("test:0", f, Config{Labels: map[string]string{"environment": "prod"}})
+		assert.NoError(t, err)
+	}
+}
+
 func Test_newDeskSetsFromSubsystems(t *testing.T) {
 	subsystems := map[string]model.MetricsSubsystem{
 		// This should be in the output
@@ -173,7 +241,7 @@ func Test_updateAllDescSets(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
-		assert.NoError(t, updateAllDescSets(config, desksets, ch))
+		assert.NoError(t, updateAllDescSets(context.Background(), config, desksets, ch))
 		close(ch)
 		wg.Done()
 	}()
@@ -226,7 +294,7 @@ func Test_updateFromMultipleDatabases(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
-		assert.NoError(t, updateFromMultipleDatabases(config, desksets, ch))
+		assert.NoError(t, updateFromMultipleDatabases(context.Background(), config, desksets, ch))
 		close(ch)
 		wg.Done()
 	}()
@@ -269,7 +337,7 @@ func Test_updateFromSingleDatabase(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
-		assert.NoError(t, updateFromSingleDatabase(config, desksets, ch))
+		assert.NoError(t, updateFromSingleDatabase(context.Background(), config, desksets, ch))
 		close(ch)
 		wg.Done()
 	}()
@@ -342,7 +410,7 @@ func Test_updateSingleDescSet(t *testing.T) {
 			var wg sync.WaitGroup
 			wg.Add(1)
 			go func() {
-				assert.NoError(t, updateSingleDescSet(conn, set, ch, addDatabaseLabel))
+				assert.NoError(t, updateSingleDescSet(context.Background(), conn, set, ch, addDatabaseLabel))
 				close(ch)
 				wg.Done()
 			}()
@@ -545,6 +613,18 @@ func Test_updateSingleMetric(t *testing.T) {
 			dbLabelValue: "",
 			want:         1,
 		},
+		{
+			// NULL value
+			desc: newCustomTypedDesc(
+				descOpts{"postgres", "table", "nullable", "description", 0},
+				prometheus.GaugeValue,
+				"nullable", nil,
+				[]string{"relname"}, labels{"const": "example"},
+				filter.New(),
+			),
+			dbLabelValue: "",
+			want:         1,
+		},
 		{
 			// label which present in metric labels, but absent in data row.
 			desc: newCustomTypedDesc(
@@ -580,6 +660,85 @@ func Test_updateSingleMetric(t *testing.T) {
 	}
 }
 
+func Test_checkDescColumns(t *testing.T) {
+	colnames := []string{"relname", "seq_scan", "inserted", "updated"}
+
+	testcases := []struct {
+		desc             typedDesc
+		addDatabaseLabel bool
+		valid            bool
+	}{
+		{
+			desc: newCustomTypedDesc(
+				descOpts{"postgres", "table", "seq_scan_total", "description", 0},
+				prometheus.CounterValue, "seq_scan", nil, []string{"relname"}, labels{}, filter.New(),
+			),
+			valid: true,
+		},
+		{
+			desc: newCustomTypedDesc(
+				descOpts{"postgres", "table", "seq_scan_total", "description", 0},
+				prometheus.CounterValue, "seq_scan", nil, []string{"database", "relname"}, labels{}, filter.New(),
+			),
+			addDatabaseLabel: true,
+			valid:            true,
+		},
+		{
+			desc: newCustomTypedDesc(
+				descOpts{"postgres", "table", "tuples_total", "description", 0},
+				prometheus.CounterValue, "", map[string][]string{"tuples": {"inserted", "updated/upd"}}, []string{"relname", "tuples"}, labels{}, filter.New(),
+			),
+			valid: true,
+		},
+		{
+			// value column is absent
+			desc: newCustomTypedDesc(
+				descOpts{"postgres", "table", "idx_scan_total", "description", 0},
+				prometheus.CounterValue, "idx_scan", nil, []string{"relname"}, labels{}, filter.New(),
+			),
+			valid: false,
+		},
+		{
+			// label column is absent
+			desc: newCustomTypedDesc(
+				descOpts{"postgres", "table", "seq_scan_total", "description", 0},
+				prometheus.CounterValue, "seq_scan", nil, []string{"database", "relname"}, labels{}, filter.New(),
+			),
+			valid: false,
+		},
+		{
+			// labeled value column is absent
+			desc: newCustomTypedDesc(
+				descOpts{"postgres", "table", "tuples_total", "description", 0},
+				prometheus.CounterValue, "", map[string][]string{"tuples": {"inserted", "deleted"}}, []string{"relname", "tuples"}, labels{}, filter.New(),
+			),
+			valid: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		err := checkDescColumns(tc.desc, colnames, tc.addDatabaseLabel)
+		if tc.valid {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func Test_parseNullableFloat(t *testing.T) {
+	v, err := parseNullableFloat(sql.NullString{String: "123.45", Valid: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 123.45, v)
+
+	v, err = parseNullableFloat(sql.NullString{Valid: false})
+	assert.NoError(t, err)
+	assert.True(t, math.IsNaN(v))
+
+	_, err = parseNullableFloat(sql.NullString{String: "invalid", Valid: true})
+	assert.Error(t, err)
+}
+
 func Test_needMultipleUpdate(t *testing.T) {
 	testcases := []struct {
 		sets []typedDescSet
@@ -627,3 +786,24 @@ func Test_parseLabeledValue(t *testing.T) {
 		assert.Equal(t, tc.s2, s2)
 	}
 }
+
+func Test_readFloatFile(t *testing.T) {
+	v, err := readFloatFile("testdata/proc/sys/net/netfilter/nf_conntrack_max")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(262144), v)
+
+	v, err = readFloatFile("testdata/sys/class/hwmon/hwmon1/temp1_input")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(38850), v)
+
+	_, err = readFloatFile("testdata/proc/sys/net/netfilter/unknown")
+	assert.Error(t, err)
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = readFloatFile("testdata/proc/sys/kernel/random/invalid")
+	assert.Error(t, err)
+
+	// File with multiple values.
+	_, err = readFloatFile("testdata/proc/sys/fs/file-nr")
+	assert.Error(t, err)
+}
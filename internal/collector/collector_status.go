@@ -0,0 +1,100 @@
+package collector
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Credentials could be a part of errors text, e.g. when connection string is mentioned, they are masked before
+// exposing errors.
+var (
+	passwordKeywordRE  = regexp.MustCompile(`(?i)(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+	passwordUserinfoRE = regexp.MustCompile(`(://[^:/@\s]*):[^@\s]*@`)
+)
+
+// Status describes state of the service and its collectors, it is used for diagnostic purposes.
+type Status struct {
+	Error      string            `json:"error,omitempty"` // last error of connecting to the service, empty if the service is available
+	Collectors []CollectorStatus `json:"collectors"`
+}
+
+// CollectorStatus describes state of the collector and result of its last run.
+type CollectorStatus struct {
+	Name         string     `json:"name"`
+	Enabled      bool       `json:"enabled"`
+	LastRun      *time.Time `json:"last_run,omitempty"`
+	LastDuration float64    `json:"last_duration_seconds"`
+	LastError    string     `json:"last_error,omitempty"`
+	Skipped      string     `json:"skipped,omitempty"` // reason of skipping the collector during last run
+}
+
+// statusState keeps results of the last runs of the service's collectors.
+type statusState struct {
+	mu         sync.Mutex
+	err        string
+	collectors map[string]CollectorStatus
+}
+
+// newStatusState creates new statusState.
+func newStatusState() *statusState {
+	return &statusState{collectors: map[string]CollectorStatus{}}
+}
+
+// update remembers result of the collector's run started at passed time.
+func (s *statusState) update(name string, start time.Time, duration time.Duration, err error, skipped string) {
+	st := CollectorStatus{
+		Name:         name,
+		Enabled:      true,
+		LastRun:      &start,
+		LastDuration: duration.Seconds(),
+		Skipped:      skipped,
+	}
+
+	if err != nil {
+		st.LastError = maskCredentials(err.Error())
+	}
+
+	s.mu.Lock()
+	s.collectors[name] = st
+	s.mu.Unlock()
+}
+
+// setError remembers error of connecting to the service, nil error means the service is available.
+func (s *statusState) setError(err error) {
+	var msg string
+	if err != nil {
+		msg = maskCredentials(err.Error())
+	}
+
+	s.mu.Lock()
+	s.err = msg
+	s.mu.Unlock()
+}
+
+// Status returns state of the service and its collectors sorted by name. Collectors which have not been run yet are
+// returned without results.
+func (n PgscvCollector) Status() Status {
+	n.status.mu.Lock()
+	defer n.status.mu.Unlock()
+
+	collectors := make([]CollectorStatus, 0, len(n.Collectors))
+	for name := range n.Collectors {
+		st, ok := n.status.collectors[name]
+		if !ok {
+			st = CollectorStatus{Name: name, Enabled: true}
+		}
+		collectors = append(collectors, st)
+	}
+
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].Name < collectors[j].Name })
+
+	return Status{Error: n.status.err, Collectors: collectors}
+}
+
+// maskCredentials replaces passwords in passed text, both specified as 'password=...' keyword and as userinfo of URL.
+func maskCredentials(s string) string {
+	s = passwordKeywordRE.ReplaceAllString(s, "${1}*****")
+	return passwordUserinfoRE.ReplaceAllString(s, "${1}:*****@")
+}
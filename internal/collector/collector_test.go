@@ -1,9 +1,18 @@
 package collector
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgconn"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"net"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestPgscvCollector_Collect(t *testing.T) {
@@ -35,4 +44,315 @@ func TestPgscvCollector_Collect(t *testing.T) {
 	// Check metrics slice should not be nil or empty.
 	assert.NotNil(t, metrics)
 	assert.Greater(t, len(metrics), 0)
+
+	// Run collectors sequentially.
+	c.Config.Concurrency = 1
+	ch = make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	var n int
+	for range ch {
+		n++
+	}
+	assert.Greater(t, n, 0)
+
+	// Check collectors stats are sent.
+	var found bool
+	for _, m := range metrics {
+		if m.Desc().String() == c.successDesc.desc.String() {
+			found = true
+			break
+		}
+	}
+	assert.True(t, found)
+}
+
+// testCollector is the configurable collector used for testing collecting logic.
+type testCollector struct {
+	delay time.Duration
+	err   error
+}
+
+// Update implements Collector interface.
+func (c testCollector) Update(ctx context.Context, _ Config, ch chan<- prometheus.Metric) error {
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	ch <- prometheus.MustNewConstMetric(prometheus.NewDesc("test", "test", nil, nil), prometheus.GaugeValue, 1)
+	return c.err
+}
+
+func Test_collect(t *testing.T) {
+	errTest := errors.New("test")
+	testcases := []struct {
+		name      string
+		timeout   time.Duration
+		cancelled bool
+		c         Collector
+		wantErr   error
+		metrics   int
+	}{
+		{name: "no timeout", c: testCollector{}, metrics: 1},
+		{name: "in time", timeout: time.Second, c: testCollector{delay: 10 * time.Millisecond}, metrics: 1},
+		{name: "with error", timeout: time.Second, c: testCollector{err: errTest}, wantErr: errTest, metrics: 1},
+		{name: "timed out", timeout: 10 * time.Millisecond, c: testCollector{delay: time.Second}, wantErr: errCollectorTimeout},
+		{name: "cancelled", timeout: time.Second, cancelled: true, c: testCollector{delay: time.Second}, wantErr: context.Canceled},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			if tc.cancelled {
+				cancel()
+			}
+			defer cancel()
+
+			ch := make(chan prometheus.Metric, 10)
+			assert.Equal(t, tc.wantErr, collect(ctx, "test", Config{CollectorTimeout: tc.timeout}, tc.c, ch))
+			assert.Len(t, ch, tc.metrics)
+		})
+	}
+}
+
+func TestPgscvCollector_Collect_skipped(t *testing.T) {
+	f := Factories{
+		"test/skipped": func(labels, model.CollectorSettings) (Collector, error) {
+			return testCollector{err: fmt.Errorf("%w: test", errInsufficientPrivilege)}, nil
+		},
+	}
+	c, err := NewPgscvCollector("test:0", f, Config{})
+	assert.NoError(t, err)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	var skipped, scrapeErrors dto.Metric
+	for m := range ch {
+		switch m.Desc().String() {
+		case c.skippedDesc.desc.String():
+			assert.NoError(t, m.Write(&skipped))
+		case c.scrapeErrorsDesc.desc.String():
+			assert.NoError(t, m.Write(&scrapeErrors))
+		}
+	}
+
+	assert.Equal(t, float64(1), skipped.GetGauge().GetValue())
+	assert.Equal(t, float64(0), scrapeErrors.GetCounter().GetValue())
+	assert.True(t, c.skips["test/skipped"])
+}
+
+func Test_skipReason(t *testing.T) {
+	privErr := &pgconn.PgError{Code: "42501"}
+
+	assert.Equal(t, "", skipReason(Config{}, nil))
+	assert.Equal(t, "", skipReason(Config{}, errors.New("test")))
+	assert.Equal(t, skipReasonInsufficientPrivilege, skipReason(Config{}, errInsufficientPrivilege))
+	assert.Equal(t, skipReasonInsufficientPrivilege, skipReason(Config{}, privErr))
+
+	// Privileged queries are failures in managed mode.
+	assert.Equal(t, "", skipReason(Config{Managed: true}, privErr))
+	assert.Equal(t, skipReasonInsufficientPrivilege, skipReason(Config{Managed: true}, errInsufficientPrivilege))
+}
+
+func TestNewPgscvCollector_labels(t *testing.T) {
+	f := Factories{}
+	f.RegisterSystemCollectors([]string{})
+	c, err := NewPgscvCollector("test:0", f, Config{Labels: map[string]string{"cluster": "main", "service_id": "invalid"}})
+	assert.NoError(t, err)
+
+	desc := c.anchorDesc.desc.String()
+	assert.Contains(t, desc, `cluster="main"`)
+	assert.Contains(t, desc, `service_id="test:0"`)
+}
+
+func TestNewPgscvCollector_labelsConflict(t *testing.T) {
+	f := Factories{}
+	f.RegisterSystemCollectors([]string{})
+	_, err := NewPgscvCollector("test:1", f, Config{Labels: map[string]string{"environment": "prod", "device": "sda", "mountpoint": "/"}})
+	assert.EqualError(t, err, "labels 'device', 'mountpoint' conflict with labels of collected metrics")
+
+	// Labels of self-metrics are checked too.
+	_, err = NewPgscvCollector("test:1", f, Config{Labels: map[string]string{"collector": "x", "reason": "y"}})
+	assert.EqualError(t, err, "labels 'collector', 'reason' conflict with labels of collected metrics")
+
+	// Conflicts of previous check don't affect next one.
+	_, err = NewPgscvCollector("test:1", f, Config{Labels: map[string]string{"environment": "prod"}})
+	assert.NoError(t, err)
+}
+
+func TestFactories_Names(t *testing.T) {
+	f := Factories{}
+	f.RegisterPatroniCollectors([]string{})
+	assert.Equal(t, []string{"patroni/common", "patroni/pgscv"}, f.Names())
+}
+
+func TestPgscvCollector_Collect_unavailable(t *testing.T) {
+	f := Factories{}
+	f.RegisterPostgresCollectors([]string{})
+	c, err := NewPgscvCollector("test:0", f, Config{ServiceType: "postgres", ConnString: "host=127.0.0.1 port=1 user=pgscv dbname=pgscv_fixtures"})
+	assert.NoError(t, err)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	var n int
+	for range ch {
+		n++
+	}
+
+	// Reconnects and up metrics, duration, success and timeouts metrics for every collector and scrapes metrics.
+	assert.Equal(t, 2+3*len(f)+2, n)
+
+	assert.NotEmpty(t, c.Status().Error)
+
+	// Next scrape is made during backoff, connection is not attempted and the same metrics are sent.
+	_, ok := c.conn.backoff(time.Now())
+	assert.True(t, ok)
+
+	ch = make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	n = 0
+	for range ch {
+		n++
+	}
+	assert.Equal(t, 2+3*len(f)+2, n)
+}
+
+func TestPgscvCollector_Collect_pgbouncerUnavailable(t *testing.T) {
+	f := Factories{}
+	f.RegisterPgbouncerCollectors([]string{})
+	c, err := NewPgscvCollector("test:0", f, Config{ServiceType: "pgbouncer", ConnString: "host=127.0.0.1 port=1 user=pgscv dbname=pgbouncer"})
+	assert.NoError(t, err)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	var up []prometheus.Metric
+	var n int
+	for m := range ch {
+		if strings.Contains(m.Desc().String(), `"pgbouncer_up"`) {
+			up = append(up, m)
+		}
+		n++
+	}
+
+	// Up metric, duration, success and timeouts metrics for every collector and scrapes metrics.
+	assert.Equal(t, 1+3*len(f)+2, n)
+	assert.Len(t, up, 1)
+
+	var metric dto.Metric
+	assert.NoError(t, up[0].Write(&metric))
+	assert.Equal(t, float64(0), metric.GetGauge().GetValue())
+}
+
+func TestPgscvCollector_checkService_timeout(t *testing.T) {
+	// Service accepts connections but never responds.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				_ = conn.Close()
+			}
+		}()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+
+	connStr := fmt.Sprintf("host=127.0.0.1 port=%d user=pgscv dbname=pgscv_fixtures", ln.Addr().(*net.TCPAddr).Port)
+	c, err := NewPgscvCollector("test:0", Factories{}, Config{ServiceType: "postgres", ConnString: connStr, CollectorTimeout: 200 * time.Millisecond})
+	assert.NoError(t, err)
+	defer c.Stop()
+
+	// Service check is bounded by collectors timeout.
+	start := time.Now()
+	_, ok := c.checkService(make(chan prometheus.Metric, 2))
+	assert.False(t, ok)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.NotEmpty(t, c.Status().Error)
+}
+
+func Test_connState_update(t *testing.T) {
+	s := &connState{}
+	assert.Equal(t, float64(0), s.update(true))
+	assert.Equal(t, float64(0), s.update(false))
+	assert.Equal(t, float64(0), s.update(false))
+	assert.Equal(t, float64(1), s.update(true))
+	assert.Equal(t, float64(1), s.update(true))
+	assert.Equal(t, float64(1), s.update(false))
+	assert.Equal(t, float64(2), s.update(true))
+}
+
+func Test_connState_backoff(t *testing.T) {
+	s := &connState{}
+	_, ok := s.backoff(time.Now())
+	assert.False(t, ok)
+
+	s.update(false)
+	delay, ok := s.backoff(time.Now())
+	assert.True(t, ok)
+	assert.LessOrEqual(t, delay, connBackoffMin)
+
+	_, ok = s.backoff(time.Now().Add(connBackoffMin))
+	assert.False(t, ok)
+
+	s.update(false)
+	_, ok = s.backoff(time.Now().Add(connBackoffMin))
+	assert.True(t, ok)
+
+	// Backoff is reset after successful attempt.
+	s.update(true)
+	_, ok = s.backoff(time.Now())
+	assert.False(t, ok)
+}
+
+func Test_connBackoffDelay(t *testing.T) {
+	assert.Equal(t, connBackoffMin, connBackoffDelay(1))
+	assert.Equal(t, 2*connBackoffMin, connBackoffDelay(2))
+	assert.Equal(t, 4*connBackoffMin, connBackoffDelay(3))
+	assert.Equal(t, connBackoffMax, connBackoffDelay(10))
+	assert.Equal(t, connBackoffMax, connBackoffDelay(1000))
+}
+
+func Test_scrapeState_update(t *testing.T) {
+	s := &scrapeState{}
+
+	total, errs := s.update(false)
+	assert.Equal(t, float64(1), total)
+	assert.Equal(t, float64(0), errs)
+
+	total, errs = s.update(true)
+	assert.Equal(t, float64(2), total)
+	assert.Equal(t, float64(1), errs)
+
+	total, errs = s.update(false)
+	assert.Equal(t, float64(3), total)
+	assert.Equal(t, float64(1), errs)
 }
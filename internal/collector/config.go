@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -23,6 +25,8 @@ type Config struct {
 	ConnString string
 	// BaseURL defines a URL string for connecting to HTTP service
 	BaseURL string
+	// Labels defines extra constant labels attached to all metrics of the service.
+	Labels map[string]string
 	// NoTrackMode controls collector to gather and send sensitive information, such as queries texts.
 	NoTrackMode bool
 	// postgresServiceConfig defines collector's options specific for Postgres service
@@ -31,6 +35,39 @@ type Config struct {
 	DatabasesRE *regexp.Regexp
 	// Settings defines collectors settings propagated from main YAML configuration.
 	Settings model.CollectorsSettings
+	// ProcfsPath defines path where procfs is mounted, by default /proc.
+	ProcfsPath string
+	// SysfsPath defines path where sysfs is mounted, by default /sys.
+	SysfsPath string
+	// CollectorTimeout defines max time allowed to a single collector for collecting metrics. Zero means no timeout.
+	CollectorTimeout time.Duration
+	// Concurrency defines max number of collectors running concurrently during scrape. Zero means no limit.
+	Concurrency int
+	// serviceID defines ID of the service, connections made by collectors are pooled on behalf of the service.
+	serviceID string
+	// Managed defines the service is managed by cloud provider (e.g. AWS RDS). Its host is not accessible and only
+	// functions granted to pg_monitor role could be used.
+	Managed bool
+}
+
+// procfsPath returns path to the passed procfs file relative to configured procfs mountpoint.
+func (cfg Config) procfsPath(elems ...string) string {
+	root := cfg.ProcfsPath
+	if root == "" {
+		root = "/proc"
+	}
+
+	return filepath.Join(append([]string{root}, elems...)...)
+}
+
+// sysfsPath returns path to the passed sysfs file relative to configured sysfs mountpoint.
+func (cfg Config) sysfsPath(elems ...string) string {
+	root := cfg.SysfsPath
+	if root == "" {
+		root = "/sys"
+	}
+
+	return filepath.Join(append([]string{root}, elems...)...)
 }
 
 // postgresServiceConfig defines Postgres-specific stuff required during collecting Postgres metrics.
@@ -47,6 +84,10 @@ type postgresServiceConfig struct {
 	dataDirectory string
 	// loggingCollector defines value of 'logging_collector' GUC.
 	loggingCollector bool
+	// superuser defines the role used for connecting to the service is superuser.
+	superuser bool
+	// pgMonitor defines the role used for connecting to the service is a member of pg_monitor role.
+	pgMonitor bool
 	// pgStatStatements defines is pg_stat_statements available in shared_preload_libraries and available for queries
 	pgStatStatements bool
 	// pgStatStatementsDatabase defines the database name where pg_stat_statements is available
@@ -55,8 +96,34 @@ type postgresServiceConfig struct {
 	pgStatStatementsSchema string
 }
 
+// checkPgbouncerService connects to Pgbouncer and runs a query to make sure the service is available.
+func checkPgbouncerService(ctx context.Context, connStr string) error {
+	if connStr == "" {
+		return nil
+	}
+
+	conn, err := store.NewContext(ctx, connStr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.QueryContext(ctx, "SHOW VERSION")
+	return err
+}
+
+// postgresPrivilegesQuery checks the role is superuser and member of pg_monitor role.
+const postgresPrivilegesQuery = "SELECT rolsuper, EXISTS (SELECT 1 FROM pg_roles m WHERE m.rolname = 'pg_monitor' " +
+	"AND pg_has_role(current_user, m.oid, 'MEMBER')) FROM pg_roles WHERE rolname = current_user"
+
+// monitorPrivileges returns true if the role has privileges required for using server-side monitoring functions,
+// such as pg_ls_waldir(), pg_ls_tmpdir(), pg_current_logfile() and others.
+func (cfg postgresServiceConfig) monitorPrivileges() bool {
+	return cfg.superuser || cfg.pgMonitor
+}
+
 // newPostgresServiceConfig defines new config for Postgres-based collectors.
-func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
+func newPostgresServiceConfig(ctx context.Context, connStr string) (postgresServiceConfig, error) {
 	var config = postgresServiceConfig{}
 
 	// Return empty config if empty connection string.
@@ -72,7 +139,7 @@ func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
 	// Determine is service running locally.
 	config.localService = isAddressLocal(pgconfig.Host)
 
-	conn, err := store.NewWithConfig(pgconfig)
+	conn, err := store.NewWithConfigContext(ctx, pgconfig)
 	if err != nil {
 		return config, err
 	}
@@ -81,7 +148,7 @@ func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
 	var setting string
 
 	// Get Postgres block size.
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'block_size'").Scan(&setting)
+	err = conn.Conn().QueryRow(ctx, "SELECT setting FROM pg_settings WHERE name = 'block_size'").Scan(&setting)
 	if err != nil {
 		return config, fmt.Errorf("failed to get block_size setting from pg_settings, %s, please check user grants", err)
 	}
@@ -93,7 +160,7 @@ func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
 	config.blockSize = bsize
 
 	// Get Postgres WAL segment size.
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'wal_segment_size'").Scan(&setting)
+	err = conn.Conn().QueryRow(ctx, "SELECT setting FROM pg_settings WHERE name = 'wal_segment_size'").Scan(&setting)
 	if err != nil {
 		return config, fmt.Errorf("failed to get wal_segment_size setting from pg_settings, %s, please check user grants", err)
 	}
@@ -105,7 +172,7 @@ func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
 	config.walSegmentSize = walSegSize
 
 	// Get Postgres server version
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'server_version_num'").Scan(&setting)
+	err = conn.Conn().QueryRow(ctx, "SELECT setting FROM pg_settings WHERE name = 'server_version_num'").Scan(&setting)
 	if err != nil {
 		return config, fmt.Errorf("failed to get server_version_num setting from pg_settings, %s, please check user grants", err)
 	}
@@ -121,7 +188,7 @@ func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
 	config.serverVersionNum = version
 
 	// Get Postgres data directory
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'data_directory'").Scan(&setting)
+	err = conn.Conn().QueryRow(ctx, "SELECT setting FROM pg_settings WHERE name = 'data_directory'").Scan(&setting)
 	if err != nil {
 		return config, fmt.Errorf("failed to get data_directory setting from pg_settings, %s, please check user grants", err)
 	}
@@ -129,7 +196,7 @@ func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
 	config.dataDirectory = setting
 
 	// Get setting of 'logging_collector' GUC.
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'logging_collector'").Scan(&setting)
+	err = conn.Conn().QueryRow(ctx, "SELECT setting FROM pg_settings WHERE name = 'logging_collector'").Scan(&setting)
 	if err != nil {
 		return config, fmt.Errorf("failed to get logging_collector setting from pg_settings, %s, please check user grants", err)
 	}
@@ -138,8 +205,18 @@ func newPostgresServiceConfig(connStr string) (postgresServiceConfig, error) {
 		config.loggingCollector = true
 	}
 
+	// Check role's privileges, pg_monitor role is available since Postgres 10.
+	err = conn.Conn().QueryRow(ctx, postgresPrivilegesQuery).Scan(&config.superuser, &config.pgMonitor)
+	if err != nil {
+		return config, fmt.Errorf("failed to check role privileges, %s", err)
+	}
+
+	if !config.monitorPrivileges() {
+		log.Warnln("role is neither superuser nor member of pg_monitor, collectors which require these privileges will be skipped")
+	}
+
 	// Discover pg_stat_statements.
-	exists, database, schema, err := discoverPgStatStatements(connStr)
+	exists, database, schema, err := discoverPgStatStatements(ctx, connStr)
 	if err != nil {
 		return config, err
 	}
@@ -186,19 +263,19 @@ func isAddressLocal(addr string) bool {
 }
 
 // discoverPgStatStatements discovers pg_stat_statements, what database and schema it is installed.
-func discoverPgStatStatements(connStr string) (bool, string, string, error) {
+func discoverPgStatStatements(ctx context.Context, connStr string) (bool, string, string, error) {
 	pgconfig, err := pgx.ParseConfig(connStr)
 	if err != nil {
 		return false, "", "", err
 	}
 
-	conn, err := store.NewWithConfig(pgconfig)
+	conn, err := store.NewWithConfigContext(ctx, pgconfig)
 	if err != nil {
 		return false, "", "", err
 	}
 
 	var setting string
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'shared_preload_libraries'").Scan(&setting)
+	err = conn.Conn().QueryRow(ctx, "SELECT setting FROM pg_settings WHERE name = 'shared_preload_libraries'").Scan(&setting)
 	if err != nil {
 		conn.Close()
 		return false, "", "", err
@@ -211,7 +288,7 @@ func discoverPgStatStatements(connStr string) (bool, string, string, error) {
 	}
 
 	// Check for pg_stat_statements in default database specified in connection string.
-	if schema := extensionInstalledSchema(conn, "pg_stat_statements"); schema != "" {
+	if schema := extensionInstalledSchema(ctx, conn, "pg_stat_statements"); schema != "" {
 		conn.Close()
 		return true, conn.Conn().Config().Database, schema, nil
 	}
@@ -221,7 +298,7 @@ func discoverPgStatStatements(connStr string) (bool, string, string, error) {
 	// and we have to walk through all database and looking for it.
 
 	// Get databases list from current connection.
-	databases, err := listDatabases(conn)
+	databases, err := listDatabases(ctx, conn)
 	if err != nil {
 		conn.Close()
 		return false, "", "", err
@@ -233,14 +310,14 @@ func discoverPgStatStatements(connStr string) (bool, string, string, error) {
 	// Establish connection to each database in the list and check where pg_stat_statements is installed.
 	for _, d := range databases {
 		pgconfig.Database = d
-		conn, err := store.NewWithConfig(pgconfig)
+		conn, err := store.NewWithConfigContext(ctx, pgconfig)
 		if err != nil {
 			log.Warnf("connect to database '%s' failed: %s; skip", pgconfig.Database, err)
 			continue
 		}
 
 		// If pg_stat_statements found, update source and return connection.
-		if schema := extensionInstalledSchema(conn, "pg_stat_statements"); schema != "" {
+		if schema := extensionInstalledSchema(ctx, conn, "pg_stat_statements"); schema != "" {
 			conn.Close()
 			return true, conn.Conn().Config().Database, schema, nil
 		}
@@ -256,12 +333,12 @@ func discoverPgStatStatements(connStr string) (bool, string, string, error) {
 }
 
 // extensionInstalledSchema returns schema name where extension is installed, or empty if not installed.
-func extensionInstalledSchema(db *store.DB, name string) string {
+func extensionInstalledSchema(ctx context.Context, db *store.DB, name string) string {
 	log.Debugf("check %s extension availability", name)
 
 	var schema string
 	err := db.Conn().
-		QueryRow(context.Background(), "SELECT extnamespace::regnamespace FROM pg_extension WHERE extname = $1", name).
+		QueryRow(ctx, "SELECT extnamespace::regnamespace FROM pg_extension WHERE extname = $1", name).
 		Scan(&schema)
 	if err != nil && err != pgx.ErrNoRows {
 		log.Errorf("failed to check extensions '%s' in pg_extension: %s", name, err)
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/cherts/pgscv/internal/store"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -18,7 +19,7 @@ func Test_newPostgresServiceConfig(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := newPostgresServiceConfig(tc.connStr)
+			_, err := newPostgresServiceConfig(context.Background(), tc.connStr)
 			if tc.valid {
 				assert.NoError(t, err)
 			} else {
@@ -60,7 +61,7 @@ func Test_discoverPgStatStatements(t *testing.T) {
 	}
 
 	for _, tc := range testcases {
-		exists, database, schema, err := discoverPgStatStatements(tc.connstr)
+		exists, database, schema, err := discoverPgStatStatements(context.Background(), tc.connstr)
 		if tc.valid {
 			assert.True(t, exists)
 			assert.Equal(t, "pgscv_fixtures", database)
@@ -75,7 +76,17 @@ func Test_discoverPgStatStatements(t *testing.T) {
 func Test_extensionInstalledSchema(t *testing.T) {
 	conn := store.NewTest(t)
 
-	assert.Equal(t, extensionInstalledSchema(conn, "plpgsql"), "pg_catalog")
-	assert.Equal(t, extensionInstalledSchema(conn, "invalid"), "")
+	assert.Equal(t, extensionInstalledSchema(context.Background(), conn, "plpgsql"), "pg_catalog")
+	assert.Equal(t, extensionInstalledSchema(context.Background(), conn, "invalid"), "")
 	conn.Close()
 }
+
+func TestConfig_procfsPath(t *testing.T) {
+	assert.Equal(t, "/proc/diskstats", Config{}.procfsPath("diskstats"))
+	assert.Equal(t, "/host/proc/diskstats", Config{ProcfsPath: "/host/proc"}.procfsPath("diskstats"))
+}
+
+func TestConfig_sysfsPath(t *testing.T) {
+	assert.Equal(t, "/sys/block/*", Config{}.sysfsPath("block", "*"))
+	assert.Equal(t, "/host/sys/block", Config{SysfsPath: "/host/sys/"}.sysfsPath("block"))
+}
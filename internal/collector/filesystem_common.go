@@ -17,6 +17,17 @@ type mount struct {
 	options    string
 }
 
+// isReadonly returns true if filesystem is mounted in read-only mode.
+func (m mount) isReadonly() bool {
+	for _, opt := range strings.Split(m.options, ",") {
+		if opt == "ro" {
+			return true
+		}
+	}
+
+	return false
+}
+
 // parseProcMounts parses /proc/mounts and returns slice of mounted filesystems properties.
 func parseProcMounts(r io.Reader) ([]mount, error) {
 	log.Debug("parse mounted filesystems")
@@ -50,3 +50,10 @@ func Test_truncateDeviceName(t *testing.T) {
 		assert.Equal(t, tc.want, truncateDeviceName(tc.path))
 	}
 }
+
+func Test_mount_isReadonly(t *testing.T) {
+	assert.True(t, mount{options: "ro,relatime"}.isReadonly())
+	assert.True(t, mount{options: "relatime,ro"}.isReadonly())
+	assert.False(t, mount{options: "rw,relatime,errors=remount-ro"}.isReadonly())
+	assert.False(t, mount{options: ""}.isReadonly())
+}
@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"os"
+	"sync"
+)
+
+type conntrackCollector struct {
+	entries     typedDesc
+	limit       typedDesc
+	unavailable sync.Once
+}
+
+// NewConntrackCollector returns a new Collector exposing netfilter connection tracking usage.
+func NewConntrackCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &conntrackCollector{
+		entries: newBuiltinTypedDesc(
+			descOpts{"node", "nf_conntrack", "entries", "Number of currently allocated flow entries for connection tracking.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		limit: newBuiltinTypedDesc(
+			descOpts{"node", "nf_conntrack", "entries_limit", "Maximum size of connection tracking table.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update implements Collector and exposes connection tracking metrics from /proc/sys/net/netfilter.
+func (c *conntrackCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
+	entries, err := readFloatFile(config.procfsPath("sys", "net", "netfilter", "nf_conntrack_count"))
+	if err != nil {
+		// Connection tracking module is not loaded, skip collecting silently.
+		if os.IsNotExist(err) {
+			c.unavailable.Do(func() {
+				log.Infoln("connection tracking is not available, skip collecting conntrack stats")
+			})
+			return nil
+		}
+		return fmt.Errorf("get conntrack entries failed: %s", err)
+	}
+
+	limit, err := readFloatFile(config.procfsPath("sys", "net", "netfilter", "nf_conntrack_max"))
+	if err != nil {
+		return fmt.Errorf("get conntrack limit failed: %s", err)
+	}
+
+	ch <- c.entries.newConstMetric(entries)
+	ch <- c.limit.newConstMetric(limit)
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConntrackCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"node_nf_conntrack_entries",
+			"node_nf_conntrack_entries_limit",
+		},
+		collector: NewConntrackCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func TestConntrackCollector_Update_testdata(t *testing.T) {
+	c, err := NewConntrackCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	for path, want := range map[string]int{"testdata/proc": 2, "testdata/invalid": 0} {
+		assert.Len(t, collectMetrics(t, c, Config{ProcfsPath: path}), want)
+	}
+}
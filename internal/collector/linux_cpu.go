@@ -3,6 +3,7 @@ package collector
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -15,36 +16,50 @@ import (
 	"strings"
 )
 
+// cpuDefaultSysticks defines default number of clock ticks per second (USER_HZ), used when it can't be determined.
+const cpuDefaultSysticks = 100
+
 type cpuCollector struct {
 	systicks float64
+	perCPU   bool
 	cpu      typedDesc
+	cpuCore  typedDesc
 	cpuAll   typedDesc
 	cpuGuest typedDesc
 	uptime   typedDesc
 	idletime typedDesc
+	throttle typedDesc
+	freq     typedDesc
 }
 
 // NewCPUCollector returns a new Collector exposing kernel/system statistics.
 func NewCPUCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
-	cmdOutput, err := exec.Command("getconf", "CLK_TCK").Output()
+	systicks, err := getSysticks()
 	if err != nil {
-		return nil, fmt.Errorf("determine clock frequency failed: %s", err)
+		log.Warnf("determine clock frequency failed: %s; use default %d", err, cpuDefaultSysticks)
+		systicks = cpuDefaultSysticks
 	}
 
-	value := strings.TrimSpace(string(cmdOutput))
-	systicks, err := strconv.ParseFloat(value, 64)
+	perCPU, err := settings.Options.Bool("per_cpu", false)
 	if err != nil {
-		return nil, fmt.Errorf("invalid input: parse '%s' failed: %w", value, err)
+		return nil, err
 	}
 
 	c := &cpuCollector{
 		systicks: systicks,
+		perCPU:   perCPU,
 		cpu: newBuiltinTypedDesc(
 			descOpts{"node", "cpu", "seconds_total", "Seconds the CPUs spent in each mode.", 0},
 			prometheus.CounterValue,
 			[]string{"mode"}, constLabels,
 			settings.Filters,
 		),
+		cpuCore: newBuiltinTypedDesc(
+			descOpts{"node", "cpu", "core_seconds_total", "Seconds each CPU core spent in each mode.", 0},
+			prometheus.CounterValue,
+			[]string{"cpu", "mode"}, constLabels,
+			settings.Filters,
+		),
 		cpuAll: newBuiltinTypedDesc(
 			descOpts{"node", "cpu", "seconds_all_total", "Seconds the CPUs spent in all modes.", 0},
 			prometheus.CounterValue,
@@ -69,13 +84,25 @@ func NewCPUCollector(constLabels labels, settings model.CollectorSettings) (Coll
 			nil, constLabels,
 			settings.Filters,
 		),
+		throttle: newBuiltinTypedDesc(
+			descOpts{"node", "cpu", "core_throttles_total", "Number of times this CPU core has been throttled because of high temperature.", 0},
+			prometheus.CounterValue,
+			[]string{"package", "core"}, constLabels,
+			settings.Filters,
+		),
+		freq: newBuiltinTypedDesc(
+			descOpts{"node", "cpu", "frequency_hertz", "Current frequency of CPU core.", 1000},
+			prometheus.GaugeValue,
+			[]string{"cpu"}, constLabels,
+			settings.Filters,
+		),
 	}
 	return c, nil
 }
 
 // Update implements Collector and exposes cpu related metrics from /proc/stat and /sys/.../cpu/.
-func (c *cpuCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
-	stat, err := getCPUStat(c.systicks)
+func (c *cpuCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
+	stat, err := getCPUStat(config.procfsPath("stat"), c.systicks)
 	if err != nil {
 		return fmt.Errorf("collect cpu usage stats failed: %s; skip", err)
 	}
@@ -101,6 +128,43 @@ func (c *cpuCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 	ch <- c.cpuGuest.newConstMetric(stat.guest, "user")
 	ch <- c.cpuGuest.newConstMetric(stat.guestnice, "nice")
 
+	// Per-core stats are optional, because on many-cores systems it produces significant number of series.
+	// Guest time is already accounted in user and nice modes, so it is not exposed per-core.
+	if c.perCPU {
+		cores, err := getCPUCoresStat(config.procfsPath("stat"), c.systicks)
+		if err != nil {
+			return fmt.Errorf("collect per-cpu usage stats failed: %s; skip", err)
+		}
+
+		for cpu, s := range cores {
+			ch <- c.cpuCore.newConstMetric(s.user, cpu, "user")
+			ch <- c.cpuCore.newConstMetric(s.nice, cpu, "nice")
+			ch <- c.cpuCore.newConstMetric(s.system, cpu, "system")
+			ch <- c.cpuCore.newConstMetric(s.idle, cpu, "idle")
+			ch <- c.cpuCore.newConstMetric(s.iowait, cpu, "iowait")
+			ch <- c.cpuCore.newConstMetric(s.irq, cpu, "irq")
+			ch <- c.cpuCore.newConstMetric(s.softirq, cpu, "softirq")
+			ch <- c.cpuCore.newConstMetric(s.steal, cpu, "steal")
+		}
+	}
+
+	// Thermal stats are not available on virtual machines and on some hardware, collect whatever is available.
+	// Throttles are counted per physical core, hence number of series is not significant and these are always
+	// collected. Frequencies are per-CPU and collected only when per-core stats are enabled.
+	thermal, err := getCPUThermalStats(config.sysfsPath("devices", "system", "cpu", "cpu[0-9]*"))
+	if err != nil {
+		log.Warnf("collect cpu thermal stats failed: %s; skip", err)
+	} else {
+		for core, v := range thermal.throttles {
+			ch <- c.throttle.newConstMetric(v, core.pkg, core.core)
+		}
+		if c.perCPU {
+			for cpu, v := range thermal.freqs {
+				ch <- c.freq.newConstMetric(v, cpu)
+			}
+		}
+	}
+
 	// Up and idle time values from /proc/uptime. Idle time accounted as summary for all cpu cores.
 	ch <- c.uptime.newConstMetric(uptime)
 	ch <- c.idletime.newConstMetric(idletime)
@@ -122,9 +186,25 @@ type cpuStat struct {
 	guestnice float64
 }
 
+// getSysticks returns number of clock ticks per second (USER_HZ) used by kernel for accounting CPU time.
+func getSysticks() (float64, error) {
+	cmdOutput, err := exec.Command("getconf", "CLK_TCK").Output()
+	if err != nil {
+		return 0, err
+	}
+
+	value := strings.TrimSpace(string(cmdOutput))
+	systicks, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid input: parse '%s' failed: %w", value, err)
+	}
+
+	return systicks, nil
+}
+
 // getCPUStat opens stat file and executes parser.
-func getCPUStat(systicks float64) (cpuStat, error) {
-	file, err := os.Open("/proc/stat")
+func getCPUStat(path string, systicks float64) (cpuStat, error) {
+	file, err := os.Open(filepath.Clean(path))
 	if err != nil {
 		return cpuStat{}, err
 	}
@@ -157,6 +237,55 @@ func parseProcCPUStat(r io.Reader, systicks float64) (cpuStat, error) {
 	return cpuStat{}, fmt.Errorf("total cpu stats not found")
 }
 
+// getCPUCoresStat opens stat file and executes per-core stats parser.
+func getCPUCoresStat(path string, systicks float64) (map[string]cpuStat, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	return parseProcCPUCoresStat(file, systicks)
+}
+
+// parseProcCPUCoresStat parses stat file and returns CPU usage stats of each core.
+func parseProcCPUCoresStat(r io.Reader, systicks float64) (map[string]cpuStat, error) {
+	log.Debug("parse per-CPU stats")
+
+	var scanner = bufio.NewScanner(r)
+	var stats = map[string]cpuStat{}
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 2 {
+			log.Debug("CPU stat invalid input: too few values; skip")
+			continue
+		}
+
+		// Looking only for per-CPU stats, e.g. 'cpu0', 'cpu1', etc.
+		if !strings.HasPrefix(parts[0], "cpu") || parts[0] == "cpu" {
+			continue
+		}
+
+		stat, err := parseCPUStat(scanner.Text(), systicks)
+		if err != nil {
+			return nil, err
+		}
+
+		stats[strings.TrimPrefix(parts[0], "cpu")] = stat
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(stats) == 0 {
+		return nil, fmt.Errorf("per-cpu stats not found")
+	}
+
+	return stats, nil
+}
+
 // parseCPUStat parses single line from stats file and returns parsed stats.
 func parseCPUStat(line string, systicks float64) (cpuStat, error) {
 	s := cpuStat{}
@@ -211,3 +340,55 @@ func getProcUptime(procfile string) (float64, float64, error) {
 
 	return up, idle, nil
 }
+
+// cpuCoreID describes physical CPU core.
+type cpuCoreID struct {
+	pkg  string // physical package (socket) id
+	core string // core id within the package
+}
+
+// cpuThermalStats describes thermal related stats of CPUs.
+type cpuThermalStats struct {
+	throttles map[cpuCoreID]float64 // number of thermal throttles of physical cores
+	freqs     map[string]float64    // current frequency (in kHz) of logical CPUs
+}
+
+// getCPUThermalStats reads thermal throttles and frequencies of CPUs from sysfs. Throttles counter is shared by all
+// logical CPUs (hyper-threads) of a physical core, hence it is taken once per core. Missing files are skipped.
+func getCPUThermalStats(path string) (cpuThermalStats, error) {
+	dirs, err := filepath.Glob(path)
+	if err != nil {
+		return cpuThermalStats{}, err
+	}
+
+	stats := cpuThermalStats{throttles: map[cpuCoreID]float64{}, freqs: map[string]float64{}}
+
+	for _, d := range dirs {
+		cpu := strings.TrimPrefix(filepath.Base(d), "cpu")
+
+		if v, err := readFloatFile(filepath.Join(d, "cpufreq", "scaling_cur_freq")); err == nil {
+			stats.freqs[cpu] = v
+		}
+
+		throttles, err := readFloatFile(filepath.Join(d, "thermal_throttle", "core_throttle_count"))
+		if err != nil {
+			continue
+		}
+
+		pkg, err := os.ReadFile(filepath.Join(d, "topology", "physical_package_id"))
+		if err != nil {
+			log.Debugf("read physical package id of cpu%s failed: %s; skip", cpu, err)
+			continue
+		}
+
+		core, err := os.ReadFile(filepath.Join(d, "topology", "core_id"))
+		if err != nil {
+			log.Debugf("read core id of cpu%s failed: %s; skip", cpu, err)
+			continue
+		}
+
+		stats.throttles[cpuCoreID{pkg: strings.TrimSpace(string(pkg)), core: strings.TrimSpace(string(core))}] = throttles
+	}
+
+	return stats, nil
+}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"github.com/cherts/pgscv/internal/model"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"path/filepath"
@@ -16,10 +17,31 @@ func TestCPUCollector_Update(t *testing.T) {
 			"node_uptime_up_seconds_total",
 			"node_uptime_idle_seconds_total",
 		},
+		optional: []string{
+			"node_cpu_core_throttles_total",
+			"node_cpu_frequency_hertz",
+		},
 		collector: NewCPUCollector,
 	}
 
 	pipeline(t, input)
+
+	// Per-CPU stats enabled.
+	input.required = append(input.required, "node_cpu_core_seconds_total")
+	input.collectorSettings = model.CollectorSettings{Options: model.CollectorOptions{"per_cpu": "true"}}
+
+	pipeline(t, input)
+}
+
+func TestNewCPUCollector(t *testing.T) {
+	_, err := NewCPUCollector(labels{}, model.CollectorSettings{Options: model.CollectorOptions{"per_cpu": "invalid"}})
+	assert.Error(t, err)
+}
+
+func Test_getSysticks(t *testing.T) {
+	got, err := getSysticks()
+	assert.NoError(t, err)
+	assert.Greater(t, got, float64(0))
 }
 
 func Test_parseProcCPUStat(t *testing.T) {
@@ -58,6 +80,27 @@ func Test_parseProcCPUStat(t *testing.T) {
 	}
 }
 
+func Test_parseProcCPUCoresStat(t *testing.T) {
+	file, err := os.Open(filepath.Clean("testdata/proc/stat.golden"))
+	assert.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	got, err := parseProcCPUCoresStat(file, 100)
+	assert.NoError(t, err)
+	assert.Len(t, got, 8)
+	assert.Equal(t, cpuStat{
+		user: 3915.44, nice: 4.34, system: 1772.76, idle: 165439.83, iowait: 49.24,
+		irq: 0, softirq: 2002.82, steal: 0, guest: 0, guestnice: 0,
+	}, got["0"])
+
+	file2, err := os.Open(filepath.Clean("testdata/proc/stat.invalid"))
+	assert.NoError(t, err)
+	defer func() { _ = file2.Close() }()
+
+	_, err = parseProcCPUCoresStat(file2, 100)
+	assert.Error(t, err)
+}
+
 func Test_parseCPUStat(t *testing.T) {
 	var testcases = []struct {
 		valid bool
@@ -97,3 +140,19 @@ func Test_getProcUptime(t *testing.T) {
 	_, _, err = getProcUptime("testdata/proc/stat.golden")
 	assert.Error(t, err)
 }
+
+func Test_getCPUThermalStats(t *testing.T) {
+	stats, err := getCPUThermalStats("testdata/sys/devices/system/cpu/cpu[0-9]*")
+	assert.NoError(t, err)
+
+	want := cpuThermalStats{
+		throttles: map[cpuCoreID]float64{{pkg: "0", core: "0"}: 5, {pkg: "0", core: "1"}: 0},
+		freqs:     map[string]float64{"0": 2400000, "1": 2500000, "2": 800000},
+	}
+	assert.Equal(t, want, stats)
+
+	// Thermal stats are not available.
+	stats, err = getCPUThermalStats("testdata/sys/devices.system/node/node[0-9]*")
+	assert.NoError(t, err)
+	assert.Equal(t, cpuThermalStats{throttles: map[cpuCoreID]float64{}, freqs: map[string]float64{}}, stats)
+}
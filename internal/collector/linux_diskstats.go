@@ -3,6 +3,7 @@ package collector
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/cherts/pgscv/internal/filter"
 	"github.com/cherts/pgscv/internal/log"
@@ -12,52 +13,141 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	// Linux always considers sectors to be 512 bytes long independently of the devices real block size.
 	// https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/tree/include/linux/types.h#n117
+	// Sectors counters in /proc/diskstats and device size in /sys/block/<dev>/size are also always accounted in
+	// 512-bytes units, even for 4K-native devices. Hence, values of queue/hw_sector_size or queue/logical_block_size
+	// must not be used for converting these sectors to bytes.
 	diskSectorSize = 512
+
+	// diskstatsDefaultIgnoredDevices defines default pattern of devices which should be ignored (virtual devices and
+	// device partitions). Used when 'device' filter is not specified in collector's settings.
+	diskstatsDefaultIgnoredDevices = `^(ram|loop|fd|sr|(h|s|v|xv)d[a-z]|nvme\d+n\d+p)\d+$`
+
+	// diskstatsDefaultIgnoredVirtualDevices defines default pattern of virtual devices which should be ignored. Used
+	// instead of diskstatsDefaultIgnoredDevices when partitions stats are requested.
+	diskstatsDefaultIgnoredVirtualDevices = `^(ram|loop|fd|sr)\d+$`
+
+	// diskstatsPartitionDevices defines pattern of partitions names. Used for distinguishing partitions from whole disks
+	// when sysfs is not available.
+	diskstatsPartitionDevices = `^((h|s|v|xv)d[a-z]+\d+|(nvme\d+n\d+|mmcblk\d+)p\d+)$`
+
+	// diskstatsDefaultIONowMax defines default max sane value of in-progress I/Os. Some kernels transiently report
+	// huge bogus values after device hotplug.
+	diskstatsDefaultIONowMax = 100000
+
+	// diskstatsDefaultPropertiesRefresh defines default interval of re-reading storage devices properties from sysfs.
+	// Properties are rarely changed, hence there is no need to read them on every scrape.
+	diskstatsDefaultPropertiesRefresh = 5 * time.Minute
 )
 
 type diskstatsCollector struct {
-	completed      typedDesc
-	completedAll   typedDesc
-	merged         typedDesc
-	mergedAll      typedDesc
-	bytes          typedDesc
-	bytesAll       typedDesc
-	times          typedDesc
-	timesAll       typedDesc
-	ionow          typedDesc
-	iotime         typedDesc
-	iotimeweighted typedDesc
-	storageInfo    typedDesc
-	storageSize    typedDesc
+	multipath       bool    // aggregate multipath devices and suppress underlying path devices
+	ionowMax        float64 // max sane value of in-progress I/Os, values above are rejected
+	ionowRejectsMu  sync.Mutex
+	ionowRejects    map[string]float64 // number of rejected in-progress I/Os values per device
+	parseErrorsMu   sync.Mutex
+	parseErrors     float64 // number of malformed lines skipped during parsing /proc/diskstats
+	dmRE            *regexp.Regexp
+	dmNamesMu       sync.Mutex
+	dmNames         map[string]string // cache of device-mapper names, e.g. dm-0 -> vg0-root
+	partRE          *regexp.Regexp
+	partsMu         sync.Mutex
+	parts           map[string]bool // cache of devices types, true means device is a partition
+	propsRefresh    time.Duration   // interval of refreshing cached storage devices properties
+	propsMu         sync.Mutex
+	props           []storageDeviceProperties // cache of storage devices properties
+	propsDevices    string                    // list of devices seen when properties have been cached
+	propsUpdated    time.Time                 // time when properties have been cached
+	completed       typedDesc
+	completedAll    typedDesc
+	merged          typedDesc
+	mergedAll       typedDesc
+	bytes           typedDesc
+	bytesAll        typedDesc
+	times           typedDesc
+	timesAll        typedDesc
+	ionow           typedDesc
+	ionowInvalid    typedDesc
+	parseErrorsDesc typedDesc
+	columns         typedDesc
+	iotime          typedDesc
+	iotimeweighted  typedDesc
+	storageInfo     typedDesc
+	storageSize     typedDesc
+	deviceMapper    typedDesc
+	deviceType      typedDesc
+	ignored         typedDesc
+	info            typedDesc
+	nrRequests      typedDesc
+	readAhead       typedDesc
+	maxSectors      typedDesc
 }
 
 // NewDiskstatsCollector returns a new Collector exposing disk device stats.
 // Docs from https://www.kernel.org/doc/Documentation/iostats.txt and https://www.kernel.org/doc/Documentation/ABI/testing/procfs-diskstats
 func NewDiskstatsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
 
+	includePartitions, err := settings.Options.Bool("include_partitions", false)
+	if err != nil {
+		return nil, err
+	}
+
 	// Define default filters (if no already present) to avoid collecting metrics about virtual devices and device partitions.
+	// User-defined 'device' filter overrides the default one and could be used either as blocklist ('exclude') or
+	// allowlist ('include'), e.g. for monitoring loop devices.
+	if settings.Filters == nil {
+		settings.Filters = filter.New()
+	}
+
 	if _, ok := settings.Filters["device"]; !ok {
-		if settings.Filters == nil {
-			settings.Filters = filter.New()
+		ignored := diskstatsDefaultIgnoredDevices
+		if includePartitions {
+			ignored = diskstatsDefaultIgnoredVirtualDevices
 		}
+		settings.Filters.Add("device", filter.Filter{Exclude: ignored})
+	}
 
-		settings.Filters.Add("device", filter.Filter{Exclude: `^(ram|loop|fd|sr|(h|s|v|xv)d[a-z]|nvme\d+n\d+p)\d+$`})
-		err := settings.Filters.Compile()
-		if err != nil {
-			return nil, err
-		}
+	// Compile filters once, at collector's creation.
+	err = settings.Filters.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("compile diskstats device filter failed: %s", err)
+	}
+
+	multipath, err := settings.Options.Bool("multipath", false)
+	if err != nil {
+		return nil, err
+	}
+
+	ionowMax, err := settings.Options.Float("io_now_max", diskstatsDefaultIONowMax)
+	if err != nil {
+		return nil, err
+	}
+
+	propsRefresh, err := settings.Options.Duration("properties_refresh_interval", diskstatsDefaultPropertiesRefresh)
+	if err != nil {
+		return nil, err
 	}
 
 	diskLabelNames := []string{"device", "type"}
 
 	return &diskstatsCollector{
+		multipath:    multipath,
+		ionowMax:     ionowMax,
+		ionowRejects: map[string]float64{},
+		dmRE:         regexp.MustCompile(`^dm-\d+$`),
+		dmNames:      map[string]string{},
+		partRE:       regexp.MustCompile(diskstatsPartitionDevices),
+		parts:        map[string]bool{},
+		propsRefresh: propsRefresh,
 		completed: newBuiltinTypedDesc(
 			descOpts{"node", "disk", "completed_total", "The total number of IO requests completed successfully of each type.", 0},
 			prometheus.CounterValue,
@@ -112,6 +202,24 @@ func NewDiskstatsCollector(constLabels labels, settings model.CollectorSettings)
 			[]string{"device"}, constLabels,
 			settings.Filters,
 		),
+		ionowInvalid: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "io_now_invalid_total", "Total number of rejected insane values of I/Os currently in progress.", 0},
+			prometheus.CounterValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
+		parseErrorsDesc: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "parse_errors_total", "Total number of malformed lines skipped during parsing of diskstats.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		columns: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "stats_columns", "Number of columns reported in diskstats for the device.", 0},
+			prometheus.GaugeValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
 		iotime: newBuiltinTypedDesc(
 			descOpts{"node", "disk", "io_time_seconds_total", "Total seconds spent doing I/Os.", .001},
 			prometheus.CounterValue,
@@ -137,16 +245,93 @@ func NewDiskstatsCollector(constLabels labels, settings model.CollectorSettings)
 			[]string{"device", "rotational", "scheduler", "virtual", "model"}, constLabels,
 			settings.Filters,
 		),
+		deviceMapper: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "device_mapper_info", "Labeled information about device-mapper devices (LVM, LUKS, etc.) names.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "name"}, constLabels,
+			settings.Filters,
+		),
+		deviceType: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "device_type_info", "Labeled information about type of device, whole disk or partition.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "type"}, constLabels,
+			settings.Filters,
+		),
+		// Ignored devices are reported regardless of filters, hence filters are not passed.
+		ignored: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "ignored", "Labeled information about devices ignored by configured device filter.", 0},
+			prometheus.GaugeValue,
+			[]string{"device"}, constLabels,
+			filter.New(),
+		),
+		info: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "info", "Labeled information about block device hardware.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "model", "wwn"}, constLabels,
+			settings.Filters,
+		),
+		nrRequests: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "queue_nr_requests", "Maximum number of requests allocated in the block device queue.", 0},
+			prometheus.GaugeValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
+		readAhead: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "read_ahead_bytes", "Maximum number of bytes to read-ahead by filesystems on the block device.", 1024},
+			prometheus.GaugeValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
+		maxSectors: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "max_sectors_bytes", "Maximum number of bytes allowed in single request to the block device.", 1024},
+			prometheus.GaugeValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
-func (c *diskstatsCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
-	stats, err := getDiskstats()
+func (c *diskstatsCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
+	stats, invalid, err := getDiskstats(config.procfsPath("diskstats"))
 	if err != nil {
 		return fmt.Errorf("get diskstats failed: %s", err)
 	}
 
+	c.parseErrorsMu.Lock()
+	c.parseErrors += float64(invalid)
+	parseErrors := c.parseErrors
+	c.parseErrorsMu.Unlock()
+
+	ch <- c.parseErrorsDesc.newConstMetric(parseErrors)
+
+	// Number of columns depends on kernel version, some drivers report more columns than running kernel supports.
+	// Values beyond the expected columns are not genuine and are ignored.
+	expected, err := diskstatsExpectedColumns(config.procfsPath("sys/kernel/osrelease"))
+	if err != nil {
+		log.Debugf("get kernel release failed: %s; use columns reported in diskstats", err)
+	}
+
+	// Remember devices before removing multipath slaves, they are used for detecting added or removed devices.
+	devices := diskstatsDevices(stats)
+
+	// Multipath device stats already account IO passed through all its paths. Remove path devices to avoid double-counting.
+	if c.multipath {
+		removeMultipathSlaves(config.sysfsPath("block"), stats)
+	}
+
 	for dev, stat := range stats {
+		// Report devices rejected by device filter, their stats are not sent.
+		if c.completedAll.hasFilter([]string{dev}) {
+			ch <- c.ignored.newConstMetric(1, dev)
+			continue
+		}
+
+		ch <- c.columns.newConstMetric(float64(len(stat)+3), dev)
+
+		if expected > 0 && len(stat)+3 > expected {
+			stat = stat[:expected-3]
+		}
+
 		// totals
 		var completedTotal, mergedTotal, bytesTotal, secondsTotal float64
 
@@ -163,7 +348,10 @@ func (c *diskstatsCollector) Update(_ Config, ch chan<- prometheus.Metric) error
 			ch <- c.merged.newConstMetric(stat[5], dev, "write")
 			ch <- c.bytes.newConstMetric(stat[6], dev, "write")
 			ch <- c.times.newConstMetric(stat[7], dev, "write")
-			ch <- c.ionow.newConstMetric(stat[8], dev)
+			if ionow, ok := c.checkIONow(dev, stat[8]); ok {
+				ch <- c.ionow.newConstMetric(ionow, dev)
+			}
+			ch <- c.ionowInvalid.newConstMetric(c.ionowRejectsTotal(dev), dev)
 			ch <- c.iotime.newConstMetric(stat[9], dev)
 			ch <- c.iotimeweighted.newConstMetric(stat[10], dev)
 		}
@@ -193,76 +381,312 @@ func (c *diskstatsCollector) Update(_ Config, ch chan<- prometheus.Metric) error
 		ch <- c.mergedAll.newConstMetric(mergedTotal, dev)
 		ch <- c.bytesAll.newConstMetric(bytesTotal, dev)
 		ch <- c.timesAll.newConstMetric(secondsTotal, dev)
+
+		// Send type of device to distinguish partitions from whole disks.
+		devType := "disk"
+		if c.isPartition(config.sysfsPath("class", "block"), dev) {
+			devType = "partition"
+		}
+		ch <- c.deviceType.newConstMetric(1, dev, devType)
+
+		// Send human-readable names of device-mapper devices.
+		if name := c.deviceMapperName(config.sysfsPath("block"), dev); name != "" {
+			ch <- c.deviceMapper.newConstMetric(1, dev, name)
+		}
 	}
 
 	// Collect storages properties.
-	storages, err := getStorageProperties("/sys/block/*")
+	storages, err := c.storageProperties(config.sysfsPath("block", "*"), devices)
 	if err != nil {
 		log.Warnf("get storage devices properties failed: %s; skip", err)
 	} else {
 		for _, s := range storages {
 			ch <- c.storageInfo.newConstMetric(1, s.device, s.rotational, s.scheduler)
-			ch <- c.storageSize.newConstMetric(float64(s.size), s.device, s.rotational, s.scheduler, s.virtual, s.model)
+			ch <- c.info.newConstMetric(1, s.device, s.model, s.wwn)
+
+			// Skip size metric for devices with unknown size.
+			if s.size >= 0 {
+				ch <- c.storageSize.newConstMetric(float64(s.size), s.device, s.rotational, s.scheduler, s.virtual, s.model)
+			}
+
+			// Skip queue settings which are not available for the device.
+			if s.nrRequests >= 0 {
+				ch <- c.nrRequests.newConstMetric(float64(s.nrRequests), s.device)
+			}
+			if s.readAheadKB >= 0 {
+				ch <- c.readAhead.newConstMetric(float64(s.readAheadKB), s.device)
+			}
+			if s.maxSectorsKB >= 0 {
+				ch <- c.maxSectors.newConstMetric(float64(s.maxSectorsKB), s.device)
+			}
 		}
 	}
 
 	return nil
 }
 
-// getDiskstats opens stats file and executes stats parser.
-func getDiskstats() (map[string][]float64, error) {
-	file, err := os.Open("/proc/diskstats")
+// checkIONow checks passed value of in-progress I/Os is sane. Insane values are accounted and rejected.
+func (c *diskstatsCollector) checkIONow(device string, value float64) (float64, bool) {
+	if value >= 0 && value <= c.ionowMax {
+		return value, true
+	}
+
+	log.Debugf("reject insane io_now value %.0f of device %s", value, device)
+
+	c.ionowRejectsMu.Lock()
+	c.ionowRejects[device]++
+	c.ionowRejectsMu.Unlock()
+
+	return 0, false
+}
+
+// ionowRejectsTotal returns total number of rejected in-progress I/Os values of passed device.
+func (c *diskstatsCollector) ionowRejectsTotal(device string) float64 {
+	c.ionowRejectsMu.Lock()
+	defer c.ionowRejectsMu.Unlock()
+
+	return c.ionowRejects[device]
+}
+
+// storageProperties returns cached storages properties. Properties are re-read when refresh interval is passed or
+// when list of devices has been changed (e.g. device has been added or removed).
+func (c *diskstatsCollector) storageProperties(path string, devices string) ([]storageDeviceProperties, error) {
+	c.propsMu.Lock()
+	defer c.propsMu.Unlock()
+
+	if !c.propsUpdated.IsZero() && devices == c.propsDevices && time.Since(c.propsUpdated) < c.propsRefresh {
+		return c.props, nil
+	}
+
+	storages, err := getStorageProperties(path)
 	if err != nil {
 		return nil, err
 	}
+
+	c.props, c.propsDevices, c.propsUpdated = storages, devices, time.Now()
+
+	return storages, nil
+}
+
+// diskstatsDevices returns sorted list of devices of passed stats joined into a single string.
+func diskstatsDevices(stats map[string][]float64) string {
+	devices := make([]string, 0, len(stats))
+	for dev := range stats {
+		devices = append(devices, dev)
+	}
+	sort.Strings(devices)
+
+	return strings.Join(devices, ",")
+}
+
+// isPartition returns true if passed device is a partition of a disk. Device is considered as a partition when its
+// sysfs directory contains 'partition' file. If sysfs is not available, device's name is used for the decision.
+func (c *diskstatsCollector) isPartition(sysclassblock string, device string) bool {
+	c.partsMu.Lock()
+	defer c.partsMu.Unlock()
+
+	if part, ok := c.parts[device]; ok {
+		return part
+	}
+
+	var part bool
+	devpath := filepath.Join(sysclassblock, device)
+	if _, err := os.Stat(filepath.Join(devpath, "partition")); err == nil {
+		part = true
+	} else if _, err := os.Stat(devpath); err != nil {
+		log.Debugf("get type of %s from sysfs failed: %s; guess by name", device, err)
+		part = c.partRE.MatchString(device)
+	}
+
+	c.parts[device] = part
+
+	return part
+}
+
+// deviceMapperName returns cached name of passed device-mapper device. Name is read from sysfs at first request. Empty
+// name is returned for non device-mapper devices or when the name is not available.
+func (c *diskstatsCollector) deviceMapperName(sysblock string, device string) string {
+	if !c.dmRE.MatchString(device) {
+		return ""
+	}
+
+	c.dmNamesMu.Lock()
+	defer c.dmNamesMu.Unlock()
+
+	if name, ok := c.dmNames[device]; ok {
+		return name
+	}
+
+	name, err := getDeviceMapperName(filepath.Join(sysblock, device))
+	if err != nil {
+		log.Debugf("get device-mapper name for %s failed: %s; skip", device, err)
+	}
+
+	// Remember also empty names to avoid reading sysfs on every scrape.
+	c.dmNames[device] = name
+
+	return name
+}
+
+// removeMultipathSlaves removes from stats the path devices which are members of multipath devices.
+func removeMultipathSlaves(sysblock string, stats map[string][]float64) {
+	for dev := range stats {
+		slaves, err := getMultipathSlaves(filepath.Join(sysblock, dev))
+		if err != nil {
+			log.Warnf("get multipath slaves for %s failed: %s; skip", dev, err)
+			continue
+		}
+
+		for _, slave := range slaves {
+			log.Debugf("ignore device %s, it is a path of multipath device %s", slave, dev)
+			delete(stats, slave)
+		}
+	}
+}
+
+// getMultipathSlaves returns names of path devices of passed multipath device. Empty list is returned for
+// non-multipath devices.
+func getMultipathSlaves(devpath string) ([]string, error) {
+	// Multipath devices are device-mapper devices with UUID prefixed by 'mpath-'.
+	uuid, err := os.ReadFile(filepath.Clean(devpath + "/dm/uuid"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !strings.HasPrefix(string(uuid), "mpath-") {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(filepath.Clean(devpath + "/slaves"))
+	if err != nil {
+		return nil, err
+	}
+
+	slaves := make([]string, 0, len(entries))
+	for _, e := range entries {
+		slaves = append(slaves, e.Name())
+	}
+
+	return slaves, nil
+}
+
+// getDiskstats opens stats file and executes stats parser.
+func getDiskstats(path string) (map[string][]float64, int, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, 0, err
+	}
 	defer func() { _ = file.Close() }()
 
 	return parseDiskstats(file)
 }
 
+// diskstatsExpectedColumns returns number of columns in diskstats expected for running kernel accordingly to kernel
+// release read from passed file: 14 columns before 4.18, 18 columns since 4.18 and 20 columns since 5.5.
+func diskstatsExpectedColumns(path string) (int, error) {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return 0, err
+	}
+
+	return parseDiskstatsExpectedColumns(strings.TrimSpace(string(content)))
+}
+
+// parseDiskstatsExpectedColumns returns number of columns in diskstats expected for passed kernel release.
+func parseDiskstatsExpectedColumns(release string) (int, error) {
+	parts := strings.SplitN(release, ".", 3)
+	if len(parts) < 2 {
+		return 0, fmt.Errorf("invalid kernel release '%s'", release)
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid kernel release '%s': %s", release, err)
+	}
+
+	// Minor version might be followed by suffix, e.g. 4.18-rc1.
+	minorStr := parts[1]
+	if i := strings.IndexFunc(minorStr, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		minorStr = minorStr[:i]
+	}
+
+	minor, err := strconv.Atoi(minorStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid kernel release '%s': %s", release, err)
+	}
+
+	switch {
+	case major > 5 || (major == 5 && minor >= 5):
+		return 20, nil
+	case major == 5 || (major == 4 && minor >= 18):
+		return 18, nil
+	default:
+		return 14, nil
+	}
+}
+
 // parseDiskstat reads stats file and returns stats structs.
-func parseDiskstats(r io.Reader) (map[string][]float64, error) {
+func parseDiskstats(r io.Reader) (map[string][]float64, int, error) {
 	log.Debug("parse disk stats")
 
 	var scanner = bufio.NewScanner(r)
 	var stats = map[string][]float64{}
+	var invalid int
 
 	for scanner.Scan() {
 		values := strings.Fields(scanner.Text())
 
 		// Linux kernel <= 4.18 have 14 columns, 4.18+ have 18, 5.5+ have 20 columns
 		// for details see https://www.kernel.org/doc/Documentation/ABI/testing/procfs-diskstats)
+		// Malformed line shouldn't break stats of all other devices, skip it.
 		if len(values) != 14 && len(values) != 18 && len(values) != 20 {
-			return nil, fmt.Errorf("invalid input, '%s': wrong number of values", scanner.Text())
+			log.Warnf("invalid input, '%s': wrong number of values, skip", scanner.Text())
+			invalid++
+			continue
 		}
 
 		device := values[2]
 
-		// Create float64 slice for values, parse line except first three values (major/minor/device)
+		// Create float64 slice for values, parse line except first three values (major/minor/device). Line with
+		// unparsable value is skipped entirely, partially parsed stats would be exposed as bogus zero values.
+		var failed bool
 		stat := make([]float64, len(values)-3)
 		for i := range stat {
 			value, err := strconv.ParseFloat(values[i+3], 64)
 			if err != nil {
-				log.Errorf("invalid input, parse '%s' failed: %s, skip", values[i+3], err.Error())
-				continue
+				log.Errorf("invalid input, parse '%s' of device %s failed: %s, skip", values[i+3], device, err.Error())
+				failed = true
+				break
 			}
 			stat[i] = value
 		}
 
+		if failed {
+			invalid++
+			continue
+		}
+
 		stats[device] = stat
 	}
 
-	return stats, scanner.Err()
+	return stats, invalid, scanner.Err()
 }
 
 // storageDeviceProperties defines storage devices properties observed through /sys/block/* interface.
 type storageDeviceProperties struct {
-	device     string
-	rotational string
-	scheduler  string
-	virtual    string
-	model      string
-	size       int64
+	device       string
+	rotational   string
+	scheduler    string
+	virtual      string
+	model        string
+	wwn          string
+	size         int64 // size in sectors, -1 means size is unknown
+	nrRequests   int64 // queue/nr_requests, -1 means value is unknown
+	readAheadKB  int64 // queue/read_ahead_kb, -1 means value is unknown
+	maxSectorsKB int64 // queue/max_sectors_kb, -1 means value is unknown
 }
 
 // getStorageProperties reads storages properties.
@@ -313,19 +737,38 @@ func getStorageProperties(path string) ([]storageDeviceProperties, error) {
 			}
 		}
 
+		// WWN is optional, it is not provided by virtual devices and some of physical devices.
+		wwn := getDeviceWWN(devpath)
+
+		// Unreadable size should not lead to skipping the whole device.
 		size, err := getDeviceSize(devpath)
 		if err != nil {
 			log.Warnf("get size for %s failed: %s; skip", device, err)
-			continue
+			size = -1
+		}
+
+		// Queue settings are optional and missing ones should not lead to skipping the whole device.
+		queue := map[string]int64{"nr_requests": -1, "read_ahead_kb": -1, "max_sectors_kb": -1}
+		for name := range queue {
+			v, err := getDeviceQueueSetting(devpath, name)
+			if err != nil {
+				log.Debugf("get queue '%s' for %s failed: %s; skip", name, device, err)
+				continue
+			}
+			queue[name] = v
 		}
 
 		storages = append(storages, storageDeviceProperties{
-			device:     device,
-			scheduler:  scheduler,
-			rotational: rotational,
-			virtual:    strconv.FormatBool(virtual),
-			model:      deviceModel,
-			size:       size,
+			device:       device,
+			scheduler:    scheduler,
+			rotational:   rotational,
+			virtual:      strconv.FormatBool(virtual),
+			model:        deviceModel,
+			wwn:          wwn,
+			size:         size,
+			nrRequests:   queue["nr_requests"],
+			readAheadKB:  queue["read_ahead_kb"],
+			maxSectorsKB: queue["max_sectors_kb"],
 		})
 	}
 	return storages, nil
@@ -355,25 +798,36 @@ func getDeviceRotational(devpath string) (string, error) {
 
 // getDeviceScheduler returns name of the IO scheduler used by device.
 func getDeviceScheduler(devpath string) (string, error) {
-	re, err := regexp.Compile(`[[a-z-]+]|none`)
-	if err != nil {
-		return "", err
-	}
-
 	schedulerFile := devpath + "/queue/scheduler"
 
 	content, err := os.ReadFile(filepath.Clean(schedulerFile))
 	if err != nil {
 		return "", err
 	}
-	reader := bufio.NewReader(bytes.NewBuffer(content))
-	line, _, err := reader.ReadLine()
-	if err != nil {
-		return "", err
+
+	return parseDeviceScheduler(string(content))
+}
+
+// parseDeviceScheduler parses content of 'queue/scheduler' file and returns active scheduler. Active scheduler is
+// wrapped in square brackets and could be placed anywhere in the list of available schedulers, e.g.
+// 'mq-deadline [kyber] bfq'. Devices without scheduler have single 'none' value, sometimes without brackets. Empty
+// content means scheduler is unknown.
+func parseDeviceScheduler(content string) (string, error) {
+	line, _, _ := strings.Cut(content, "\n")
+	fields := strings.Fields(line)
+
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	for _, f := range fields {
+		if len(f) > 2 && strings.HasPrefix(f, "[") && strings.HasSuffix(f, "]") {
+			return f[1 : len(f)-1], nil
+		}
 	}
 
-	if sched := re.Find(line); sched != nil {
-		return string(bytes.Trim(sched, "[]")), nil
+	if len(fields) == 1 && fields[0] == "none" {
+		return "none", nil
 	}
 
 	return "", fmt.Errorf("unknown scheduler: %s", line)
@@ -394,6 +848,35 @@ func getDeviceSize(devpath string) (int64, error) {
 	return size, nil
 }
 
+// getDeviceQueueSetting returns numeric setting of the device's queue, e.g. 'nr_requests'.
+func getDeviceQueueSetting(devpath string, name string) (int64, error) {
+	data, err := os.ReadFile(filepath.Join(devpath, "queue", name))
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return v, nil
+}
+
+// getDeviceWWN returns World Wide Name of the device, or empty string if WWN is not available.
+func getDeviceWWN(devpath string) string {
+	for _, name := range []string{"wwid", "device/wwid"} {
+		data, err := os.ReadFile(filepath.Join(devpath, name))
+		if err != nil {
+			continue
+		}
+
+		return strings.TrimSpace(string(data))
+	}
+
+	return ""
+}
+
 // getDeviceModel returns model of the device.
 func getDeviceModel(devpath string) (string, error) {
 	m, err := os.ReadFile(devpath + "/device/model") // #nosec G304
@@ -407,3 +890,13 @@ func getDeviceModel(devpath string) (string, error) {
 
 	return strings.TrimSpace(string(m)), nil
 }
+
+// getDeviceMapperName returns name of the device-mapper device.
+func getDeviceMapperName(devpath string) (string, error) {
+	name, err := os.ReadFile(filepath.Clean(devpath + "/dm/name"))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(name)), nil
+}
@@ -3,10 +3,14 @@ package collector
 import (
 	"github.com/cherts/pgscv/internal/filter"
 	"github.com/cherts/pgscv/internal/model"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDiskstatsCollector_Update(t *testing.T) {
@@ -21,10 +25,22 @@ func TestDiskstatsCollector_Update(t *testing.T) {
 			"node_disk_time_seconds_total",
 			"node_disk_time_seconds_all_total",
 			"node_disk_io_now",
+			"node_disk_io_now_invalid_total",
+			"node_disk_parse_errors_total",
+			"node_disk_stats_columns",
 			"node_disk_io_time_seconds_total",
 			"node_disk_io_time_weighted_seconds_total",
 			"node_system_storage_info",
 			"node_system_storage_size_bytes",
+			"node_disk_info",
+		},
+		optional: []string{
+			"node_disk_device_mapper_info",
+			"node_disk_device_type_info",
+			"node_disk_ignored",
+			"node_disk_queue_nr_requests",
+			"node_disk_read_ahead_bytes",
+			"node_disk_max_sectors_bytes",
 		},
 		collector:         NewDiskstatsCollector,
 		collectorSettings: model.CollectorSettings{Filters: filter.New()},
@@ -33,13 +49,85 @@ func TestDiskstatsCollector_Update(t *testing.T) {
 	pipeline(t, input)
 }
 
+func TestDiskstatsCollector_Update_testdata(t *testing.T) {
+	c, err := NewDiskstatsCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	assert.NotEmpty(t, collectMetrics(t, c, Config{ProcfsPath: "testdata/proc", SysfsPath: "testdata/sys"}))
+}
+
+func TestDiskstatsCollector_Update_ignored(t *testing.T) {
+	settings := model.CollectorSettings{Filters: filter.Filters{"device": {Exclude: `^sdb$`}}}
+	c, err := NewDiskstatsCollector(labels{}, settings)
+	assert.NoError(t, err)
+
+	var ignored []string
+	for _, m := range collectMetrics(t, c, Config{ProcfsPath: "testdata/proc", SysfsPath: "testdata/sys"}) {
+		if !strings.Contains(m.Desc().String(), `"node_disk_ignored"`) {
+			continue
+		}
+
+		pb := &dto.Metric{}
+		assert.NoError(t, m.Write(pb))
+		for _, lp := range pb.GetLabel() {
+			ignored = append(ignored, lp.GetValue())
+		}
+	}
+
+	assert.Equal(t, []string{"sdb"}, ignored)
+}
+
+func TestNewDiskstatsCollector(t *testing.T) {
+	// Default filter.
+	c, err := NewDiskstatsCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+	dc := c.(*diskstatsCollector)
+	assert.False(t, dc.completedAll.hasFilter([]string{"sda"}))
+	assert.True(t, dc.completedAll.hasFilter([]string{"loop0"}))
+	assert.True(t, dc.completedAll.hasFilter([]string{"nvme0n1p1"}))
+
+	// Default filter with partitions included.
+	settings := model.CollectorSettings{Options: model.CollectorOptions{"include_partitions": "true"}}
+	c, err = NewDiskstatsCollector(labels{}, settings)
+	assert.NoError(t, err)
+	dc = c.(*diskstatsCollector)
+	assert.False(t, dc.completedAll.hasFilter([]string{"sda1"}))
+	assert.False(t, dc.completedAll.hasFilter([]string{"nvme0n1p1"}))
+	assert.True(t, dc.completedAll.hasFilter([]string{"loop0"}))
+
+	// User-defined allowlist filter.
+	settings = model.CollectorSettings{Filters: filter.Filters{"device": {Include: `^loop\d+$`}}}
+	c, err = NewDiskstatsCollector(labels{}, settings)
+	assert.NoError(t, err)
+	dc = c.(*diskstatsCollector)
+	assert.True(t, dc.completedAll.hasFilter([]string{"sda"}))
+	assert.False(t, dc.completedAll.hasFilter([]string{"loop0"}))
+
+	// Invalid user-defined filter.
+	settings = model.CollectorSettings{Filters: filter.Filters{"device": {Exclude: `[invalid`}}}
+	_, err = NewDiskstatsCollector(labels{}, settings)
+	assert.Error(t, err)
+}
+
+func TestDiskstatsCollector_sectorSize(t *testing.T) {
+	c, err := NewDiskstatsCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+	dc := c.(*diskstatsCollector)
+
+	// Sectors are always 512 bytes long, independently of device's logical or physical block size.
+	for _, d := range []typedDesc{dc.bytes, dc.bytesAll, dc.storageSize} {
+		assert.Equal(t, float64(512), d.factor)
+	}
+}
+
 func Test_parseDiskstats(t *testing.T) {
 	file, err := os.Open(filepath.Clean("testdata/proc/diskstats.golden"))
 	assert.NoError(t, err)
 	defer func() { _ = file.Close() }()
 
-	stats, err := parseDiskstats(file)
+	stats, invalid, err := parseDiskstats(file)
 	assert.NoError(t, err)
+	assert.Equal(t, 0, invalid)
 
 	want := map[string][]float64{
 		"sda": {118374, 28537, 5814772, 33586, 170999, 194921, 19277944, 181605, 0, 187400, 108536, 16519, 0, 5817512, 63312},
@@ -47,12 +135,85 @@ func Test_parseDiskstats(t *testing.T) {
 	}
 
 	assert.Equal(t, want, stats)
+
+	// Malformed lines and lines with unparsable values are skipped, stats of other devices are still parsed.
+	input := "   8       0 sda 1 2 3 4 5 6 7 8 9 10 11\n" +
+		"   8      16 sdb 1 2 3 4\n" +
+		"   8      32 sdc 1 2 3 4 5 6 7 8 9 invalid 11\n"
+
+	stats, invalid, err = parseDiskstats(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, invalid)
+	assert.Equal(t, map[string][]float64{
+		"sda": {1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+	}, stats)
+	assert.NotContains(t, stats, "sdc")
+}
+
+func Test_parseDiskstatsExpectedColumns(t *testing.T) {
+	testcases := []struct {
+		release string
+		want    int
+	}{
+		{release: "3.10.0-1160.el7.x86_64", want: 14},
+		{release: "4.15.0-213-generic", want: 14},
+		{release: "4.18.0-513.el8.x86_64", want: 18},
+		{release: "5.4.0-150-generic", want: 18},
+		{release: "5.5-rc1", want: 20},
+		{release: "6.8.0-45-generic", want: 20},
+	}
+
+	for _, tc := range testcases {
+		got, err := parseDiskstatsExpectedColumns(tc.release)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, got, tc.release)
+	}
+
+	_, err := parseDiskstatsExpectedColumns("invalid")
+	assert.Error(t, err)
+}
+
+func TestDiskstatsCollector_Update_columns(t *testing.T) {
+	procfs := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(procfs, "sys", "kernel"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(procfs, "sys", "kernel", "osrelease"), []byte("4.19.0-26-amd64\n"), 0644))
+	// Line with 20 columns reported on kernel which supports only 18 columns.
+	assert.NoError(t, os.WriteFile(filepath.Join(procfs, "diskstats"), []byte("   8       0 sda 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17\n"), 0644))
+
+	settings := model.CollectorSettings{Filters: filter.New()}
+	c, err := NewDiskstatsCollector(labels{}, settings)
+	assert.NoError(t, err)
+
+	var columns, discard, flush int
+	for _, m := range collectMetrics(t, c, Config{ProcfsPath: procfs, SysfsPath: "testdata/sys"}) {
+		desc := m.Desc().String()
+		switch {
+		case strings.Contains(desc, `"node_disk_stats_columns"`):
+			columns++
+		case strings.Contains(desc, `"node_disk_completed_total"`):
+			var metric dto.Metric
+			assert.NoError(t, m.Write(&metric))
+			for _, l := range metric.GetLabel() {
+				if l.GetName() == "type" && l.GetValue() == "discard" {
+					discard++
+				}
+				if l.GetName() == "type" && l.GetValue() == "flush" {
+					flush++
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, 1, columns)
+	assert.Equal(t, 1, discard)
+	assert.Equal(t, 0, flush)
 }
 
 func Test_getStorageProperties(t *testing.T) {
 	want := []storageDeviceProperties{
-		{device: "sda", rotational: "0", scheduler: "mq-deadline", size: 234441648, virtual: "true"},
-		{device: "sdb", rotational: "1", scheduler: "deadline", size: 3907029168, virtual: "false", model: "TEST HARDDISK WITH LONG LONG LON"},
+		{device: "sda", rotational: "0", scheduler: "mq-deadline", size: 234441648, virtual: "true", wwn: "eui.0025388b91b2c3d4", nrRequests: 64, readAheadKB: 128, maxSectorsKB: 1280},
+		{device: "sdb", rotational: "1", scheduler: "deadline", size: 3907029168, virtual: "false", model: "TEST HARDDISK WITH LONG LONG LON", wwn: "naa.5000c500a1b2c3d4", nrRequests: -1, readAheadKB: 256, maxSectorsKB: -1},
+		{device: "sdx", rotational: "1", scheduler: "none", size: -1, virtual: "true", nrRequests: -1, readAheadKB: -1, maxSectorsKB: -1},
 	}
 
 	storages, err := getStorageProperties("testdata/sys/block/*")
@@ -60,6 +221,48 @@ func Test_getStorageProperties(t *testing.T) {
 	assert.Equal(t, want, storages)
 }
 
+func Test_diskstatsCollector_storageProperties(t *testing.T) {
+	c := &diskstatsCollector{propsRefresh: time.Hour}
+
+	storages, err := c.storageProperties("testdata/sys/block/*", "sda,sdb")
+	assert.NoError(t, err)
+	assert.Len(t, storages, 3)
+
+	// Devices are not changed, cached properties are returned without reading sysfs.
+	storages, err = c.storageProperties("testdata/sys/unknown/*", "sda,sdb")
+	assert.NoError(t, err)
+	assert.Len(t, storages, 3)
+
+	// Devices are changed, properties are re-read.
+	storages, err = c.storageProperties("testdata/sys/unknown/*", "sda")
+	assert.NoError(t, err)
+	assert.Len(t, storages, 0)
+
+	// Refresh interval is passed, properties are re-read.
+	c.propsRefresh = 0
+	storages, err = c.storageProperties("testdata/sys/block/*", "sda")
+	assert.NoError(t, err)
+	assert.Len(t, storages, 3)
+}
+
+func Test_diskstatsCollector_isPartition(t *testing.T) {
+	c := &diskstatsCollector{partRE: regexp.MustCompile(diskstatsPartitionDevices), parts: map[string]bool{}}
+
+	assert.False(t, c.isPartition("testdata/sys/class/block", "sda"))
+	assert.True(t, c.isPartition("testdata/sys/class/block", "sda1"))
+
+	// Devices not present in sysfs are recognized by name.
+	assert.True(t, c.isPartition("testdata/sys/class/block", "nvme0n1p2"))
+	assert.True(t, c.isPartition("testdata/sys/class/block", "mmcblk0p1"))
+	assert.False(t, c.isPartition("testdata/sys/class/block", "nvme0n1"))
+	assert.False(t, c.isPartition("testdata/sys/class/block", "dm-0"))
+}
+
+func Test_diskstatsDevices(t *testing.T) {
+	assert.Equal(t, "", diskstatsDevices(map[string][]float64{}))
+	assert.Equal(t, "dm-0,sda,sdb", diskstatsDevices(map[string][]float64{"sdb": nil, "dm-0": nil, "sda": nil}))
+}
+
 func Test_getDeviceRotational(t *testing.T) {
 	r, err := getDeviceRotational("testdata/sys/block/sda")
 	assert.NoError(t, err)
@@ -92,6 +295,57 @@ func Test_getDeviceScheduler(t *testing.T) {
 	assert.Equal(t, "", r)
 }
 
+func Test_parseDeviceScheduler(t *testing.T) {
+	testcases := []struct {
+		valid   bool
+		content string
+		want    string
+	}{
+		{valid: true, content: "none\n", want: "none"},
+		{valid: true, content: "[none]\n", want: "none"},
+		{valid: true, content: "[none] mq-deadline\n", want: "none"},
+		{valid: true, content: "[mq-deadline] kyber bfq\n", want: "mq-deadline"},
+		{valid: true, content: "mq-deadline [kyber] bfq\n", want: "kyber"},
+		{valid: true, content: "mq-deadline kyber [bfq]\n", want: "bfq"},
+		{valid: true, content: "noop [deadline] cfq", want: "deadline"},
+		{valid: true, content: "", want: ""},
+		{valid: true, content: "\n", want: ""},
+		{valid: false, content: "invalid\n"},
+		{valid: false, content: "mq-deadline kyber bfq\n"},
+		{valid: false, content: "[] none bfq\n"},
+	}
+
+	for _, tc := range testcases {
+		got, err := parseDeviceScheduler(tc.content)
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func Test_getDeviceWWN(t *testing.T) {
+	assert.Equal(t, "eui.0025388b91b2c3d4", getDeviceWWN("testdata/sys/block/sda"))
+	assert.Equal(t, "naa.5000c500a1b2c3d4", getDeviceWWN("testdata/sys/block/sdb"))
+	assert.Equal(t, "", getDeviceWWN("testdata/sys/block/sdx"))
+}
+
+func Test_getDeviceQueueSetting(t *testing.T) {
+	v, err := getDeviceQueueSetting("testdata/sys/block/sda", "nr_requests")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(64), v)
+
+	// Read file with bad content
+	_, err = getDeviceQueueSetting("testdata/sys/block/sdb", "nr_requests")
+	assert.Error(t, err)
+
+	// Read unknown file
+	_, err = getDeviceQueueSetting("testdata/sys/block/sdx", "nr_requests")
+	assert.Error(t, err)
+}
+
 func Test_getDeviceSize(t *testing.T) {
 	sz, err := getDeviceSize("testdata/sys/block/sda")
 	assert.NoError(t, err)
@@ -123,3 +377,71 @@ func Test_getDeviceModel(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "", m)
 }
+
+func Test_getDeviceMapperName(t *testing.T) {
+	name, err := getDeviceMapperName("testdata/sys/block/dm-0")
+	assert.NoError(t, err)
+	assert.Equal(t, "vg0-root", name)
+
+	// Read unknown file
+	name, err = getDeviceMapperName("testdata/sys/block/sda")
+	assert.Error(t, err)
+	assert.Equal(t, "", name)
+}
+
+func TestDiskstatsCollector_deviceMapperName(t *testing.T) {
+	c, err := NewDiskstatsCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+	dc := c.(*diskstatsCollector)
+
+	assert.Equal(t, "vg0-root", dc.deviceMapperName("testdata/sys/block", "dm-0"))
+	assert.Equal(t, "", dc.deviceMapperName("testdata/sys/block", "dm-2"))
+	assert.Equal(t, "", dc.deviceMapperName("testdata/sys/block", "sda"))
+	assert.Equal(t, map[string]string{"dm-0": "vg0-root", "dm-2": ""}, dc.dmNames)
+}
+
+func Test_removeMultipathSlaves(t *testing.T) {
+	stats := map[string][]float64{"sda": {1}, "sdb": {1}, "sdc": {1}, "dm-0": {1}, "dm-1": {2}}
+	removeMultipathSlaves("testdata/sys/block", stats)
+	assert.Equal(t, map[string][]float64{"sda": {1}, "dm-0": {1}, "dm-1": {2}}, stats)
+}
+
+func Test_getMultipathSlaves(t *testing.T) {
+	slaves, err := getMultipathSlaves("testdata/sys/block/dm-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sdb", "sdc"}, slaves)
+
+	// Not a multipath device-mapper device.
+	slaves, err = getMultipathSlaves("testdata/sys/block/dm-0")
+	assert.NoError(t, err)
+	assert.Nil(t, slaves)
+
+	// Not a device-mapper device.
+	slaves, err = getMultipathSlaves("testdata/sys/block/sda")
+	assert.NoError(t, err)
+	assert.Nil(t, slaves)
+}
+
+func TestDiskstatsCollector_checkIONow(t *testing.T) {
+	settings := model.CollectorSettings{Options: model.CollectorOptions{"io_now_max": "1000"}}
+	c, err := NewDiskstatsCollector(labels{}, settings)
+	assert.NoError(t, err)
+	dc := c.(*diskstatsCollector)
+
+	v, ok := dc.checkIONow("sda", 10)
+	assert.True(t, ok)
+	assert.Equal(t, float64(10), v)
+
+	_, ok = dc.checkIONow("sda", 4294967295)
+	assert.False(t, ok)
+	_, ok = dc.checkIONow("sda", -1)
+	assert.False(t, ok)
+
+	assert.Equal(t, float64(2), dc.ionowRejectsTotal("sda"))
+	assert.Equal(t, float64(0), dc.ionowRejectsTotal("sdb"))
+
+	// Invalid option value.
+	settings = model.CollectorSettings{Options: model.CollectorOptions{"io_now_max": "invalid"}}
+	_, err = NewDiskstatsCollector(labels{}, settings)
+	assert.Error(t, err)
+}
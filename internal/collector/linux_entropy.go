@@ -0,0 +1,49 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type entropyCollector struct {
+	available typedDesc
+	poolsize  typedDesc
+}
+
+// NewEntropyCollector returns a new Collector exposing kernel random pool stats.
+func NewEntropyCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &entropyCollector{
+		available: newBuiltinTypedDesc(
+			descOpts{"node", "entropy", "available_bits", "Number of bits of entropy available in kernel random pool.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		poolsize: newBuiltinTypedDesc(
+			descOpts{"node", "entropy", "pool_size_bits", "Size of kernel random pool, in bits.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update implements Collector and exposes entropy related metrics from /proc/sys/kernel/random.
+func (c *entropyCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
+	available, err := readFloatFile(config.procfsPath("sys", "kernel", "random", "entropy_avail"))
+	if err != nil {
+		return fmt.Errorf("get available entropy failed: %s", err)
+	}
+
+	poolsize, err := readFloatFile(config.procfsPath("sys", "kernel", "random", "poolsize"))
+	if err != nil {
+		return fmt.Errorf("get entropy pool size failed: %s", err)
+	}
+
+	ch <- c.available.newConstMetric(available)
+	ch <- c.poolsize.newConstMetric(poolsize)
+
+	return nil
+}
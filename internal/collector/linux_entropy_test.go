@@ -0,0 +1,17 @@
+package collector
+
+import (
+	"testing"
+)
+
+func TestEntropyCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"node_entropy_available_bits",
+			"node_entropy_pool_size_bits",
+		},
+		collector: NewEntropyCollector,
+	}
+
+	pipeline(t, input)
+}
@@ -0,0 +1,96 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type filefdCollector struct {
+	allocated typedDesc
+	maximum   typedDesc
+	nrOpen    typedDesc
+}
+
+// NewFilefdCollector returns a new Collector exposing system-wide file descriptors stats and limits.
+func NewFilefdCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &filefdCollector{
+		allocated: newBuiltinTypedDesc(
+			descOpts{"node", "filefd", "allocated", "Number of allocated file descriptors in the system.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		maximum: newBuiltinTypedDesc(
+			descOpts{"node", "filefd", "maximum", "Maximum number of file descriptors could be allocated in the system.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		nrOpen: newBuiltinTypedDesc(
+			descOpts{"node", "", "nr_open", "Maximum number of file descriptors could be opened by a single process.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update implements Collector and exposes file descriptors related metrics from /proc/sys/fs.
+func (c *filefdCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
+	allocated, maximum, err := getFileNr(config.procfsPath("sys", "fs", "file-nr"))
+	if err != nil {
+		return fmt.Errorf("get file-nr stats failed: %s", err)
+	}
+
+	ch <- c.allocated.newConstMetric(allocated)
+	ch <- c.maximum.newConstMetric(maximum)
+
+	nrOpen, err := readFloatFile(config.procfsPath("sys", "fs", "nr_open"))
+	if err != nil {
+		log.Warnf("get nr_open failed: %s; skip", err)
+	} else {
+		ch <- c.nrOpen.newConstMetric(nrOpen)
+	}
+
+	return nil
+}
+
+// getFileNr reads file-nr file and returns number of allocated file descriptors and max number of file descriptors
+// (the same as fs.file-max).
+func getFileNr(path string) (float64, float64, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return parseFileNr(string(data))
+}
+
+// parseFileNr parses content of file-nr file, e.g. '11744	0	9223372036854775807'.
+func parseFileNr(data string) (float64, float64, error) {
+	log.Debug("parse file-nr stats")
+
+	parts := strings.Fields(data)
+	if len(parts) != 3 {
+		return 0, 0, fmt.Errorf("invalid input, '%s': wrong number of values", data)
+	}
+
+	allocated, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid input, parse '%s' failed: %w", parts[0], err)
+	}
+
+	maximum, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid input, parse '%s' failed: %w", parts[2], err)
+	}
+
+	return allocated, maximum, nil
+}
@@ -0,0 +1,36 @@
+package collector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFilefdCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"node_filefd_allocated",
+			"node_filefd_maximum",
+			"node_nr_open",
+		},
+		collector: NewFilefdCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_getFileNr(t *testing.T) {
+	allocated, maximum, err := getFileNr("testdata/proc/sys/fs/file-nr")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(11744), allocated)
+	assert.Equal(t, float64(9223372036854775807), maximum)
+
+	_, _, err = getFileNr("testdata/proc/sys/fs/invalid")
+	assert.Error(t, err)
+}
+
+func Test_parseFileNr(t *testing.T) {
+	for _, s := range []string{"", "1 0", "invalid 0 100", "1 0 invalid"} {
+		_, _, err := parseFileNr(s)
+		assert.Error(t, err)
+	}
+}
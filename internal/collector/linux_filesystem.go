@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/cherts/pgscv/internal/filter"
@@ -9,6 +10,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -22,6 +26,8 @@ type filesystemCollector struct {
 	bytesTotal typedDesc
 	files      typedDesc
 	filesTotal typedDesc
+	readonly   typedDesc
+	errors     typedDesc
 }
 
 // NewFilesystemCollector returns a new Collector exposing filesystem stats.
@@ -65,12 +71,24 @@ func NewFilesystemCollector(constLabels labels, settings model.CollectorSettings
 			[]string{"device", "mountpoint", "fstype"}, constLabels,
 			settings.Filters,
 		),
+		readonly: newBuiltinTypedDesc(
+			descOpts{"node", "filesystem", "readonly", "Whether the filesystem is mounted read-only.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "mountpoint", "fstype"}, constLabels,
+			settings.Filters,
+		),
+		errors: newBuiltinTypedDesc(
+			descOpts{"node", "filesystem", "device_errors_total", "Total number of errors detected by filesystem (ext4 only).", 0},
+			prometheus.CounterValue,
+			[]string{"device", "mountpoint", "fstype"}, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
 // Update method collects filesystem usage statistics.
-func (c *filesystemCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
-	stats, err := getFilesystemStats()
+func (c *filesystemCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
+	stats, err := getFilesystemStats(config.procfsPath("mounts"))
 	if err != nil {
 		return fmt.Errorf("get filesystem stats failed: %s", err)
 	}
@@ -88,11 +106,37 @@ func (c *filesystemCollector) Update(_ Config, ch chan<- prometheus.Metric) erro
 		ch <- c.filesTotal.newConstMetric(s.files, device, s.mount.mountpoint, s.mount.fstype)
 		ch <- c.files.newConstMetric(s.filesfree, device, s.mount.mountpoint, s.mount.fstype, "free")
 		ch <- c.files.newConstMetric(s.files-s.filesfree, device, s.mount.mountpoint, s.mount.fstype, "used")
+
+		var readonly float64
+		if s.mount.isReadonly() {
+			readonly = 1
+		}
+		ch <- c.readonly.newConstMetric(readonly, device, s.mount.mountpoint, s.mount.fstype)
+
+		// Errors count is provided only by ext4 driver (ext2/ext3 filesystems could be mounted using ext4 driver too).
+		if strings.HasPrefix(s.mount.fstype, "ext") {
+			errorsCount, err := getExt4ErrorsCount(config.sysfsPath("fs", "ext4", device))
+			if err != nil {
+				log.Debugf("get errors count of %s failed: %s; skip", device, err)
+				continue
+			}
+			ch <- c.errors.newConstMetric(errorsCount, device, s.mount.mountpoint, s.mount.fstype)
+		}
 	}
 
 	return nil
 }
 
+// getExt4ErrorsCount returns number of errors detected by ext4 filesystem.
+func getExt4ErrorsCount(devpath string) (float64, error) {
+	content, err := os.ReadFile(filepath.Clean(devpath + "/errors_count"))
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(strings.TrimSpace(string(content)), 64)
+}
+
 // filesystemStat describes various stats related to filesystem usage.
 type filesystemStat struct {
 	mount     mount
@@ -105,8 +149,8 @@ type filesystemStat struct {
 }
 
 // getFilesystemStats opens stats file and execute stats parser.
-func getFilesystemStats() ([]filesystemStat, error) {
-	file, err := os.Open("/proc/mounts")
+func getFilesystemStats(path string) ([]filesystemStat, error) {
+	file, err := os.Open(filepath.Clean(path))
 	if err != nil {
 		return nil, err
 	}
@@ -17,6 +17,10 @@ func TestFilesystemCollector_Update(t *testing.T) {
 			"node_filesystem_bytes_total",
 			"node_filesystem_files",
 			"node_filesystem_files_total",
+			"node_filesystem_readonly",
+		},
+		optional: []string{
+			"node_filesystem_device_errors_total",
 		},
 		collector:         NewFilesystemCollector,
 		collectorSettings: model.CollectorSettings{Filters: filter.New()},
@@ -26,12 +30,21 @@ func TestFilesystemCollector_Update(t *testing.T) {
 }
 
 func Test_getFilesystemStats(t *testing.T) {
-	got, err := getFilesystemStats()
+	got, err := getFilesystemStats("/proc/mounts")
 	assert.NoError(t, err)
 	assert.NotNil(t, got)
 	assert.Greater(t, len(got), 0)
 }
 
+func Test_getExt4ErrorsCount(t *testing.T) {
+	got, err := getExt4ErrorsCount("testdata/sys/fs/ext4/sda1")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(3), got)
+
+	_, err = getExt4ErrorsCount("testdata/sys/fs/ext4/unknown")
+	assert.Error(t, err)
+}
+
 func Test_parseFilesystemStats(t *testing.T) {
 	file, err := os.Open(filepath.Clean("testdata/proc/mounts.golden"))
 	assert.NoError(t, err)
@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type hwmonCollector struct {
+	temp typedDesc
+	crit typedDesc
+}
+
+// NewHwmonCollector returns a new Collector exposing hardware monitoring sensors stats.
+func NewHwmonCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &hwmonCollector{
+		temp: newBuiltinTypedDesc(
+			descOpts{"node", "hwmon", "temp_celsius", "Hardware monitor sensor temperature, in celsius.", .001},
+			prometheus.GaugeValue,
+			[]string{"hwmon", "chip", "sensor"}, constLabels,
+			settings.Filters,
+		),
+		crit: newBuiltinTypedDesc(
+			descOpts{"node", "hwmon", "temp_crit_celsius", "Hardware monitor sensor critical temperature threshold, in celsius.", .001},
+			prometheus.GaugeValue,
+			[]string{"hwmon", "chip", "sensor"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update implements Collector and exposes temperature sensors stats from /sys/class/hwmon.
+func (c *hwmonCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
+	sensors, err := getHwmonSensors(config.sysfsPath("class", "hwmon", "hwmon*"))
+	if err != nil {
+		return fmt.Errorf("get hwmon sensors stats failed: %s", err)
+	}
+
+	for _, s := range sensors {
+		ch <- c.temp.newConstMetric(s.temp, s.hwmon, s.chip, s.sensor)
+
+		if s.crit > 0 {
+			ch <- c.crit.newConstMetric(s.crit, s.hwmon, s.chip, s.sensor)
+		}
+	}
+
+	return nil
+}
+
+// hwmonSensor describes single temperature sensor of hardware monitor.
+type hwmonSensor struct {
+	hwmon  string  // hwmon device name, e.g. hwmon0
+	chip   string  // chip name, e.g. coretemp, nvme, drivetemp
+	sensor string  // sensor label if present, or sensor name, e.g. temp1
+	temp   float64 // temperature in millidegree celsius
+	crit   float64 // critical temperature in millidegree celsius, zero if not present
+}
+
+// getHwmonSensors walks through hwmon devices directories matched to pattern and returns temperature sensors stats.
+func getHwmonSensors(pattern string) ([]hwmonSensor, error) {
+	dirs, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var sensors []hwmonSensor
+
+	for _, dir := range dirs {
+		hwmon := filepath.Base(dir)
+
+		// Chip name is optional, use hwmon device name when it is not present.
+		chip := hwmon
+		if data, err := os.ReadFile(filepath.Join(dir, "name")); err == nil {
+			chip = strings.TrimSpace(string(data))
+		}
+
+		inputs, err := filepath.Glob(filepath.Join(dir, "temp*_input"))
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(inputs)
+
+		for _, input := range inputs {
+			name := strings.TrimSuffix(filepath.Base(input), "_input")
+
+			temp, err := readFloatFile(input)
+			if err != nil {
+				log.Warnf("read %s failed: %s; skip", input, err)
+				continue
+			}
+
+			// Zero value usually means sensor is not populated.
+			if temp == 0 {
+				continue
+			}
+
+			s := hwmonSensor{hwmon: hwmon, chip: chip, sensor: name, temp: temp}
+
+			if data, err := os.ReadFile(filepath.Join(dir, name+"_label")); err == nil {
+				s.sensor = strings.TrimSpace(string(data))
+			}
+
+			if crit, err := readFloatFile(filepath.Join(dir, name+"_crit")); err == nil {
+				s.crit = crit
+			}
+
+			sensors = append(sensors, s)
+		}
+	}
+
+	return sensors, nil
+}
@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHwmonCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"node_hwmon_temp_celsius",
+			"node_hwmon_temp_crit_celsius",
+		},
+		collector: NewHwmonCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func TestHwmonCollector_Update_testdata(t *testing.T) {
+	c, err := NewHwmonCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	// 4 temperature sensors, 2 of them with critical thresholds.
+	assert.Len(t, collectMetrics(t, c, Config{SysfsPath: "testdata/sys"}), 6)
+}
+
+func Test_getHwmonSensors(t *testing.T) {
+	got, err := getHwmonSensors("testdata/sys/class/hwmon/hwmon*")
+	assert.NoError(t, err)
+
+	want := []hwmonSensor{
+		{hwmon: "hwmon0", chip: "coretemp", sensor: "Package id 0", temp: 45000, crit: 100000},
+		{hwmon: "hwmon0", chip: "coretemp", sensor: "Core 0", temp: 43000, crit: 100000},
+		{hwmon: "hwmon1", chip: "nvme", sensor: "Composite", temp: 38850},
+		{hwmon: "hwmon2", chip: "hwmon2", sensor: "temp1", temp: 27800},
+	}
+	assert.Equal(t, want, got)
+
+	got, err = getHwmonSensors("testdata/sys/class/invalid/hwmon*")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -11,9 +12,13 @@ import (
 )
 
 type loadaverageCollector struct {
-	load1  typedDesc
-	load5  typedDesc
-	load15 typedDesc
+	load1        typedDesc
+	load5        typedDesc
+	load15       typedDesc
+	runnable     typedDesc
+	entities     typedDesc
+	procsRunning typedDesc
+	procsBlocked typedDesc
 }
 
 // NewLoadAverageCollector returns a new Collector exposing load average statistics.
@@ -37,40 +42,82 @@ func NewLoadAverageCollector(constLabels labels, settings model.CollectorSetting
 			nil, constLabels,
 			settings.Filters,
 		),
+		runnable: newBuiltinTypedDesc(
+			descOpts{"node", "processes", "runnable", "Number of currently runnable kernel scheduling entities (processes, threads).", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		entities: newBuiltinTypedDesc(
+			descOpts{"node", "processes", "total", "Number of kernel scheduling entities (processes, threads) currently existing in the system.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		procsRunning: newBuiltinTypedDesc(
+			descOpts{"node", "procs", "running", "Number of processes in runnable state.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		procsBlocked: newBuiltinTypedDesc(
+			descOpts{"node", "procs", "blocked", "Number of processes blocked waiting for I/O to complete.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
-// Update implements Collector and exposes load average related metrics from /proc/loadavg.
-func (c *loadaverageCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+// Update implements Collector and exposes load average related metrics from /proc/loadavg and processes related
+// metrics from /proc/stat.
+func (c *loadaverageCollector) Update(_ context.Context, _ Config, ch chan<- prometheus.Metric) error {
 	stats, err := getLoadAverageStats()
 	if err != nil {
 		return fmt.Errorf("get load average stats failed: %s", err)
 	}
 
-	ch <- c.load1.newConstMetric(stats[0])
-	ch <- c.load5.newConstMetric(stats[1])
-	ch <- c.load15.newConstMetric(stats[2])
+	ch <- c.load1.newConstMetric(stats.loads[0])
+	ch <- c.load5.newConstMetric(stats.loads[1])
+	ch <- c.load15.newConstMetric(stats.loads[2])
+	ch <- c.runnable.newConstMetric(stats.runnable)
+	ch <- c.entities.newConstMetric(stats.entities)
+
+	stat, err := getProcStat()
+	if err != nil {
+		return fmt.Errorf("get /proc/stat stats failed: %s", err)
+	}
+
+	ch <- c.procsRunning.newConstMetric(stat.procsRunning)
+	ch <- c.procsBlocked.newConstMetric(stat.procsBlocked)
 
 	return nil
 }
 
+// loadAverageStat describes stats from /proc/loadavg.
+type loadAverageStat struct {
+	loads    []float64 // 1m, 5m and 15m load averages
+	runnable float64   // number of currently runnable scheduling entities
+	entities float64   // number of existing scheduling entities
+}
+
 // getLoadAverageStats reads /proc/loadavg and return load stats.
-func getLoadAverageStats() ([]float64, error) {
+func getLoadAverageStats() (loadAverageStat, error) {
 	data, err := os.ReadFile("/proc/loadavg")
 	if err != nil {
-		return nil, err
+		return loadAverageStat{}, err
 	}
 
 	return parseLoadAverageStats(string(data))
 }
 
 // parseLoadAverageStats parses content from /proc/loadavg and return load stats.
-func parseLoadAverageStats(data string) ([]float64, error) {
+func parseLoadAverageStats(data string) (loadAverageStat, error) {
 	log.Debug("parse load average stats")
 
 	parts := strings.Fields(data)
-	if len(parts) < 3 {
-		return nil, fmt.Errorf("invalid input, '%s': too few values", data)
+	if len(parts) < 4 {
+		return loadAverageStat{}, fmt.Errorf("invalid input, '%s': too few values", data)
 	}
 
 	var err error
@@ -78,8 +125,26 @@ func parseLoadAverageStats(data string) ([]float64, error) {
 	for i, load := range parts[0:3] {
 		loads[i], err = strconv.ParseFloat(load, 64)
 		if err != nil {
-			return nil, fmt.Errorf("invalid input, parse '%s' failed: %w", load, err)
+			return loadAverageStat{}, fmt.Errorf("invalid input, parse '%s' failed: %w", load, err)
 		}
 	}
-	return loads, nil
+
+	// Fourth field contains number of runnable and existing scheduling entities, e.g. '1/2076'.
+	runnable, entities, ok := strings.Cut(parts[3], "/")
+	if !ok {
+		return loadAverageStat{}, fmt.Errorf("invalid input, '%s': wrong format", parts[3])
+	}
+
+	stat := loadAverageStat{loads: loads}
+	for _, v := range []struct {
+		value string
+		dest  *float64
+	}{{runnable, &stat.runnable}, {entities, &stat.entities}} {
+		*v.dest, err = strconv.ParseFloat(v.value, 64)
+		if err != nil {
+			return loadAverageStat{}, fmt.Errorf("invalid input, parse '%s' failed: %w", v.value, err)
+		}
+	}
+
+	return stat, nil
 }
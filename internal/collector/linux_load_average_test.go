@@ -12,6 +12,10 @@ func TestLoadAverageCollector_Update(t *testing.T) {
 			"node_load1",
 			"node_load5",
 			"node_load15",
+			"node_processes_runnable",
+			"node_processes_total",
+			"node_procs_running",
+			"node_procs_blocked",
 		},
 		collector: NewLoadAverageCollector,
 	}
@@ -20,24 +24,28 @@ func TestLoadAverageCollector_Update(t *testing.T) {
 }
 
 func Test_getLoadAverageStats(t *testing.T) {
-	loads, err := getLoadAverageStats()
+	stat, err := getLoadAverageStats()
 	assert.NoError(t, err)
-	assert.Len(t, loads, 3)
+	assert.Len(t, stat.loads, 3)
+	assert.Greater(t, stat.entities, float64(0))
 }
 
 func Test_parseLoadAverageStats(t *testing.T) {
 	data, err := os.ReadFile("./testdata/proc/loadavg.golden")
 	assert.NoError(t, err)
 
-	loads, err := parseLoadAverageStats(string(data))
+	stat, err := parseLoadAverageStats(string(data))
 	assert.NoError(t, err)
-	assert.Equal(t, 1.15, loads[0])
-	assert.Equal(t, 1.36, loads[1])
-	assert.Equal(t, 1.24, loads[2])
-
-	_, err = parseLoadAverageStats("invalid data")
-	assert.Error(t, err)
-
-	_, err = parseLoadAverageStats("1 qq 2 1/123 12312")
-	assert.Error(t, err)
+	assert.Equal(t, loadAverageStat{loads: []float64{1.15, 1.36, 1.24}, runnable: 1, entities: 2076}, stat)
+
+	for _, s := range []string{
+		"invalid data",
+		"1 2 3",
+		"1 qq 2 1/123 12312",
+		"1 2 3 123 12312",
+		"1 2 3 1/qq 12312",
+	} {
+		_, err = parseLoadAverageStats(s)
+		assert.Error(t, err)
+	}
 }
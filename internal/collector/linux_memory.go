@@ -2,6 +2,7 @@ package collector
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"github.com/cherts/pgscv/internal/filter"
 	"github.com/cherts/pgscv/internal/log"
@@ -9,6 +10,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -44,13 +46,13 @@ func NewMeminfoCollector(constLabels labels, settings model.CollectorSettings) (
 }
 
 // Update method collects network interfaces statistics.
-func (c *meminfoCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
-	meminfo, err := getMeminfoStats()
+func (c *meminfoCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
+	meminfo, err := getMeminfoStats(config.procfsPath("meminfo"))
 	if err != nil {
 		return fmt.Errorf("get /proc/meminfo stats failed: %s", err)
 	}
 
-	vmstat, err := getVmstatStats()
+	vmstat, err := getVmstatStats(config.procfsPath("vmstat"))
 	if err != nil {
 		return fmt.Errorf("get /proc/vmstat stats failed: %s", err)
 	}
@@ -96,8 +98,8 @@ func (c *meminfoCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 }
 
 // getMeminfoStats is the intermediate function which opens stats file and run stats parser for extracting stats.
-func getMeminfoStats() (map[string]float64, error) {
-	file, err := os.Open("/proc/meminfo")
+func getMeminfoStats(path string) (map[string]float64, error) {
+	file, err := os.Open(filepath.Clean(path))
 	if err != nil {
 		return nil, err
 	}
@@ -142,8 +144,8 @@ func parseMeminfoStats(r io.Reader) (map[string]float64, error) {
 }
 
 // getVmstatStats is the intermediate function which opens stats file and run stats parser for extracting stats.
-func getVmstatStats() (map[string]float64, error) {
-	file, err := os.Open("/proc/vmstat")
+func getVmstatStats(path string) (map[string]float64, error) {
+	file, err := os.Open(filepath.Clean(path))
 	if err != nil {
 		return nil, err
 	}
@@ -2,9 +2,11 @@ package collector
 
 import (
 	"fmt"
+	"github.com/cherts/pgscv/internal/model"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -109,8 +111,30 @@ func TestMeminfoCollector_Update(t *testing.T) {
 	pipeline(t, input)
 }
 
+func TestMeminfoCollector_Update_testdata(t *testing.T) {
+	c, err := NewMeminfoCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	var names []string
+	for _, m := range collectMetrics(t, c, Config{ProcfsPath: "testdata/proc"}) {
+		names = append(names, m.Desc().String())
+	}
+
+	// Unit-less and unknown fields are passed through as is.
+	for _, name := range []string{"node_memory_HugePages_Total", "node_memory_Active_anon", "node_memory_MemUsed", "node_vmstat_pgpgin"} {
+		var found bool
+		for _, n := range names {
+			if strings.Contains(n, `"`+name+`"`) {
+				found = true
+				break
+			}
+		}
+		assert.True(t, found, name)
+	}
+}
+
 func Test_getMeminfoStats(t *testing.T) {
-	s, err := getMeminfoStats()
+	s, err := getMeminfoStats("/proc/meminfo")
 	assert.NoError(t, err)
 	assert.Greater(t, len(s), 0)
 }
@@ -192,7 +216,7 @@ func Test_parseMeminfoStats(t *testing.T) {
 }
 
 func Test_getVmstatStats(t *testing.T) {
-	s, err := getVmstatStats()
+	s, err := getVmstatStats("/proc/vmstat")
 	assert.NoError(t, err)
 	assert.Greater(t, len(s), 0)
 }
@@ -2,6 +2,7 @@ package collector
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"github.com/cherts/pgscv/internal/filter"
 	"github.com/cherts/pgscv/internal/log"
@@ -9,14 +10,20 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
 
+// netdevDefaultIgnoredDevices defines default pattern of virtual and loopback network interfaces excluded from collecting.
+const netdevDefaultIgnoredDevices = `docker|virbr|veth|^lo$`
+
 type netdevCollector struct {
 	bytes   typedDesc
 	packets typedDesc
 	events  typedDesc
+	up      typedDesc
+	speed   typedDesc
 }
 
 // NewNetdevCollector returns a new Collector exposing network interfaces stats.
@@ -28,7 +35,7 @@ func NewNetdevCollector(constLabels labels, settings model.CollectorSettings) (C
 			settings.Filters = filter.New()
 		}
 
-		settings.Filters.Add("device", filter.Filter{Exclude: `docker|virbr`})
+		settings.Filters.Add("device", filter.Filter{Exclude: netdevDefaultIgnoredDevices})
 		err := settings.Filters.Compile()
 		if err != nil {
 			return nil, err
@@ -54,12 +61,24 @@ func NewNetdevCollector(constLabels labels, settings model.CollectorSettings) (C
 			[]string{"device", "type", "event"}, constLabels,
 			settings.Filters,
 		),
+		up: newBuiltinTypedDesc(
+			descOpts{"node", "network", "up", "Network device operational state, 1 if device is up, 0 otherwise.", 0},
+			prometheus.GaugeValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
+		speed: newBuiltinTypedDesc(
+			descOpts{"node", "network", "speed_bytes", "Network device link speed, in bytes per second.", 125000},
+			prometheus.GaugeValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
 // Update method collects network interfaces statistics
-func (c *netdevCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
-	stats, err := getNetdevStats()
+func (c *netdevCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
+	stats, err := getNetdevStats(config.procfsPath("net", "dev"))
 	if err != nil {
 		return fmt.Errorf("get /proc/net/dev stats failed: %s", err)
 	}
@@ -89,14 +108,28 @@ func (c *netdevCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 		ch <- c.events.newConstMetric(stat[13], device, "sent", "colls")
 		ch <- c.events.newConstMetric(stat[14], device, "sent", "carrier")
 		ch <- c.events.newConstMetric(stat[15], device, "sent", "compressed")
+
+		// Link properties are available only for devices which are visible in sysfs.
+		link, err := getNetdevLink(config.sysfsPath("class", "net", device))
+		if err != nil {
+			log.Debugf("get link properties of %s failed: %s; skip", device, err)
+			continue
+		}
+
+		ch <- c.up.newConstMetric(link.up, device)
+
+		// Speed is not available for virtual devices and devices without link.
+		if link.speed > 0 {
+			ch <- c.speed.newConstMetric(link.speed, device)
+		}
 	}
 
 	return nil
 }
 
 // getNetdevStats is the intermediate function which opens stats file and run stats parser for extracting stats.
-func getNetdevStats() (map[string][]float64, error) {
-	file, err := os.Open("/proc/net/dev")
+func getNetdevStats(path string) (map[string][]float64, error) {
+	file, err := os.Open(filepath.Clean(path))
 	if err != nil {
 		return nil, err
 	}
@@ -143,3 +176,36 @@ func parseNetdevStats(r io.Reader) (map[string][]float64, error) {
 
 	return stats, scanner.Err()
 }
+
+// netdevLink describes network device link properties.
+type netdevLink struct {
+	up    float64 // 1 if device operational state is 'up'
+	speed float64 // link speed in Mbit/s, -1 if unknown
+}
+
+// getNetdevLink reads link properties of network device from sysfs directory (e.g. /sys/class/net/eth0).
+func getNetdevLink(devpath string) (netdevLink, error) {
+	data, err := os.ReadFile(filepath.Join(devpath, "operstate"))
+	if err != nil {
+		return netdevLink{}, err
+	}
+
+	link := netdevLink{speed: -1}
+	if strings.TrimSpace(string(data)) == "up" {
+		link.up = 1
+	}
+
+	// Reading speed of virtual devices returns EINVAL, consider speed as unknown.
+	data, err = os.ReadFile(filepath.Join(devpath, "speed"))
+	if err != nil {
+		return link, nil
+	}
+
+	value := strings.TrimSpace(string(data))
+	link.speed, err = strconv.ParseFloat(value, 64)
+	if err != nil {
+		return netdevLink{}, fmt.Errorf("invalid input, parse '%s' failed: %w", value, err)
+	}
+
+	return link, nil
+}
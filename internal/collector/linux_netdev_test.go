@@ -16,6 +16,10 @@ func TestNetdevCollector_Update(t *testing.T) {
 			"node_network_packets_total",
 			"node_network_events_total",
 		},
+		optional: []string{
+			"node_network_up",
+			"node_network_speed_bytes",
+		},
 		collector:         NewNetdevCollector,
 		collectorSettings: model.CollectorSettings{Filters: filter.New()},
 	}
@@ -23,6 +27,56 @@ func TestNetdevCollector_Update(t *testing.T) {
 	pipeline(t, input)
 }
 
+func TestNetdevCollector_Update_testdata(t *testing.T) {
+	c, err := NewNetdevCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	nc := c.(*netdevCollector)
+	var up, speed int
+	for _, m := range collectMetrics(t, c, Config{ProcfsPath: "testdata/proc", SysfsPath: "testdata/sys"}) {
+		switch m.Desc().String() {
+		case nc.up.desc.String():
+			up++
+		case nc.speed.desc.String():
+			speed++
+		}
+	}
+
+	// Loopback is excluded by default, speed is known only for enp2s0.
+	assert.Equal(t, 2, up)
+	assert.Equal(t, 1, speed)
+}
+
+func TestNewNetdevCollector(t *testing.T) {
+	c, err := NewNetdevCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+	nc := c.(*netdevCollector)
+
+	for _, dev := range []string{"lo", "docker0", "virbr0", "veth1a2b3c"} {
+		assert.True(t, nc.up.hasFilter([]string{dev}), dev)
+	}
+	for _, dev := range []string{"eth0", "enp2s0", "bond0", "lo1"} {
+		assert.False(t, nc.up.hasFilter([]string{dev}), dev)
+	}
+}
+
+func Test_getNetdevLink(t *testing.T) {
+	link, err := getNetdevLink("testdata/sys/class/net/enp2s0")
+	assert.NoError(t, err)
+	assert.Equal(t, netdevLink{up: 1, speed: 1000}, link)
+
+	link, err = getNetdevLink("testdata/sys/class/net/wlxc8be19e6279d")
+	assert.NoError(t, err)
+	assert.Equal(t, netdevLink{up: 0, speed: -1}, link)
+
+	link, err = getNetdevLink("testdata/sys/class/net/lo")
+	assert.NoError(t, err)
+	assert.Equal(t, netdevLink{up: 0, speed: -1}, link)
+
+	_, err = getNetdevLink("testdata/sys/class/net/invalid")
+	assert.Error(t, err)
+}
+
 func Test_parseNetdevStats(t *testing.T) {
 	file, err := os.Open(filepath.Clean("testdata/proc/netdev.golden"))
 	assert.NoError(t, err)
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -31,7 +32,7 @@ func NewNetworkCollector(constLabels labels, settings model.CollectorSettings) (
 	}, nil
 }
 
-func (c *networkCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+func (c *networkCollector) Update(_ context.Context, _ Config, ch chan<- prometheus.Metric) error {
 	addresses, err := net.InterfaceAddrs()
 	if err != nil {
 		return err
@@ -0,0 +1,163 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type numaCollector struct {
+	re            *regexp.Regexp
+	subsysFilters filter.Filters
+	constLabels   labels
+	unavailable   sync.Once
+}
+
+// NewNumaCollector returns a new Collector exposing per-node memory stats of NUMA systems.
+func NewNumaCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &numaCollector{
+		re:            regexp.MustCompile(`\((.*)\)`),
+		subsysFilters: settings.Filters,
+		constLabels:   constLabels,
+	}, nil
+}
+
+// Update method collects per-node memory stats of NUMA systems.
+func (c *numaCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
+	nodes, err := filepath.Glob(config.sysfsPath("devices", "system", "node", "node[0-9]*"))
+	if err != nil {
+		return fmt.Errorf("get NUMA nodes failed: %s", err)
+	}
+
+	// Per-node stats make no sense on non-NUMA systems (or kernels without NUMA support), skip collecting silently.
+	if len(nodes) < 2 {
+		c.unavailable.Do(func() {
+			log.Infoln("NUMA is not available, skip collecting NUMA stats")
+		})
+		return nil
+	}
+
+	for _, nodepath := range nodes {
+		node := strings.TrimPrefix(filepath.Base(nodepath), "node")
+
+		meminfo, err := getNumaMeminfoStats(filepath.Join(nodepath, "meminfo"))
+		if err != nil {
+			return fmt.Errorf("get node %s meminfo stats failed: %s", node, err)
+		}
+
+		for param, v := range meminfo {
+			name := c.re.ReplaceAllString(param, "_${1}")
+			if v.bytes {
+				name += "_bytes"
+			}
+
+			desc := newBuiltinTypedDesc(
+				descOpts{"node", "memory", "numa_" + name, fmt.Sprintf("Memory information field %s of NUMA node.", param), 0},
+				prometheus.GaugeValue,
+				[]string{"node"}, c.constLabels,
+				c.subsysFilters,
+			)
+
+			ch <- desc.newConstMetric(v.value, node)
+		}
+
+		numastat, err := getNumastatStats(filepath.Join(nodepath, "numastat"))
+		if err != nil {
+			return fmt.Errorf("get node %s numastat stats failed: %s", node, err)
+		}
+
+		for param, value := range numastat {
+			name := strings.TrimPrefix(param, "numa_")
+
+			desc := newBuiltinTypedDesc(
+				descOpts{"node", "numa", name + "_total", fmt.Sprintf("NUMA allocation statistics field %s.", param), 0},
+				prometheus.CounterValue,
+				[]string{"node"}, c.constLabels,
+				c.subsysFilters,
+			)
+
+			ch <- desc.newConstMetric(value, node)
+		}
+	}
+
+	return nil
+}
+
+// numaMeminfoValue describes single value of per-node meminfo.
+type numaMeminfoValue struct {
+	value float64
+	bytes bool // value is accounted in bytes
+}
+
+// getNumaMeminfoStats opens per-node meminfo file and parses its content.
+func getNumaMeminfoStats(path string) (map[string]numaMeminfoValue, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	return parseNumaMeminfoStats(file)
+}
+
+// parseNumaMeminfoStats parses per-node meminfo content, e.g. 'Node 0 MemTotal:   32802092 kB'.
+func parseNumaMeminfoStats(r io.Reader) (map[string]numaMeminfoValue, error) {
+	log.Debug("parse NUMA node meminfo stats")
+
+	var (
+		scanner = bufio.NewScanner(r)
+		stats   = map[string]numaMeminfoValue{}
+	)
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+
+		if len(parts) < 4 || len(parts) > 5 || parts[0] != "Node" {
+			return nil, fmt.Errorf("invalid input, '%s': wrong number of values", scanner.Text())
+		}
+
+		param, value := strings.TrimRight(parts[2], ":"), parts[3]
+
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.Errorf("invalid input, parse '%s' failed: %s, skip", value, err.Error())
+			continue
+		}
+
+		var bytes bool
+		if len(parts) == 5 && parts[4] == "kB" {
+			v *= 1024
+			bytes = true
+		}
+
+		stats[param] = numaMeminfoValue{value: v, bytes: bytes}
+	}
+
+	return stats, scanner.Err()
+}
+
+// getNumastatStats opens per-node numastat file and parses its content.
+func getNumastatStats(path string) (map[string]float64, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	// numastat has the same 'key value' format as /proc/vmstat.
+	return parseVmstatStats(file)
+}
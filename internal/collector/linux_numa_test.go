@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNumaCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"node_memory_numa_MemTotal_bytes",
+			"node_memory_numa_MemFree_bytes",
+			"node_numa_hit_total",
+			"node_numa_miss_total",
+		},
+		collector: NewNumaCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func TestNumaCollector_Update_testdata(t *testing.T) {
+	c, err := NewNumaCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	// NUMA stats are available: 2 nodes * (6 meminfo + 6 numastat).
+	assert.Len(t, collectMetrics(t, c, Config{SysfsPath: "testdata/sys"}), 24)
+
+	// Single-node system.
+	tmpdir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpdir, "devices", "system", "node", "node0"), 0750))
+
+	for _, path := range []string{tmpdir, "testdata/invalid"} {
+		assert.Empty(t, collectMetrics(t, c, Config{SysfsPath: path}))
+	}
+}
+
+func Test_parseNumaMeminfoStats(t *testing.T) {
+	file, err := os.Open(filepath.Clean("testdata/sys/devices/system/node/node0/meminfo"))
+	assert.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	stats, err := parseNumaMeminfoStats(file)
+	assert.NoError(t, err)
+
+	want := map[string]numaMeminfoValue{
+		"MemTotal":        {value: 16401046 * 1024, bytes: true},
+		"MemFree":         {value: 1230412 * 1024, bytes: true},
+		"MemUsed":         {value: 15170634 * 1024, bytes: true},
+		"Active(anon)":    {value: 5612348 * 1024, bytes: true},
+		"HugePages_Total": {value: 0},
+		"HugePages_Free":  {value: 0},
+	}
+	assert.Equal(t, want, stats)
+
+	// Test invalid input.
+	for _, s := range []string{
+		"Node 0 MemTotal:",
+		"MemTotal: 16401046 kB",
+		"Node 0 MemTotal: 16401046 kB invalid",
+	} {
+		_, err = parseNumaMeminfoStats(strings.NewReader(s))
+		assert.Error(t, err)
+	}
+}
+
+func Test_getNumastatStats(t *testing.T) {
+	stats, err := getNumastatStats("testdata/sys/devices/system/node/node1/numastat")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1846473817), stats["numa_hit"])
+	assert.Equal(t, float64(1024), stats["numa_miss"])
+	assert.Len(t, stats, 6)
+
+	_, err = getNumastatStats("testdata/sys/devices/system/node/node1/invalid")
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// pressureResources defines resources tracked by Pressure Stall Information (PSI).
+var pressureResources = []string{"cpu", "io", "memory"}
+
+type pressureCollector struct {
+	waiting     map[string]typedDesc
+	stalled     map[string]typedDesc
+	avg         typedDesc
+	unavailable sync.Once
+}
+
+// NewPressureCollector returns a new Collector exposing Pressure Stall Information (PSI) stats.
+func NewPressureCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	c := &pressureCollector{
+		waiting: map[string]typedDesc{},
+		stalled: map[string]typedDesc{},
+		avg: newBuiltinTypedDesc(
+			descOpts{"node", "pressure", "stall_ratio", "Share of time in which some (or all) non-idle tasks are stalled on resource, averaged over window.", .01},
+			prometheus.GaugeValue,
+			[]string{"resource", "kind", "window"}, constLabels,
+			settings.Filters,
+		),
+	}
+
+	for _, res := range pressureResources {
+		c.waiting[res] = newBuiltinTypedDesc(
+			descOpts{"node", "pressure", res + "_waiting_seconds_total", "Total time in seconds in which at least some tasks have been waiting for " + res + ".", .000001},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		)
+		c.stalled[res] = newBuiltinTypedDesc(
+			descOpts{"node", "pressure", res + "_stalled_seconds_total", "Total time in seconds in which all non-idle tasks have been stalled on " + res + ".", .000001},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		)
+	}
+
+	return c, nil
+}
+
+// Update implements Collector and exposes PSI related metrics from /proc/pressure.
+func (c *pressureCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
+	for _, res := range pressureResources {
+		stats, err := getPressureStats(config.procfsPath("pressure", res))
+		if err != nil {
+			// Kernels without PSI support (or with PSI disabled) have no /proc/pressure, skip collecting silently.
+			if os.IsNotExist(err) {
+				c.unavailable.Do(func() {
+					log.Infoln("pressure stall information is not available, skip collecting pressure stats")
+				})
+				return nil
+			}
+			return fmt.Errorf("get %s pressure stats failed: %s", res, err)
+		}
+
+		waiting, stalled := c.waiting[res], c.stalled[res]
+		for kind, s := range stats {
+			switch kind {
+			case "some":
+				ch <- waiting.newConstMetric(s.total)
+			case "full":
+				ch <- stalled.newConstMetric(s.total)
+			}
+
+			ch <- c.avg.newConstMetric(s.avg10, res, kind, "10s")
+			ch <- c.avg.newConstMetric(s.avg60, res, kind, "60s")
+			ch <- c.avg.newConstMetric(s.avg300, res, kind, "300s")
+		}
+	}
+
+	return nil
+}
+
+// pressureStat describes single line of PSI stats file.
+type pressureStat struct {
+	avg10  float64
+	avg60  float64
+	avg300 float64
+	total  float64
+}
+
+// getPressureStats opens PSI stats file and parses its content.
+func getPressureStats(path string) (map[string]pressureStat, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	return parsePressureStats(file)
+}
+
+// parsePressureStats parses PSI stats file content and returns stats by kind ('some' or 'full').
+func parsePressureStats(r io.Reader) (map[string]pressureStat, error) {
+	log.Debug("parse pressure stats")
+
+	var scanner = bufio.NewScanner(r)
+	var stats = map[string]pressureStat{}
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+
+		if len(parts) != 5 {
+			return nil, fmt.Errorf("invalid input: '%s': wrong number of values", scanner.Text())
+		}
+
+		kind := parts[0]
+		if kind != "some" && kind != "full" {
+			return nil, fmt.Errorf("invalid input: '%s': unknown kind", scanner.Text())
+		}
+
+		var s pressureStat
+		for _, part := range parts[1:] {
+			kv := strings.SplitN(part, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid input: '%s': wrong value format", part)
+			}
+
+			v, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid input, parse '%s' failed: %w", kv[1], err)
+			}
+
+			switch kv[0] {
+			case "avg10":
+				s.avg10 = v
+			case "avg60":
+				s.avg60 = v
+			case "avg300":
+				s.avg300 = v
+			case "total":
+				s.total = v
+			default:
+				return nil, fmt.Errorf("invalid input: '%s': unknown key", part)
+			}
+		}
+
+		stats[kind] = s
+	}
+
+	return stats, scanner.Err()
+}
@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPressureCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"node_pressure_cpu_waiting_seconds_total",
+			"node_pressure_cpu_stalled_seconds_total",
+			"node_pressure_io_waiting_seconds_total",
+			"node_pressure_io_stalled_seconds_total",
+			"node_pressure_memory_waiting_seconds_total",
+			"node_pressure_memory_stalled_seconds_total",
+			"node_pressure_stall_ratio",
+		},
+		collector: NewPressureCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func TestPressureCollector_Update_testdata(t *testing.T) {
+	c, err := NewPressureCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	// Pressure stats are available: 3 resources * 2 kinds * (1 total + 3 averages).
+	assert.Len(t, collectMetrics(t, c, Config{ProcfsPath: "testdata/proc"}), 24)
+
+	// Pressure stats are not available.
+	assert.Empty(t, collectMetrics(t, c, Config{ProcfsPath: "testdata/invalid"}))
+}
+
+func Test_getPressureStats(t *testing.T) {
+	stats, err := getPressureStats("testdata/proc/pressure/io")
+	assert.NoError(t, err)
+	assert.Len(t, stats, 2)
+
+	_, err = getPressureStats("testdata/proc/pressure/invalid")
+	assert.Error(t, err)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_parsePressureStats(t *testing.T) {
+	file, err := os.Open(filepath.Clean("testdata/proc/pressure/io"))
+	assert.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	stats, err := parsePressureStats(file)
+	assert.NoError(t, err)
+
+	want := map[string]pressureStat{
+		"some": {avg10: 1.25, avg60: 0.80, avg300: 0.40, total: 98765432},
+		"full": {avg10: 0.50, avg60: 0.30, avg300: 0.10, total: 45678901},
+	}
+	assert.Equal(t, want, stats)
+
+	// Test invalid input.
+	for _, s := range []string{
+		"some avg10=0.00 avg60=0.00 avg300=0.00",
+		"invalid avg10=0.00 avg60=0.00 avg300=0.00 total=0",
+		"some avg10=0.00 avg60=0.00 avg300=0.00 total",
+		"some avg10=0.00 avg60=0.00 avg300=0.00 total=invalid",
+		"some avg10=0.00 avg60=0.00 avg300=0.00 invalid=0",
+	} {
+		_, err = parsePressureStats(strings.NewReader(s))
+		assert.Error(t, err)
+	}
+}
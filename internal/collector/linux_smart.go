@@ -0,0 +1,239 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// smartReallocatedSectorsID defines ID of ATA SMART attribute 'Reallocated_Sector_Ct'.
+	smartReallocatedSectorsID = 5
+
+	// smartctlFatalExitBits defines bits of smartctl exit status which mean the device has not been queried at all
+	// (command line did not parse, or device open failed). Other bits report device problems found by smartctl.
+	smartctlFatalExitBits = 0x03
+)
+
+type smartCollector struct {
+	smartctl    string // path to smartctl executable
+	unavailable sync.Once
+	filters     filter.Filters
+	health      typedDesc
+	attribute   typedDesc
+}
+
+// NewSmartCollector returns a new Collector exposing SMART health of storage devices. The collector is disabled by
+// default and should be explicitly enabled in collectors settings.
+func NewSmartCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	// Use the same default devices filter as diskstats collector.
+	if settings.Filters == nil {
+		settings.Filters = filter.New()
+	}
+
+	if _, ok := settings.Filters["device"]; !ok {
+		settings.Filters.Add("device", filter.Filter{Exclude: diskstatsDefaultIgnoredDevices})
+	}
+
+	err := settings.Filters.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("compile smart device filter failed: %s", err)
+	}
+
+	smartctl := "smartctl"
+	if v, ok := settings.Options["smartctl_path"]; ok {
+		smartctl = v
+	}
+
+	return &smartCollector{
+		smartctl: smartctl,
+		filters:  settings.Filters,
+		health: newBuiltinTypedDesc(
+			descOpts{"node", "smart", "device_health", "Overall SMART health self-assessment of the device (1 = passed, 0 = failed).", 0},
+			prometheus.GaugeValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
+		attribute: newBuiltinTypedDesc(
+			descOpts{"node", "smart", "attribute", "Value of SMART attribute of the device.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "attribute"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update implements Collector and exposes SMART health of physical storage devices using smartctl.
+func (c *smartCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	smartctl, err := exec.LookPath(c.smartctl)
+	if err != nil {
+		c.unavailable.Do(func() {
+			log.Infof("smartctl is not available: %s, skip collecting SMART stats", err)
+		})
+		return nil
+	}
+
+	devices, err := getPhysicalDevices(config.sysfsPath("block", "*"))
+	if err != nil {
+		return fmt.Errorf("get storage devices failed: %s", err)
+	}
+
+	for _, device := range devices {
+		if f, ok := c.filters["device"]; ok && !f.Pass(device) {
+			continue
+		}
+
+		stat, err := getSmartStat(ctx, smartctl, "/dev/"+device)
+		if err != nil {
+			// Collector's timeout exceeded, there is no reason to query remaining devices.
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Warnf("get SMART stats of %s failed: %s; skip", device, err)
+			continue
+		}
+
+		if stat.health != nil {
+			var v float64
+			if *stat.health {
+				v = 1
+			}
+			ch <- c.health.newConstMetric(v, device)
+		}
+
+		for name, v := range stat.attributes {
+			ch <- c.attribute.newConstMetric(v, device, name)
+		}
+	}
+
+	return nil
+}
+
+// getPhysicalDevices returns names of physical storage devices. Virtual devices (LVM, MDRAID, etc.) don't have
+// 'device' symlink and have no SMART.
+func getPhysicalDevices(path string) ([]string, error) {
+	dirs, err := filepath.Glob(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []string
+	for _, devpath := range dirs {
+		if _, err := os.Stat(devpath + "/device"); err != nil {
+			continue
+		}
+		devices = append(devices, filepath.Base(devpath))
+	}
+
+	return devices, nil
+}
+
+// smartStat describes SMART stats of a device.
+type smartStat struct {
+	health     *bool              // overall health self-assessment, nil if unknown
+	attributes map[string]float64 // SMART attributes
+}
+
+// getSmartStat runs smartctl for passed device and parses its output.
+func getSmartStat(ctx context.Context, smartctl string, device string) (smartStat, error) {
+	out, err := exec.CommandContext(ctx, smartctl, "--json", "--info", "--health", "--attributes", device).Output()
+	if err != nil {
+		// Non-zero exit status is also returned when smartctl found problems with the device, its output is still
+		// valid in this case.
+		var ee *exec.ExitError
+		if !errors.As(err, &ee) || ee.ExitCode() < 0 || ee.ExitCode()&smartctlFatalExitBits != 0 {
+			return smartStat{}, fmt.Errorf("%s: %s", err, smartctlMessage(out))
+		}
+	}
+
+	return parseSmartctlOutput(out)
+}
+
+// smartctlMessage returns error messages reported by smartctl in JSON output.
+func smartctlMessage(out []byte) string {
+	var v struct {
+		Smartctl struct {
+			Messages []struct {
+				String string `json:"string"`
+			} `json:"messages"`
+		} `json:"smartctl"`
+	}
+
+	if err := json.Unmarshal(out, &v); err != nil || len(v.Smartctl.Messages) == 0 {
+		return "no details"
+	}
+
+	return v.Smartctl.Messages[0].String
+}
+
+// smartctlOutput describes used parts of 'smartctl --json' output.
+type smartctlOutput struct {
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature *struct {
+		Current float64 `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime *struct {
+		Hours float64 `json:"hours"`
+	} `json:"power_on_time"`
+	AtaSmartAttributes *struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value float64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NvmeSmartHealth *struct {
+		MediaErrors float64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// parseSmartctlOutput parses 'smartctl --json' output and returns SMART stats.
+func parseSmartctlOutput(out []byte) (smartStat, error) {
+	log.Debug("parse smartctl output")
+
+	var v smartctlOutput
+	if err := json.Unmarshal(out, &v); err != nil {
+		return smartStat{}, fmt.Errorf("invalid input: %s", err)
+	}
+
+	stat := smartStat{attributes: map[string]float64{}}
+
+	if v.SmartStatus != nil {
+		passed := v.SmartStatus.Passed
+		stat.health = &passed
+	}
+
+	if v.Temperature != nil {
+		stat.attributes["temperature_celsius"] = v.Temperature.Current
+	}
+
+	if v.PowerOnTime != nil {
+		stat.attributes["power_on_hours"] = v.PowerOnTime.Hours
+	}
+
+	if v.AtaSmartAttributes != nil {
+		for _, a := range v.AtaSmartAttributes.Table {
+			if a.ID == smartReallocatedSectorsID {
+				stat.attributes["reallocated_sector_count"] = a.Raw.Value
+			}
+		}
+	}
+
+	if v.NvmeSmartHealth != nil {
+		stat.attributes["media_errors"] = v.NvmeSmartHealth.MediaErrors
+	}
+
+	return stat, nil
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSmartCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"node_smart_device_health",
+			"node_smart_attribute",
+		},
+		collector: NewSmartCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func TestSmartCollector_Update_unavailable(t *testing.T) {
+	c, err := NewSmartCollector(labels{}, model.CollectorSettings{Options: model.CollectorOptions{"smartctl_path": "/nonexistent/smartctl"}})
+	assert.NoError(t, err)
+
+	assert.Empty(t, collectMetrics(t, c, Config{SysfsPath: "testdata/sys"}))
+}
+
+func Test_getPhysicalDevices(t *testing.T) {
+	devices, err := getPhysicalDevices("testdata/sys/block/*")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sdb"}, devices)
+}
+
+func Test_parseSmartctlOutput(t *testing.T) {
+	passed, failed := true, false
+
+	testcases := []struct {
+		in   string
+		want smartStat
+	}{
+		{
+			in: "testdata/smartctl/sda.json",
+			want: smartStat{health: &passed, attributes: map[string]float64{
+				"reallocated_sector_count": 8, "temperature_celsius": 33, "power_on_hours": 21093,
+			}},
+		},
+		{
+			in: "testdata/smartctl/nvme0n1.json",
+			want: smartStat{health: &failed, attributes: map[string]float64{
+				"media_errors": 2, "temperature_celsius": 41, "power_on_hours": 1200,
+			}},
+		},
+	}
+
+	for _, tc := range testcases {
+		out, err := os.ReadFile(filepath.Clean(tc.in))
+		assert.NoError(t, err)
+
+		got, err := parseSmartctlOutput(out)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, got)
+	}
+
+	// Device without SMART support.
+	got, err := parseSmartctlOutput([]byte(`{"smartctl": {"exit_status": 4}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, smartStat{attributes: map[string]float64{}}, got)
+
+	_, err = parseSmartctlOutput([]byte("invalid"))
+	assert.Error(t, err)
+}
+
+func Test_smartctlMessage(t *testing.T) {
+	assert.Equal(t, "Smartctl open device: /dev/sdx failed: No such device",
+		smartctlMessage([]byte(`{"smartctl": {"messages": [{"string": "Smartctl open device: /dev/sdx failed: No such device", "severity": "error"}]}}`)),
+	)
+	assert.Equal(t, "no details", smartctlMessage([]byte("invalid")))
+}
@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type softnetCollector struct {
+	processed typedDesc
+	dropped   typedDesc
+	squeezed  typedDesc
+}
+
+// NewSoftnetCollector returns a new Collector exposing per-CPU network backlog stats.
+func NewSoftnetCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &softnetCollector{
+		processed: newBuiltinTypedDesc(
+			descOpts{"node", "softnet", "processed_total", "Total number of network frames processed by CPU.", 0},
+			prometheus.CounterValue,
+			[]string{"cpu"}, constLabels,
+			settings.Filters,
+		),
+		dropped: newBuiltinTypedDesc(
+			descOpts{"node", "softnet", "dropped_total", "Total number of network frames dropped by CPU because of full backlog queue.", 0},
+			prometheus.CounterValue,
+			[]string{"cpu"}, constLabels,
+			settings.Filters,
+		),
+		squeezed: newBuiltinTypedDesc(
+			descOpts{"node", "softnet", "times_squeezed_total", "Total number of times CPU ran out of budget or time when processing network frames.", 0},
+			prometheus.CounterValue,
+			[]string{"cpu"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update implements Collector and exposes network backlog related metrics from /proc/net/softnet_stat.
+func (c *softnetCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
+	stats, err := getSoftnetStats(config.procfsPath("net", "softnet_stat"))
+	if err != nil {
+		return fmt.Errorf("get softnet stats failed: %s", err)
+	}
+
+	for _, s := range stats {
+		ch <- c.processed.newConstMetric(s.processed, s.cpu)
+		ch <- c.dropped.newConstMetric(s.dropped, s.cpu)
+		ch <- c.squeezed.newConstMetric(s.squeezed, s.cpu)
+	}
+
+	return nil
+}
+
+// softnetStat describes network backlog stats of single CPU.
+type softnetStat struct {
+	cpu       string
+	processed float64
+	dropped   float64
+	squeezed  float64
+}
+
+// getSoftnetStats opens softnet stats file and parses its content.
+func getSoftnetStats(path string) ([]softnetStat, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	return parseSoftnetStats(file)
+}
+
+// parseSoftnetStats parses softnet stats content. Each line contains hex values of single CPU. Since kernel 5.10 the
+// 13th value contains CPU number, on older kernels the number of line is used as CPU number.
+func parseSoftnetStats(r io.Reader) ([]softnetStat, error) {
+	log.Debug("parse softnet stats")
+
+	var (
+		scanner = bufio.NewScanner(r)
+		stats   []softnetStat
+	)
+
+	for n := 0; scanner.Scan(); n++ {
+		parts := strings.Fields(scanner.Text())
+
+		// Old kernels (2.6) provide 9 values, the first three ones are needed.
+		if len(parts) < 9 {
+			return nil, fmt.Errorf("invalid input, '%s': wrong number of values", scanner.Text())
+		}
+
+		values := make([]float64, 3)
+		for i := range values {
+			v, err := strconv.ParseUint(parts[i], 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid input, parse '%s' failed: %w", parts[i], err)
+			}
+			values[i] = float64(v)
+		}
+
+		cpu := strconv.Itoa(n)
+		if len(parts) >= 13 {
+			v, err := strconv.ParseUint(parts[12], 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid input, parse '%s' failed: %w", parts[12], err)
+			}
+			cpu = strconv.FormatUint(v, 10)
+		}
+
+		stats = append(stats, softnetStat{cpu: cpu, processed: values[0], dropped: values[1], squeezed: values[2]})
+	}
+
+	return stats, scanner.Err()
+}
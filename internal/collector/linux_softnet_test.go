@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSoftnetCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"node_softnet_processed_total",
+			"node_softnet_dropped_total",
+			"node_softnet_times_squeezed_total",
+		},
+		collector: NewSoftnetCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func TestSoftnetCollector_Update_testdata(t *testing.T) {
+	c, err := NewSoftnetCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	// 2 CPUs * 3 metrics.
+	assert.Len(t, collectMetrics(t, c, Config{ProcfsPath: "testdata/proc"}), 6)
+}
+
+func Test_parseSoftnetStats(t *testing.T) {
+	file, err := os.Open(filepath.Clean("testdata/proc/net/softnet_stat"))
+	assert.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	stats, err := parseSoftnetStats(file)
+	assert.NoError(t, err)
+
+	want := []softnetStat{
+		{cpu: "0", processed: 42177, dropped: 0, squeezed: 2},
+		{cpu: "2", processed: 127667, dropped: 10, squeezed: 31},
+	}
+	assert.Equal(t, want, stats)
+
+	// Old kernels without CPU number.
+	stats, err = parseSoftnetStats(strings.NewReader(
+		"00000010 00000001 00000002 00000000 00000000 00000000 00000000 00000000 00000000\n" +
+			"00000020 00000003 00000004 00000000 00000000 00000000 00000000 00000000 00000000\n",
+	))
+	assert.NoError(t, err)
+	assert.Equal(t, []softnetStat{
+		{cpu: "0", processed: 16, dropped: 1, squeezed: 2},
+		{cpu: "1", processed: 32, dropped: 3, squeezed: 4},
+	}, stats)
+
+	// Test invalid input.
+	for _, s := range []string{
+		"00000010 00000001 00000002",
+		"invalid 00000001 00000002 00000000 00000000 00000000 00000000 00000000 00000000",
+		"00000010 00000001 00000002 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000000 invalid",
+	} {
+		_, err = parseSoftnetStats(strings.NewReader(s))
+		assert.Error(t, err)
+	}
+}
@@ -3,6 +3,7 @@ package collector
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -23,6 +24,7 @@ type systemCollector struct {
 	governors  typedDesc
 	numanodes  typedDesc
 	ctxt       typedDesc
+	intr       typedDesc
 	forks      typedDesc
 	btime      typedDesc
 }
@@ -74,6 +76,12 @@ func NewSysconfigCollector(constLabels labels, settings model.CollectorSettings)
 			nil, constLabels,
 			settings.Filters,
 		),
+		intr: newBuiltinTypedDesc(
+			descOpts{"node", "", "intr_total", "Total number of interrupts serviced.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 		forks: newBuiltinTypedDesc(
 			descOpts{"node", "", "forks_total", "Total number of forks.", 0},
 			prometheus.CounterValue,
@@ -90,7 +98,7 @@ func NewSysconfigCollector(constLabels labels, settings model.CollectorSettings)
 }
 
 // Update method collects filesystem usage statistics.
-func (c *systemCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+func (c *systemCollector) Update(_ context.Context, _ Config, ch chan<- prometheus.Metric) error {
 	sysctls := readSysctls(c.sysctlList)
 
 	for name, value := range sysctls {
@@ -130,6 +138,7 @@ func (c *systemCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
 		log.Warnf("parse /proc/stat failed: %s; skip", err)
 	} else {
 		ch <- c.ctxt.newConstMetric(stat.ctxt)
+		ch <- c.intr.newConstMetric(stat.intr)
 		ch <- c.btime.newConstMetric(stat.btime)
 		ch <- c.forks.newConstMetric(stat.forks)
 	}
@@ -256,9 +265,12 @@ func countNumaNodes(path string) (n float64, err error) {
 
 // systemProcStat represents some stats from /proc/stat file.
 type systemProcStat struct {
-	ctxt  float64
-	btime float64
-	forks float64
+	ctxt         float64
+	intr         float64
+	btime        float64
+	forks        float64
+	procsRunning float64
+	procsBlocked float64
 }
 
 func getProcStat() (systemProcStat, error) {
@@ -293,6 +305,11 @@ func parseProcStat(r io.Reader) (systemProcStat, error) {
 			if err != nil {
 				return stat, fmt.Errorf("invalid input, parse '%s' (ctxt) failed: %s; skip", parts[1], err)
 			}
+		case "intr":
+			stat.intr, err = strconv.ParseFloat(parts[1], 64)
+			if err != nil {
+				return stat, fmt.Errorf("invalid input, parse '%s' (intr) failed: %s; skip", parts[1], err)
+			}
 		case "btime":
 			stat.btime, err = strconv.ParseFloat(parts[1], 64)
 			if err != nil {
@@ -303,6 +320,16 @@ func parseProcStat(r io.Reader) (systemProcStat, error) {
 			if err != nil {
 				return stat, fmt.Errorf("invalid input, parse '%s' (processes) failed: %s; skip", parts[1], err)
 			}
+		case "procs_running":
+			stat.procsRunning, err = strconv.ParseFloat(parts[1], 64)
+			if err != nil {
+				return stat, fmt.Errorf("invalid input, parse '%s' (procs_running) failed: %s; skip", parts[1], err)
+			}
+		case "procs_blocked":
+			stat.procsBlocked, err = strconv.ParseFloat(parts[1], 64)
+			if err != nil {
+				return stat, fmt.Errorf("invalid input, parse '%s' (procs_blocked) failed: %s; skip", parts[1], err)
+			}
 		default:
 			continue
 		}
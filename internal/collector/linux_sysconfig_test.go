@@ -14,6 +14,7 @@ func TestSystemCollector_Update(t *testing.T) {
 			"node_system_cpu_cores_total",
 			"node_system_numa_nodes_total",
 			"node_context_switches_total",
+			"node_intr_total",
 			"node_forks_total",
 			"node_boot_time_seconds",
 		},
@@ -81,9 +82,12 @@ func Test_parseProcStat(t *testing.T) {
 		want  systemProcStat
 	}{
 		{in: "testdata/proc/stat.golden", valid: true, want: systemProcStat{
-			ctxt:  3253088019,
-			btime: 1596255715,
-			forks: 214670,
+			ctxt:         3253088019,
+			intr:         1569470757,
+			btime:        1596255715,
+			forks:        214670,
+			procsRunning: 1,
+			procsBlocked: 0,
 		}},
 		{in: "testdata/proc/stat.invalid", valid: false},
 	}
@@ -2,6 +2,7 @@ package collector
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -36,7 +37,7 @@ func NewSysInfoCollector(constLabels labels, settings model.CollectorSettings) (
 }
 
 // Update implements Collector and exposes system info metrics.
-func (c *sysinfoCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+func (c *sysinfoCollector) Update(_ context.Context, _ Config, ch chan<- prometheus.Metric) error {
 	info, err := getSysInfo()
 	if err != nil {
 		return err
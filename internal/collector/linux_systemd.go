@@ -0,0 +1,248 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// systemdDefaultUnits defines default patterns of units which state should be collected.
+	systemdDefaultUnits = "postgresql*,pgbouncer*"
+)
+
+// systemdUnitStates defines possible active states of systemd units.
+var systemdUnitStates = []string{"active", "activating", "deactivating", "inactive", "failed"}
+
+type systemdCollector struct {
+	units      []string // patterns of units names
+	disabledMu sync.Mutex
+	disabled   bool // systemd is not available, collector is disabled
+	state      typedDesc
+	restarts   typedDesc
+}
+
+// NewSystemdCollector returns a new Collector exposing state of systemd units.
+func NewSystemdCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	units := systemdDefaultUnits
+	if v, ok := settings.Options["units"]; ok {
+		units = v
+	}
+
+	var patterns []string
+	for _, p := range strings.Split(units, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("invalid value '%s' of option 'units': no units specified", units)
+	}
+
+	return &systemdCollector{
+		units: patterns,
+		state: newBuiltinTypedDesc(
+			descOpts{"node", "systemd", "unit_state", "Systemd unit active state.", 0},
+			prometheus.GaugeValue,
+			[]string{"name", "state"}, constLabels,
+			settings.Filters,
+		),
+		restarts: newBuiltinTypedDesc(
+			descOpts{"node", "systemd", "service_restart_total", "Total number of automatic restarts of systemd service.", 0},
+			prometheus.CounterValue,
+			[]string{"name"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update implements Collector and exposes state of systemd units. Units are queried using systemctl, which talks to
+// systemd over D-Bus.
+func (c *systemdCollector) Update(ctx context.Context, _ Config, ch chan<- prometheus.Metric) error {
+	c.disabledMu.Lock()
+	disabled := c.disabled
+	c.disabledMu.Unlock()
+
+	if disabled {
+		return nil
+	}
+
+	args := append([]string{"list-units", "--all", "--plain", "--no-legend", "--no-pager"}, c.units...)
+	out, err := exec.CommandContext(ctx, "systemctl", args...).Output()
+	if err != nil {
+		if !systemdAbsent(err) {
+			return fmt.Errorf("list systemd units failed: %s", systemctlError(err))
+		}
+
+		// Systemd is not installed or not used as init system (e.g. in containers), there is no reason to try again
+		// on every scrape.
+		log.Infof("systemd is not available: %s, skip collecting systemd stats", systemctlError(err))
+
+		c.disabledMu.Lock()
+		c.disabled = true
+		c.disabledMu.Unlock()
+
+		return nil
+	}
+
+	units, err := parseSystemdUnits(bytes.NewReader(out))
+	if err != nil {
+		return fmt.Errorf("parse systemd units failed: %s", err)
+	}
+
+	var services []string
+	for _, u := range units {
+		for _, state := range systemdUnitStates {
+			var v float64
+			if u.state == state {
+				v = 1
+			}
+			ch <- c.state.newConstMetric(v, u.name, state)
+		}
+
+		if strings.HasSuffix(u.name, ".service") {
+			services = append(services, u.name)
+		}
+	}
+
+	if len(services) == 0 {
+		return nil
+	}
+
+	args = append([]string{"show", "--property=Id,NRestarts"}, services...)
+	out, err = exec.CommandContext(ctx, "systemctl", args...).Output()
+	if err != nil {
+		return fmt.Errorf("get systemd services properties failed: %s", systemctlError(err))
+	}
+
+	restarts, err := parseSystemdRestarts(bytes.NewReader(out))
+	if err != nil {
+		return fmt.Errorf("parse systemd services properties failed: %s", err)
+	}
+
+	for name, v := range restarts {
+		ch <- c.restarts.newConstMetric(v, name)
+	}
+
+	return nil
+}
+
+// systemdAbsent returns true if systemctl failed because systemd is not installed or not used as init system. Other
+// failures, e.g. temporarily unavailable D-Bus, are not considered as systemd absence.
+func systemdAbsent(err error) bool {
+	if errors.Is(err, exec.ErrNotFound) {
+		return true
+	}
+
+	var ee *exec.ExitError
+	return errors.As(err, &ee) && bytes.Contains(ee.Stderr, []byte("System has not been booted with systemd"))
+}
+
+// systemctlError returns error message extended with systemctl's stderr output.
+func systemctlError(err error) string {
+	if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+		return fmt.Sprintf("%s: %s", err, strings.Join(strings.Fields(string(ee.Stderr)), " "))
+	}
+
+	return err.Error()
+}
+
+// systemdUnit describes name and active state of systemd unit.
+type systemdUnit struct {
+	name  string
+	state string
+}
+
+// parseSystemdUnits parses 'systemctl list-units --plain --no-legend' output, e.g.
+// 'postgresql.service loaded active exited PostgreSQL RDBMS'.
+func parseSystemdUnits(r io.Reader) ([]systemdUnit, error) {
+	log.Debug("parse systemd units")
+
+	var (
+		scanner = bufio.NewScanner(r)
+		units   []systemdUnit
+	)
+
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+
+		// Failed units might be prefixed by a status mark.
+		if parts[0] == "●" || parts[0] == "*" {
+			parts = parts[1:]
+		}
+
+		if len(parts) < 4 {
+			return nil, fmt.Errorf("invalid input, '%s': wrong number of values", scanner.Text())
+		}
+
+		units = append(units, systemdUnit{name: parts[0], state: parts[2]})
+	}
+
+	return units, scanner.Err()
+}
+
+// parseSystemdRestarts parses 'systemctl show --property=Id,NRestarts' output and returns number of restarts of
+// services. Services without NRestarts property (systemd older than 235) are skipped.
+func parseSystemdRestarts(r io.Reader) (map[string]float64, error) {
+	log.Debug("parse systemd services properties")
+
+	var (
+		scanner  = bufio.NewScanner(r)
+		restarts = map[string]float64{}
+		id       string
+		value    string
+	)
+
+	// Properties are not printed in requested order, hence remember them and flush at the end of unit's block.
+	flush := func() {
+		if id != "" && value != "" {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				log.Errorf("invalid input, parse '%s' failed: %s, skip", value, err.Error())
+			} else {
+				restarts[id] = v
+			}
+		}
+		id, value = "", ""
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Properties of different units are separated by empty line.
+		if line == "" {
+			flush()
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid input, '%s': wrong format", line)
+		}
+
+		switch k {
+		case "Id":
+			id = v
+		case "NRestarts":
+			value = v
+		}
+	}
+
+	flush()
+
+	return restarts, scanner.Err()
+}
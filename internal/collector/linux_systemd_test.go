@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"errors"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSystemdCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"node_systemd_unit_state",
+			"node_systemd_service_restart_total",
+		},
+		collector: NewSystemdCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func TestNewSystemdCollector(t *testing.T) {
+	c, err := NewSystemdCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"postgresql*", "pgbouncer*"}, c.(*systemdCollector).units)
+
+	c, err = NewSystemdCollector(labels{}, model.CollectorSettings{Options: model.CollectorOptions{"units": "patroni.service, etcd*"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"patroni.service", "etcd*"}, c.(*systemdCollector).units)
+
+	_, err = NewSystemdCollector(labels{}, model.CollectorSettings{Options: model.CollectorOptions{"units": " , "}})
+	assert.Error(t, err)
+}
+
+func Test_systemdAbsent(t *testing.T) {
+	assert.True(t, systemdAbsent(&exec.Error{Name: "systemctl", Err: exec.ErrNotFound}))
+	assert.True(t, systemdAbsent(&exec.ExitError{Stderr: []byte("System has not been booted with systemd as init system (PID 1). Can't operate.\n")}))
+	assert.False(t, systemdAbsent(&exec.ExitError{Stderr: []byte("Failed to connect to bus: Connection refused\n")}))
+	assert.False(t, systemdAbsent(errors.New("signal: killed")))
+}
+
+func Test_parseSystemdUnits(t *testing.T) {
+	input := "postgresql.service          loaded active   exited  PostgreSQL RDBMS\n" +
+		"postgresql@16-main.service  loaded active   running PostgreSQL Cluster 16-main\n" +
+		"● pgbouncer.service         loaded failed   failed  connection pooler for PostgreSQL\n" +
+		"pgbouncer.socket            loaded inactive dead    pgbouncer socket\n"
+
+	units, err := parseSystemdUnits(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, []systemdUnit{
+		{name: "postgresql.service", state: "active"},
+		{name: "postgresql@16-main.service", state: "active"},
+		{name: "pgbouncer.service", state: "failed"},
+		{name: "pgbouncer.socket", state: "inactive"},
+	}, units)
+
+	_, err = parseSystemdUnits(strings.NewReader("postgresql.service loaded"))
+	assert.Error(t, err)
+}
+
+func Test_parseSystemdRestarts(t *testing.T) {
+	input := "NRestarts=0\nId=postgresql.service\n\n" +
+		"Id=pgbouncer.service\nNRestarts=3\n\n" +
+		"Id=old.service\n"
+
+	restarts, err := parseSystemdRestarts(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"postgresql.service": 0, "pgbouncer.service": 3}, restarts)
+
+	_, err = parseSystemdRestarts(strings.NewReader("invalid"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package collector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"syscall"
+	"testing"
+)
+
+func TestTimexCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"node_timex_offset_seconds",
+			"node_timex_sync_status",
+			"node_timex_frequency_adjustment_ratio",
+		},
+		collector: NewTimexCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parseTimex(t *testing.T) {
+	testcases := []struct {
+		buf   syscall.Timex
+		state int
+		want  timexStat
+	}{
+		// Synchronized clock, offset in microseconds.
+		{buf: syscall.Timex{Offset: 1500, Freq: 65536 * 10}, state: 0, want: timexStat{offset: 0.0015, syncStatus: 1, frequency: 1.00001}},
+		// Synchronized clock, offset in nanoseconds.
+		{buf: syscall.Timex{Offset: -2000000, Status: timexStatusNano}, state: 0, want: timexStat{offset: -0.002, syncStatus: 1, frequency: 1}},
+		// Unsynchronized clock.
+		{buf: syscall.Timex{}, state: timexStateError, want: timexStat{offset: 0, syncStatus: 0, frequency: 1}},
+	}
+
+	for _, tc := range testcases {
+		got := parseTimex(tc.buf, tc.state)
+		assert.InDelta(t, tc.want.offset, got.offset, 1e-12)
+		assert.Equal(t, tc.want.syncStatus, got.syncStatus)
+		assert.InDelta(t, tc.want.frequency, got.frequency, 1e-12)
+	}
+}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -215,7 +216,7 @@ func NewPatroniCommonCollector(constLabels labels, settings model.CollectorSetti
 	}, nil
 }
 
-func (c *patroniCommonCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+func (c *patroniCommonCollector) Update(_ context.Context, config Config, ch chan<- prometheus.Metric) error {
 	if strings.HasPrefix(config.BaseURL, "https://") {
 		c.client.EnableTLSInsecure()
 	}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
@@ -13,6 +14,7 @@ const (
 	// admin console queries used for retrieving stats.
 	poolsQuery   = "SHOW POOLS"
 	clientsQuery = "SHOW CLIENTS"
+	dbQuery      = "SHOW DATABASES"
 )
 
 type pgbouncerPoolsCollector struct {
@@ -20,6 +22,10 @@ type pgbouncerPoolsCollector struct {
 	conns      typedDesc
 	maxwait    typedDesc
 	clients    typedDesc
+	dbConns    typedDesc
+	dbMaxConns typedDesc
+	dbPaused   typedDesc
+	dbDisabled typedDesc
 }
 
 // NewPgbouncerPoolsCollector returns a new Collector exposing pgbouncer pools connections usage stats.
@@ -46,32 +52,63 @@ func NewPgbouncerPoolsCollector(constLabels labels, settings model.CollectorSett
 			[]string{"user", "database", "address"}, constLabels,
 			settings.Filters,
 		),
+		dbConns: newBuiltinTypedDesc(
+			descOpts{"pgbouncer", "database", "connections_in_flight", "The total number of server connections established for the database.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		dbMaxConns: newBuiltinTypedDesc(
+			descOpts{"pgbouncer", "database", "max_connections", "Maximum number of allowed server connections for the database, zero means unlimited.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		dbPaused: newBuiltinTypedDesc(
+			descOpts{"pgbouncer", "database", "paused", "Database is paused: 1 is paused, 0 is not.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		dbDisabled: newBuiltinTypedDesc(
+			descOpts{"pgbouncer", "database", "disabled", "Database is disabled: 1 is disabled, 0 is not.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
 		labelNames: poolsLabelNames,
 	}, nil
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *pgbouncerPoolsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *pgbouncerPoolsCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	res, err := conn.Query(poolsQuery)
+	res, err := conn.QueryContext(ctx, poolsQuery)
 	if err != nil {
 		return err
 	}
 
 	poolsStats := parsePgbouncerPoolsStats(res, c.labelNames)
 
-	res, err = conn.Query(clientsQuery)
+	res, err = conn.QueryContext(ctx, clientsQuery)
 	if err != nil {
 		return err
 	}
 
 	clientsStats := parsePgbouncerClientsStats(res)
 
+	res, err = conn.QueryContext(ctx, dbQuery)
+	if err != nil {
+		return err
+	}
+
+	dbStats := parsePgbouncerDatabasesStats(res)
+
 	// Process pools stats.
 	for _, stat := range poolsStats {
 		ch <- c.conns.newConstMetric(stat.clActive, stat.user, stat.database, stat.mode, "cl_active")
@@ -97,6 +134,14 @@ func (c *pgbouncerPoolsCollector) Update(config Config, ch chan<- prometheus.Met
 		ch <- c.clients.newConstMetric(v, user, database, address)
 	}
 
+	// Process databases stats.
+	for _, stat := range dbStats {
+		ch <- c.dbConns.newConstMetric(stat.current, stat.database)
+		ch <- c.dbMaxConns.newConstMetric(stat.max, stat.database)
+		ch <- c.dbPaused.newConstMetric(stat.paused, stat.database)
+		ch <- c.dbDisabled.newConstMetric(stat.disabled, stat.database)
+	}
+
 	return nil
 }
 
@@ -178,7 +223,10 @@ func parsePgbouncerPoolsStats(r *model.PGResult, labelNames []string) map[string
 			case "sv_login":
 				s.svLogin = v
 			case "maxwait":
-				s.maxWait = v
+				s.maxWait += v
+			case "maxwait_us":
+				// Since Pgbouncer 1.8 'maxwait' has seconds only, and microseconds part is in 'maxwait_us'.
+				s.maxWait += v / 1000000
 			default:
 				continue
 			}
@@ -219,3 +267,63 @@ func parsePgbouncerClientsStats(r *model.PGResult) map[string]float64 {
 
 	return stats
 }
+
+// pgbouncerDatabaseStat is a per-database store for server connections metrics.
+type pgbouncerDatabaseStat struct {
+	database string
+	current  float64
+	max      float64
+	paused   float64
+	disabled float64
+}
+
+// parsePgbouncerDatabasesStats parses query result and returns databases stats.
+func parsePgbouncerDatabasesStats(r *model.PGResult) map[string]pgbouncerDatabaseStat {
+	log.Debug("parse pgbouncer databases stats")
+
+	var stats = map[string]pgbouncerDatabaseStat{}
+
+	for _, row := range r.Rows {
+		stat := pgbouncerDatabaseStat{}
+
+		for i, colname := range r.Colnames {
+			// Database name defined in pgbouncer config is used as label, the same as in SHOW POOLS.
+			if string(colname.Name) == "name" {
+				stat.database = row[i].String
+				continue
+			}
+
+			// Skip empty (NULL) values.
+			if !row[i].Valid {
+				continue
+			}
+
+			// Choose a field for the value, skip all other columns.
+			var value *float64
+			switch string(colname.Name) {
+			case "current_connections":
+				value = &stat.current
+			case "max_connections":
+				value = &stat.max
+			case "paused":
+				value = &stat.paused
+			case "disabled":
+				value = &stat.disabled
+			default:
+				continue
+			}
+
+			v, err := strconv.ParseFloat(row[i].String, 64)
+			if err != nil {
+				log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+				continue
+			}
+
+			*value = v
+		}
+
+		stats[stat.database] = stat
+	}
+
+	return stats
+}
@@ -14,6 +14,10 @@ func TestPgbouncerPoolsCollector_Update(t *testing.T) {
 			"pgbouncer_pool_connections_in_flight",
 			"pgbouncer_pool_max_wait_seconds",
 			"pgbouncer_client_connections_in_flight",
+			"pgbouncer_database_connections_in_flight",
+			"pgbouncer_database_max_connections",
+			"pgbouncer_database_paused",
+			"pgbouncer_database_disabled",
 		},
 		collector: NewPgbouncerPoolsCollector,
 		service:   model.ServiceTypePgbouncer,
@@ -32,31 +36,31 @@ func Test_parsePgbouncerPoolsStats(t *testing.T) {
 			name: "normal output",
 			res: &model.PGResult{
 				Nrows: 2,
-				Ncols: 11,
+				Ncols: 12,
 				Colnames: []pgproto3.FieldDescription{
 					{Name: []byte("database")}, {Name: []byte("user")},
 					{Name: []byte("cl_active")}, {Name: []byte("cl_waiting")}, {Name: []byte("sv_active")}, {Name: []byte("sv_idle")},
 					{Name: []byte("sv_used")}, {Name: []byte("sv_tested")}, {Name: []byte("sv_login")}, {Name: []byte("maxwait")},
-					{Name: []byte("pool_mode")},
+					{Name: []byte("maxwait_us")}, {Name: []byte("pool_mode")},
 				},
 				Rows: [][]sql.NullString{
 					{
 						{String: "testdb1", Valid: true}, {String: "testuser1", Valid: true},
 						{String: "15", Valid: true}, {String: "5", Valid: true}, {String: "10", Valid: true}, {String: "1", Valid: true},
 						{String: "1", Valid: true}, {String: "1", Valid: true}, {String: "1", Valid: true}, {String: "1", Valid: true},
-						{String: "transaction", Valid: true},
+						{String: "250000", Valid: true}, {String: "transaction", Valid: true},
 					},
 					{
 						{String: "testdb2", Valid: true}, {String: "testuser2", Valid: true},
 						{String: "25", Valid: true}, {String: "10", Valid: true}, {String: "25", Valid: true}, {String: "2", Valid: true},
 						{String: "2", Valid: true}, {String: "2", Valid: true}, {String: "2", Valid: true}, {String: "2", Valid: true},
-						{String: "statement", Valid: true},
+						{String: "0", Valid: true}, {String: "statement", Valid: true},
 					},
 				},
 			},
 			want: map[string]pgbouncerPoolStat{
 				"testuser1/testdb1/transaction": {
-					database: "testdb1", user: "testuser1", clActive: 15, clWaiting: 5, svActive: 10, svIdle: 1, svUsed: 1, svTested: 1, svLogin: 1, maxWait: 1, mode: "transaction",
+					database: "testdb1", user: "testuser1", clActive: 15, clWaiting: 5, svActive: 10, svIdle: 1, svUsed: 1, svTested: 1, svLogin: 1, maxWait: 1.25, mode: "transaction",
 				},
 				"testuser2/testdb2/statement": {
 					database: "testdb2", user: "testuser2", clActive: 25, clWaiting: 10, svActive: 25, svIdle: 2, svUsed: 2, svTested: 2, svLogin: 2, maxWait: 2, mode: "statement",
@@ -115,3 +119,44 @@ func Test_parsePgbouncerClientsStats(t *testing.T) {
 		})
 	}
 }
+
+func Test_parsePgbouncerDatabasesStats(t *testing.T) {
+	var testCases = []struct {
+		name string
+		res  *model.PGResult
+		want map[string]pgbouncerDatabaseStat
+	}{
+		{
+			name: "normal output",
+			res: &model.PGResult{
+				Nrows: 2,
+				Ncols: 8,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("name")}, {Name: []byte("host")}, {Name: []byte("database")}, {Name: []byte("pool_mode")},
+					{Name: []byte("max_connections")}, {Name: []byte("current_connections")}, {Name: []byte("paused")}, {Name: []byte("disabled")},
+				},
+				Rows: [][]sql.NullString{
+					{
+						{String: "db1", Valid: true}, {String: "127.0.0.1", Valid: true}, {String: "postgres", Valid: true}, {String: "transaction", Valid: true},
+						{String: "100", Valid: true}, {String: "12", Valid: true}, {String: "0", Valid: true}, {String: "0", Valid: true},
+					},
+					{
+						{String: "pgbouncer", Valid: true}, {}, {String: "pgbouncer", Valid: true}, {String: "statement", Valid: true},
+						{String: "0", Valid: true}, {String: "0", Valid: true}, {String: "1", Valid: true}, {String: "1", Valid: true},
+					},
+				},
+			},
+			want: map[string]pgbouncerDatabaseStat{
+				"db1":       {database: "db1", max: 100, current: 12},
+				"pgbouncer": {database: "pgbouncer", paused: 1, disabled: 1},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parsePgbouncerDatabasesStats(tc.res)
+			assert.EqualValues(t, tc.want, got)
+		})
+	}
+}
@@ -60,8 +60,8 @@ func NewPgbouncerSettingsCollector(constLabels labels, settings model.CollectorS
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *pgbouncerSettingsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *pgbouncerSettingsCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
@@ -71,7 +71,7 @@ func (c *pgbouncerSettingsCollector) Update(config Config, ch chan<- prometheus.
 	// there is no way to extract version string. Query the version, if zero value is returned it
 	// means there is an old Pgbouncer is answered. Just skip collecting version metric and continue.
 
-	version, versionStr, err := queryPgbouncerVersion(conn)
+	version, versionStr, err := queryPgbouncerVersion(ctx, conn)
 	if err != nil {
 		return err
 	}
@@ -81,7 +81,7 @@ func (c *pgbouncerSettingsCollector) Update(config Config, ch chan<- prometheus.
 
 	// Query pgbouncer settings.
 
-	res, err := conn.Query(settingsQuery)
+	res, err := conn.QueryContext(ctx, settingsQuery)
 	if err != nil {
 		return err
 	}
@@ -125,9 +125,9 @@ func (c *pgbouncerSettingsCollector) Update(config Config, ch chan<- prometheus.
 }
 
 // queryPgbouncerVersion queries version info from Pgbouncer and return numeric and string version representation.
-func queryPgbouncerVersion(conn *store.DB) (int, string, error) {
+func queryPgbouncerVersion(ctx context.Context, conn *store.DB) (int, string, error) {
 	var versionStr string
-	err := conn.Conn().QueryRow(context.Background(), versionQuery).Scan(&versionStr)
+	err := conn.Conn().QueryRow(ctx, versionQuery).Scan(&versionStr)
 	if err != nil {
 		// Pgbouncer before 1.12 returns version string as a NOTICE, and it seems there is no way to extract
 		// message text from the NOTICE. Return zero value and nil as error.
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"github.com/jackc/pgproto3/v2"
 	"github.com/cherts/pgscv/internal/model"
@@ -27,7 +28,7 @@ func TestPgbouncerSettingsCollector_Update(t *testing.T) {
 func Test_queryPgbouncerVersion(t *testing.T) {
 	db := store.NewTestPgbouncer(t)
 
-	str, num, err := queryPgbouncerVersion(db)
+	str, num, err := queryPgbouncerVersion(context.Background(), db)
 	assert.NoError(t, err)
 	assert.NotEqual(t, "", str)
 	assert.NotEqual(t, 0, num)
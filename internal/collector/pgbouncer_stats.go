@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
@@ -11,7 +12,6 @@ import (
 const pgbouncerStatsQuery = "SHOW STATS"
 
 type pgbouncerStatsCollector struct {
-	up         typedDesc
 	xacts      typedDesc
 	queries    typedDesc
 	bytes      typedDesc
@@ -26,12 +26,6 @@ func NewPgbouncerStatsCollector(constLabels labels, settings model.CollectorSett
 
 	return &pgbouncerStatsCollector{
 		labelNames: pgbouncerLabelNames,
-		up: newBuiltinTypedDesc(
-			descOpts{"pgbouncer", "", "up", "State of Pgbouncer service: 0 is down, 1 is up.", 0},
-			prometheus.CounterValue,
-			nil, constLabels,
-			settings.Filters,
-		),
 		xacts: newBuiltinTypedDesc(
 			descOpts{"pgbouncer", "", "transactions_total", "Total number of SQL transactions processed, for each database.", 0},
 			prometheus.CounterValue,
@@ -64,15 +58,14 @@ func NewPgbouncerStatsCollector(constLabels labels, settings model.CollectorSett
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *pgbouncerStatsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *pgbouncerStatsCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
-		ch <- c.up.newConstMetric(0)
 		return err
 	}
 	defer conn.Close()
 
-	res, err := conn.Query(pgbouncerStatsQuery)
+	res, err := conn.QueryContext(ctx, pgbouncerStatsQuery)
 	if err != nil {
 		return err
 	}
@@ -89,9 +82,6 @@ func (c *pgbouncerStatsCollector) Update(config Config, ch chan<- prometheus.Met
 		ch <- c.time.newConstMetric(stat.waittime, stat.database, "waiting", "none")
 	}
 
-	// All is ok, collect up metric.
-	ch <- c.up.newConstMetric(1)
-
 	return nil
 }
 
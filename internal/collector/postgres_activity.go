@@ -19,7 +19,7 @@ const (
 		"coalesce(extract(epoch FROM clock_timestamp() - xact_start), 0) AS active_seconds, " +
 		"CASE WHEN waiting = 't' THEN extract(epoch FROM clock_timestamp() - state_change) ELSE 0 END AS waiting_seconds, " +
 		"left(query, 32) AS query " +
-		"FROM pg_stat_activity"
+		"FROM pg_stat_activity WHERE pid <> pg_backend_pid()"
 
 	// postgresActivityQuery96 defines activity query for 9.6.
 	// Postgres 9.6 doesn't have 'backend_type' attribute.
@@ -28,28 +28,30 @@ const (
 		"coalesce(extract(epoch FROM clock_timestamp() - xact_start), 0) AS active_seconds, " +
 		"CASE WHEN wait_event_type = 'Lock' THEN extract(epoch FROM clock_timestamp() - state_change) ELSE 0 END AS waiting_seconds, " +
 		"left(query, 32) AS query " +
-		"FROM pg_stat_activity"
+		"FROM pg_stat_activity WHERE pid <> pg_backend_pid()"
 
 	// postgresActivityQuery13 defines activity query for versions from 10 to 13.
 	postgresActivityQuery13 = "SELECT " +
-		"coalesce(usename, backend_type) AS user, datname AS database, state, wait_event_type, wait_event, " +
+		"coalesce(usename, backend_type) AS user, datname AS database, state, wait_event_type, wait_event, backend_type, " +
 		"coalesce(extract(epoch FROM clock_timestamp() - xact_start), 0) AS active_seconds, " +
 		"CASE WHEN wait_event_type = 'Lock' THEN extract(epoch FROM clock_timestamp() - state_change) ELSE 0 END AS waiting_seconds, " +
 		"left(query, 32) AS query " +
-		"FROM pg_stat_activity"
+		"FROM pg_stat_activity WHERE pid <> pg_backend_pid()"
 
 	// postgresActivityQueryLatest defines activity query for recent versions.
 	// Postgres 14 has pg_locks.waitstart which is better for taking sessions waiting time.
 	postgresActivityQueryLatest = "SELECT " +
-		"coalesce(usename, backend_type) AS user, datname AS database, state, wait_event_type, wait_event, " +
+		"coalesce(usename, backend_type) AS user, datname AS database, state, wait_event_type, wait_event, backend_type, " +
 		"coalesce(extract(epoch FROM clock_timestamp() - xact_start), 0) AS active_seconds, " +
 		"CASE WHEN wait_event_type = 'Lock' " +
 		"THEN (SELECT extract(epoch FROM clock_timestamp() - max(waitstart)) FROM pg_locks l WHERE l.pid = a.pid) " +
 		"ELSE 0 END AS waiting_seconds, " +
 		"left(query, 32) AS query " +
-		"FROM pg_stat_activity a"
+		"FROM pg_stat_activity a WHERE pid <> pg_backend_pid()"
 
-	postgresPreparedXactQuery = "SELECT count(*) AS total FROM pg_prepared_xacts"
+	postgresPreparedXactQuery = "SELECT count(*) AS total, " +
+		"coalesce(extract(epoch FROM clock_timestamp() - min(prepared)), 0) AS max_age_seconds " +
+		"FROM pg_prepared_xacts"
 
 	postgresStartTimeQuery = "SELECT extract(epoch FROM pg_postmaster_start_time())"
 
@@ -64,20 +66,25 @@ const (
 
 	// Wait event type names
 	weLock = "Lock"
+
+	// Backend types accordingly to pg_stat_activity.backend_type which are not accounted as client connections.
+	btBgworker       = "background worker"
+	btParallelWorker = "parallel worker"
 )
 
 // postgresActivityCollector contains metrics related to Postgres activity.
 type postgresActivityCollector struct {
-	up         typedDesc
-	startTime  typedDesc
-	waitEvents typedDesc
-	states     typedDesc
-	statesAll  typedDesc
-	activity   typedDesc
-	prepared   typedDesc
-	inflight   typedDesc
-	vacuums    typedDesc
-	re         queryRegexp // regexps for queries classification
+	startTime   typedDesc
+	waitEvents  typedDesc
+	states      typedDesc
+	statesAll   typedDesc
+	activity    typedDesc
+	idleXact    typedDesc
+	prepared    typedDesc
+	preparedAge typedDesc
+	inflight    typedDesc
+	vacuums     typedDesc
+	re          queryRegexp // regexps for queries classification
 }
 
 // NewPostgresActivityCollector returns a new Collector exposing postgres activity stats.
@@ -86,12 +93,6 @@ type postgresActivityCollector struct {
 //   2. https://www.postgresql.org/docs/current/view-pg-prepared-xacts.html
 func NewPostgresActivityCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
 	return &postgresActivityCollector{
-		up: newBuiltinTypedDesc(
-			descOpts{"postgres", "", "up", "State of PostgreSQL service: 0 is down, 1 is up.", 0},
-			prometheus.GaugeValue,
-			nil, constLabels,
-			settings.Filters,
-		),
 		startTime: newBuiltinTypedDesc(
 			descOpts{"postgres", "", "start_time_seconds", "Postgres start time, in unixtime.", 0},
 			prometheus.GaugeValue,
@@ -122,12 +123,24 @@ func NewPostgresActivityCollector(constLabels labels, settings model.CollectorSe
 			[]string{"user", "database", "state", "type"}, constLabels,
 			settings.Filters,
 		),
+		idleXact: newBuiltinTypedDesc(
+			descOpts{"postgres", "activity", "idle_in_transaction_seconds_max", "Longest duration of transaction being in idle state, in seconds.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 		prepared: newBuiltinTypedDesc(
 			descOpts{"postgres", "activity", "prepared_transactions_in_flight", "Number of transactions that are currently prepared for two-phase commit.", 0},
 			prometheus.GaugeValue,
 			nil, constLabels,
 			settings.Filters,
 		),
+		preparedAge: newBuiltinTypedDesc(
+			descOpts{"postgres", "activity", "prepared_transactions_age_seconds_max", "Age of the oldest transaction prepared for two-phase commit, in seconds.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 		inflight: newBuiltinTypedDesc(
 			descOpts{"postgres", "activity", "queries_in_flight", "Number of queries running in-flight of each type.", 0},
 			prometheus.GaugeValue,
@@ -145,16 +158,15 @@ func NewPostgresActivityCollector(constLabels labels, settings model.CollectorSe
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *postgresActivityCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
-		ch <- c.up.newConstMetric(0)
 		return err
 	}
 	defer conn.Close()
 
 	// get pg_stat_activity stats
-	res, err := conn.Query(selectActivityQuery(config.serverVersionNum))
+	res, err := conn.QueryContext(ctx, selectActivityQuery(config.serverVersionNum))
 	if err != nil {
 		return err
 	}
@@ -164,16 +176,18 @@ func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.M
 
 	// get pg_prepared_xacts stats
 	var count int
-	err = conn.Conn().QueryRow(context.Background(), postgresPreparedXactQuery).Scan(&count)
+	var preparedAge float64
+	err = conn.Conn().QueryRow(ctx, postgresPreparedXactQuery).Scan(&count, &preparedAge)
 	if err != nil {
 		log.Warnf("query pg_prepared_xacts failed: %s; skip", err)
 	} else {
 		stats.prepared = float64(count)
+		stats.preparedAge = preparedAge
 	}
 
 	// get postmaster start time
 	var startTime float64
-	err = conn.Conn().QueryRow(context.Background(), postgresStartTimeQuery).Scan(&startTime)
+	err = conn.Conn().QueryRow(ctx, postgresStartTimeQuery).Scan(&startTime)
 	if err != nil {
 		log.Warnf("query postmaster start time failed: %s; skip", err)
 	} else {
@@ -221,6 +235,7 @@ func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.M
 
 	// prepared transactions
 	ch <- c.prepared.newConstMetric(stats.prepared)
+	ch <- c.preparedAge.newConstMetric(stats.preparedAge)
 
 	// Longest activity by states, per user/database
 	for tag, values := range map[string]map[string]float64{
@@ -242,6 +257,18 @@ func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.M
 		}
 	}
 
+	// Longest idle transaction regardless of user/database, including maintenance ones.
+	var maxIdleXact float64
+	for _, values := range []map[string]float64{stats.maxIdleUser, stats.maxIdleMaint} {
+		for _, v := range values {
+			if v > maxIdleXact {
+				maxIdleXact = v
+			}
+		}
+	}
+
+	ch <- c.idleXact.newConstMetric(maxIdleXact)
+
 	// in flight queries
 	ch <- c.inflight.newConstMetric(stats.querySelect, "select")
 	ch <- c.inflight.newConstMetric(stats.queryMod, "mod")
@@ -259,9 +286,6 @@ func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.M
 	// postmaster start time
 	ch <- c.startTime.newConstMetric(stats.startTime)
 
-	// All activity metrics collected successfully, now we can collect up metric.
-	ch <- c.up.newConstMetric(1)
-
 	return nil
 }
 
@@ -303,6 +327,7 @@ type postgresActivityStat struct {
 	waiting        map[string]float64 // wait_event_type = 'Lock' (or waiting = 't')
 	waitEvents     map[string]float64 // wait_event_type/wait_event counters
 	prepared       float64            // FROM pg_prepared_xacts
+	preparedAge    float64            // age of the oldest prepared transaction, in seconds
 	maxIdleUser    map[string]float64 // longest duration among idle transactions opened by user/database
 	maxIdleMaint   map[string]float64 // longest duration among idle transactions initiated by maintenance operations (autovacuum, vacuum. analyze)
 	maxActiveUser  map[string]float64 // longest duration among client queries
@@ -383,11 +408,18 @@ func parsePostgresActivityStats(r *model.PGResult, re queryRegexp) postgresActiv
 				// Check backend state:
 				// 1) is not in a waiting state. Waiting backends are accounted separately.
 				// 2) don't have NULL database. This is a background daemon and should not be counted.
+				// 3) is not a background or parallel worker, these are not client connections.
 
 				if (row[waitColIdx].String == weLock || row[waitColIdx].String == "t") || !row[databaseColIdx].Valid {
 					continue
 				}
 
+				if idx, ok := colindexes["backend_type"]; ok {
+					if bt := row[idx].String; bt == btBgworker || bt == btParallelWorker {
+						continue
+					}
+				}
+
 				userColIdx := colindexes["user"]
 				stats.updateState(row[userColIdx].String, row[databaseColIdx].String, row[i].String)
 			case waitColumnName:
@@ -11,13 +11,14 @@ import (
 func TestPostgresActivityCollector_Update(t *testing.T) {
 	var input = pipelineInput{
 		required: []string{
-			"postgres_up",
 			"postgres_start_time_seconds",
 			"postgres_activity_wait_events_in_flight",
 			"postgres_activity_connections_in_flight",
 			"postgres_activity_connections_all_in_flight",
 			"postgres_activity_max_seconds",
+			"postgres_activity_idle_in_transaction_seconds_max",
 			"postgres_activity_prepared_transactions_in_flight",
+			"postgres_activity_prepared_transactions_age_seconds_max",
 			"postgres_activity_queries_in_flight",
 			"postgres_activity_vacuums_in_flight",
 		},
@@ -216,6 +217,43 @@ func Test_parsePostgresActivityStats(t *testing.T) {
 				re:          testRE,
 			},
 		},
+		{
+			name: "background workers are not accounted",
+			res: &model.PGResult{
+				Nrows: 3,
+				Ncols: 9,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("user")},
+					{Name: []byte("database")},
+					{Name: []byte("state")},
+					{Name: []byte("wait_event_type")},
+					{Name: []byte("wait_event")},
+					{Name: []byte("backend_type")},
+					{Name: []byte("active_seconds")},
+					{Name: []byte("waiting_seconds")},
+					{Name: []byte("query")},
+				},
+				Rows: [][]sql.NullString{
+					{{String: "testuser", Valid: true}, {String: "testdb", Valid: true}, {String: "active", Valid: true}, {}, {}, {String: "client backend", Valid: true}, {String: "10", Valid: true}, {String: "0", Valid: true}, {String: "SELECT test", Valid: true}},
+					{{String: "testuser", Valid: true}, {String: "testdb", Valid: true}, {String: "active", Valid: true}, {}, {}, {String: "parallel worker", Valid: true}, {String: "10", Valid: true}, {String: "0", Valid: true}, {String: "SELECT test", Valid: true}},
+					{{String: "testuser", Valid: true}, {String: "testdb", Valid: true}, {String: "idle", Valid: true}, {String: "Extension", Valid: true}, {String: "Extension", Valid: true}, {String: "background worker", Valid: true}, {String: "0", Valid: true}, {String: "0", Valid: true}, {String: "", Valid: true}},
+				},
+			},
+			want: postgresActivityStat{
+				waitEvents:  map[string]float64{"Extension/Extension": 1},
+				maxIdleUser: map[string]float64{}, maxIdleMaint: map[string]float64{},
+				maxActiveUser: map[string]float64{"testuser/testdb": 10}, maxActiveMaint: map[string]float64{},
+				maxWaitUser: map[string]float64{}, maxWaitMaint: map[string]float64{},
+				active:      map[string]float64{"testuser/testdb": 1},
+				idle:        map[string]float64{},
+				idlexact:    map[string]float64{},
+				other:       map[string]float64{},
+				waiting:     map[string]float64{},
+				querySelect: 2,
+				vacuumOps:   map[string]float64{"regular": 0, "user": 0, "wraparound": 0},
+				re:          testRE,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
@@ -8,16 +9,28 @@ import (
 	"strconv"
 )
 
-const walArchivingQuery = "SELECT archived_count, failed_count, " +
-	"extract(epoch from now() - last_archived_time) AS since_last_archive_seconds, " +
-	"(SELECT count(*) FROM pg_ls_archive_statusdir() WHERE name ~'.ready') AS lag_files " +
-	"FROM pg_stat_archiver WHERE archived_count > 0"
+const (
+	walArchivingQuery = "SELECT archived_count, failed_count, " +
+		"extract(epoch from now() - last_archived_time) AS since_last_archive_seconds, " +
+		"extract(epoch from now() - last_failed_time) AS since_last_failed_seconds, " +
+		"(SELECT count(*) FROM pg_ls_archive_statusdir() WHERE name ~'.ready') AS lag_files " +
+		"FROM pg_stat_archiver WHERE archived_count > 0 OR failed_count > 0"
+
+	// Archive status directory is not accessible on managed services or without pg_monitor privileges, WAL segments
+	// waiting for archiving are not counted.
+	walArchivingNoLagQuery = "SELECT archived_count, failed_count, " +
+		"extract(epoch from now() - last_archived_time) AS since_last_archive_seconds, " +
+		"extract(epoch from now() - last_failed_time) AS since_last_failed_seconds " +
+		"FROM pg_stat_archiver WHERE archived_count > 0 OR failed_count > 0"
+)
 
 type postgresWalArchivingCollector struct {
 	archived             typedDesc
 	failed               typedDesc
 	sinceArchivedSeconds typedDesc
+	sinceFailedSeconds   typedDesc
 	archivingLag         typedDesc
+	pendingWal           typedDesc
 }
 
 // NewPostgresWalArchivingCollector returns a new Collector exposing postgres WAL archiving stats.
@@ -42,18 +55,30 @@ func NewPostgresWalArchivingCollector(constLabels labels, settings model.Collect
 			nil, constLabels,
 			settings.Filters,
 		),
+		sinceFailedSeconds: newBuiltinTypedDesc(
+			descOpts{"postgres", "archiver", "last_failed_seconds", "Number of seconds since last failed attempt to archive WAL segment.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 		archivingLag: newBuiltinTypedDesc(
 			descOpts{"postgres", "archiver", "lag_bytes", "Amount of WAL segments ready, but not archived, in bytes.", 0},
 			prometheus.GaugeValue,
 			nil, constLabels,
 			settings.Filters,
 		),
+		pendingWal: newBuiltinTypedDesc(
+			descOpts{"postgres", "archiver", "pending_wal_count", "Number of WAL segments ready, but not archived.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresWalArchivingCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *postgresWalArchivingCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
@@ -64,22 +89,39 @@ func (c *postgresWalArchivingCollector) Update(config Config, ch chan<- promethe
 		return nil
 	}
 
-	res, err := conn.Query(walArchivingQuery)
+	countLag := !config.Managed && config.monitorPrivileges()
+
+	query := walArchivingQuery
+	if !countLag {
+		query = walArchivingNoLagQuery
+	}
+
+	res, err := conn.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
 
 	stats := parsePostgresWalArchivingStats(res)
 
-	if stats.archived == 0 {
-		log.Debugln("zero archived WAL segments, skip collecting archiver stats")
+	if stats.archived == 0 && stats.failed == 0 {
+		log.Debugln("zero archived and failed WAL segments, skip collecting archiver stats")
 		return nil
 	}
 
 	ch <- c.archived.newConstMetric(stats.archived)
 	ch <- c.failed.newConstMetric(stats.failed)
-	ch <- c.sinceArchivedSeconds.newConstMetric(stats.sinceArchivedSeconds)
-	ch <- c.archivingLag.newConstMetric(stats.lagFiles * float64(config.walSegmentSize))
+	if countLag {
+		ch <- c.archivingLag.newConstMetric(stats.lagFiles * float64(config.walSegmentSize))
+		ch <- c.pendingWal.newConstMetric(stats.lagFiles)
+	}
+
+	// Archiver might fail from the very beginning, or never fail - send time-related metrics only when they make sense.
+	if stats.archived > 0 {
+		ch <- c.sinceArchivedSeconds.newConstMetric(stats.sinceArchivedSeconds)
+	}
+	if stats.failed > 0 {
+		ch <- c.sinceFailedSeconds.newConstMetric(stats.sinceFailedSeconds)
+	}
 
 	return nil
 }
@@ -89,6 +131,7 @@ type postgresWalArchivingStat struct {
 	archived             float64
 	failed               float64
 	sinceArchivedSeconds float64
+	sinceFailedSeconds   float64
 	lagFiles             float64
 }
 
@@ -121,6 +164,8 @@ func parsePostgresWalArchivingStats(r *model.PGResult) postgresWalArchivingStat
 				stats.failed = v
 			case "since_last_archive_seconds":
 				stats.sinceArchivedSeconds = v
+			case "since_last_failed_seconds":
+				stats.sinceFailedSeconds = v
 			case "lag_files":
 				stats.lagFiles = v
 			default:
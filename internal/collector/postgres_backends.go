@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	postgresBackendsQuery = "SELECT backend_type, count(*) AS total, " +
+		"coalesce(extract(epoch FROM clock_timestamp() - min(backend_start)), 0) AS oldest_seconds " +
+		"FROM pg_stat_activity WHERE pid <> pg_backend_pid() GROUP BY backend_type"
+)
+
+type postgresBackendsCollector struct {
+	backends   typedDesc
+	oldest     typedDesc
+	labelNames []string
+}
+
+// NewPostgresBackendsCollector returns a new Collector exposing number of backends and age of the oldest backend
+// of each backend type. Long-lived client backends might indicate connections leak.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-ACTIVITY-VIEW
+func NewPostgresBackendsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	var labels = []string{"backend_type"}
+
+	return &postgresBackendsCollector{
+		labelNames: labels,
+		backends: newBuiltinTypedDesc(
+			descOpts{"postgres", "backends", "total", "Number of backends of each backend type.", 0},
+			prometheus.GaugeValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		oldest: newBuiltinTypedDesc(
+			descOpts{"postgres", "backend", "oldest_seconds", "Age of the oldest backend of each backend type, in seconds.", 0},
+			prometheus.GaugeValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresBackendsCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	if config.serverVersionNum < PostgresV10 {
+		log.Debugln("[postgres backends collector]: backend_type is not available, required Postgres 10 or newer")
+		return nil
+	}
+
+	conn, err := store.NewContext(ctx, config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.QueryContext(ctx, postgresBackendsQuery)
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range parsePostgresGenericStats(res, c.labelNames) {
+		backendType := stat.labels["backend_type"]
+
+		ch <- c.backends.newConstMetric(stat.values["total"], backendType)
+		ch <- c.oldest.newConstMetric(stat.values["oldest_seconds"], backendType)
+	}
+
+	return nil
+}
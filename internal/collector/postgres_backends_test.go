@@ -0,0 +1,19 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"testing"
+)
+
+func TestPostgresBackendsCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_backends_total",
+			"postgres_backend_oldest_seconds",
+		},
+		collector: NewPostgresBackendsCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
@@ -9,12 +10,24 @@ import (
 )
 
 const (
-	postgresBgwriterQuery = "SELECT " +
+	postgresBgwriterQuery16 = "SELECT " +
 		"checkpoints_timed, checkpoints_req, checkpoint_write_time, checkpoint_sync_time, " +
 		"buffers_checkpoint, buffers_clean, maxwritten_clean, " +
 		"buffers_backend, buffers_backend_fsync, buffers_alloc, " +
 		"coalesce(extract('epoch' from age(now(), stats_reset)), 0) as stats_age_seconds " +
 		"FROM pg_stat_bgwriter"
+
+	// Since Postgres 17 checkpointer stats are moved into pg_stat_checkpointer, and backends writes and fsyncs are
+	// available only in pg_stat_io. Columns are aliased to pre-17 names to keep the same metrics.
+	postgresBgwriterQueryLatest = "SELECT " +
+		"c.num_timed AS checkpoints_timed, c.num_requested AS checkpoints_req, " +
+		"c.write_time AS checkpoint_write_time, c.sync_time AS checkpoint_sync_time, " +
+		"c.buffers_written AS buffers_checkpoint, b.buffers_clean, b.maxwritten_clean, " +
+		"io.writes AS buffers_backend, io.fsyncs AS buffers_backend_fsync, b.buffers_alloc, " +
+		"coalesce(extract('epoch' from age(now(), c.stats_reset)), 0) as stats_age_seconds " +
+		"FROM pg_stat_checkpointer c, pg_stat_bgwriter b, " +
+		"(SELECT sum(writes) AS writes, sum(fsyncs) AS fsyncs FROM pg_stat_io " +
+		"WHERE backend_type = 'client backend' AND object = 'relation') io"
 )
 
 type postgresBgwriterCollector struct {
@@ -85,14 +98,14 @@ func NewPostgresBgwriterCollector(constLabels labels, settings model.CollectorSe
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresBgwriterCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *postgresBgwriterCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	res, err := conn.Query(postgresBgwriterQuery)
+	res, err := conn.QueryContext(ctx, selectBgwriterQuery(config.serverVersionNum))
 	if err != nil {
 		return err
 	}
@@ -133,6 +146,16 @@ func (c *postgresBgwriterCollector) Update(config Config, ch chan<- prometheus.M
 	return nil
 }
 
+// selectBgwriterQuery returns suitable bgwriter/checkpointer query depending on passed version.
+func selectBgwriterQuery(version int) string {
+	switch {
+	case version < PostgresV17:
+		return postgresBgwriterQuery16
+	default:
+		return postgresBgwriterQueryLatest
+	}
+}
+
 // postgresBgwriterStat describes stats related to Postgres background writes.
 type postgresBgwriterStat struct {
 	ckptTimed        float64
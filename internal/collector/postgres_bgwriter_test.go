@@ -70,3 +70,21 @@ func Test_parsePostgresBgwriterStats(t *testing.T) {
 		})
 	}
 }
+
+func Test_selectBgwriterQuery(t *testing.T) {
+	var testcases = []struct {
+		version int
+		want    string
+	}{
+		{version: 90600, want: postgresBgwriterQuery16},
+		{version: 160005, want: postgresBgwriterQuery16},
+		{version: 170000, want: postgresBgwriterQueryLatest},
+		{version: 170002, want: postgresBgwriterQueryLatest},
+	}
+
+	for _, tc := range testcases {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tc.want, selectBgwriterQuery(tc.version))
+		})
+	}
+}
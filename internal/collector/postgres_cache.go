@@ -0,0 +1,146 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	postgresCacheDatabasesQuery = "SELECT datname AS database, blks_hit::float8 / nullif(blks_hit + blks_read, 0) AS ratio " +
+		"FROM pg_stat_database WHERE datname IS NOT NULL"
+
+	postgresCacheTablesQuery = "SELECT current_database() AS database, schemaname AS schema, relname AS relation, 'table' AS type, " +
+		"coalesce(heap_blks_hit, 0) AS hit, coalesce(heap_blks_read, 0) AS read " +
+		"FROM pg_statio_user_tables WHERE true"
+
+	// TOAST blocks are accounted to the owning table, NULL values are reported for tables without TOAST.
+	postgresCacheToastQuery = "SELECT current_database() AS database, schemaname AS schema, relname AS relation, 'toast' AS type, " +
+		"toast_blks_hit AS hit, toast_blks_read AS read " +
+		"FROM pg_statio_user_tables WHERE toast_blks_read IS NOT NULL"
+
+	postgresCacheIndexesQuery = "SELECT current_database() AS database, schemaname AS schema, indexrelname AS relation, 'index' AS type, " +
+		"coalesce(idx_blks_hit, 0) AS hit, coalesce(idx_blks_read, 0) AS read " +
+		"FROM pg_statio_user_indexes WHERE true"
+)
+
+// postgresCacheCollector defines metric descriptors.
+type postgresCacheCollector struct {
+	hit        typedDesc
+	read       typedDesc
+	ratio      typedDesc
+	labelNames []string
+	objects    postgresObjectsFilter
+}
+
+// NewPostgresCacheCollector returns a new Collector exposing number of relations' blocks found in shared buffers and
+// read from disk (or OS page cache), which could be used for calculating per-relation cache hit ratio. Database-wide
+// cache hit ratio is exposed too.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STATIO-ALL-TABLES-VIEW
+func NewPostgresCacheCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	var labels = []string{"database", "schema", "relation", "type"}
+
+	objects, err := newPostgresObjectsFilter(settings.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &postgresCacheCollector{
+		labelNames: labels,
+		objects:    objects,
+		hit: newBuiltinTypedDesc(
+			descOpts{"postgres", "relation", "blocks_hit_total", "Total number of relation's blocks found in shared buffers.", 0},
+			prometheus.CounterValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		read: newBuiltinTypedDesc(
+			descOpts{"postgres", "relation", "blocks_read_total", "Total number of relation's blocks read from disk or OS page cache.", 0},
+			prometheus.CounterValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		ratio: newBuiltinTypedDesc(
+			descOpts{"postgres", "database", "cache_hit_ratio", "Ratio of blocks found in shared buffers to all accessed blocks in the database.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresCacheCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	databases, err := listFilteredDatabases(ctx, conn, c.objects)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	res, err := conn.QueryContext(ctx, postgresCacheDatabasesQuery+c.objects.databasesCondition("datname"))
+	conn.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range parsePostgresGenericStats(res, []string{"database"}) {
+		// Ratio is not defined when no blocks have been accessed yet.
+		ratio, ok := stat.values["ratio"]
+		if !ok {
+			continue
+		}
+
+		if config.DatabasesRE != nil && !config.DatabasesRE.MatchString(stat.labels["database"]) {
+			continue
+		}
+
+		ch <- c.ratio.newConstMetric(ratio, stat.labels["database"])
+	}
+
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	query := postgresCacheTablesQuery + c.objects.relationsCondition("schemaname", "relname") +
+		" UNION ALL " + postgresCacheToastQuery + c.objects.relationsCondition("schemaname", "relname") +
+		" UNION ALL " + postgresCacheIndexesQuery + c.objects.relationsCondition("schemaname", "relname")
+
+	for _, d := range databases {
+		// Skip database if not matched to allowed.
+		if config.DatabasesRE != nil && !config.DatabasesRE.MatchString(d) {
+			continue
+		}
+
+		pgconfig.Database = d
+		conn, err := store.NewWithConfigContext(ctx, pgconfig)
+		if err != nil {
+			return err
+		}
+
+		res, err := conn.QueryContext(ctx, query)
+		conn.Close()
+		if err != nil {
+			log.Warnf("get relations cache stat of database '%s' failed: %s; skip", d, err)
+			continue
+		}
+
+		for _, stat := range parsePostgresGenericStats(res, c.labelNames) {
+			labelValues := []string{stat.labels["database"], stat.labels["schema"], stat.labels["relation"], stat.labels["type"]}
+
+			ch <- c.hit.newConstMetric(stat.values["hit"], labelValues...)
+			ch <- c.read.newConstMetric(stat.values["read"], labelValues...)
+		}
+	}
+
+	return nil
+}
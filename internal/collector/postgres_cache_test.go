@@ -0,0 +1,22 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"testing"
+)
+
+func TestPostgresCacheCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_database_cache_hit_ratio",
+		},
+		optional: []string{
+			"postgres_relation_blocks_hit_total",
+			"postgres_relation_blocks_read_total",
+		},
+		collector: NewPostgresCacheCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
@@ -2,9 +2,13 @@ package collector
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
+	"github.com/jackc/pgconn"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -20,12 +24,23 @@ const (
 	PostgresV14 = 140000
 	PostgresV15 = 150000
 	PostgresV16 = 160000
+	PostgresV17 = 170000
 
 	// Minimal required version is 9.5.
 	PostgresVMinNum = PostgresV95
 	PostgresVMinStr = "9.5"
 )
 
+// postgresVersionString returns human-readable representation of numeric Postgres version, e.g. 160002 -> 16.2,
+// 90624 -> 9.6.24.
+func postgresVersionString(num int) string {
+	if num >= PostgresV10 {
+		return strconv.Itoa(num/10000) + "." + strconv.Itoa(num%10000)
+	}
+
+	return strconv.Itoa(num/10000) + "." + strconv.Itoa(num/100%100) + "." + strconv.Itoa(num%100)
+}
+
 // postgresGenericStat represent generic stat suitable for all kind of stats
 type postgresGenericStat struct {
 	labels map[string]string
@@ -145,9 +160,9 @@ func parsePostgresCustomStats(r *model.PGResult, labelNames []string) postgresCu
 }
 
 // listDatabases returns slice with databases names
-func listDatabases(db *store.DB) ([]string, error) {
+func listDatabases(ctx context.Context, db *store.DB) ([]string, error) {
 	// getDBList returns the list of databases that allowed for connection
-	rows, err := db.Conn().Query(context.Background(), "SELECT datname FROM pg_database WHERE NOT datistemplate AND datallowconn")
+	rows, err := db.Conn().Query(ctx, "SELECT datname FROM pg_database WHERE NOT datistemplate AND datallowconn")
 	if err != nil {
 		return nil, err
 	}
@@ -163,3 +178,92 @@ func listDatabases(db *store.DB) ([]string, error) {
 	}
 	return list, nil
 }
+
+// postgresObjectsFilter defines regular expressions used for including or excluding databases, schemas and relations
+// by per-object collectors. Expressions are added to collectors' queries, hence POSIX regular expressions syntax
+// supported by Postgres should be used.
+type postgresObjectsFilter struct {
+	includeDatabases string
+	excludeDatabases string
+	includeSchemas   string
+	excludeSchemas   string
+	includeRelations string
+	excludeRelations string
+}
+
+// newPostgresObjectsFilter creates objects filter using 'include_databases', 'exclude_databases', 'include_schemas',
+// 'exclude_schemas', 'include_tables' and 'exclude_tables' collector's options.
+func newPostgresObjectsFilter(options model.CollectorOptions) (postgresObjectsFilter, error) {
+	f := postgresObjectsFilter{
+		includeDatabases: options["include_databases"],
+		excludeDatabases: options["exclude_databases"],
+		includeSchemas:   options["include_schemas"],
+		excludeSchemas:   options["exclude_schemas"],
+		includeRelations: options["include_tables"],
+		excludeRelations: options["exclude_tables"],
+	}
+
+	// Basic sanity check, POSIX and RE2 syntax are quite similar.
+	for _, re := range []string{f.includeDatabases, f.excludeDatabases, f.includeSchemas, f.excludeSchemas, f.includeRelations, f.excludeRelations} {
+		if _, err := regexp.Compile(re); err != nil {
+			return f, fmt.Errorf("invalid objects filter regexp '%s': %s", re, err)
+		}
+	}
+
+	return f, nil
+}
+
+// databasesCondition returns SQL condition for filtering databases by name stored in passed column.
+func (f postgresObjectsFilter) databasesCondition(datname string) string {
+	return regexpCondition(datname, f.includeDatabases, f.excludeDatabases)
+}
+
+// relationsCondition returns SQL condition for filtering relations by schema and relation names stored in passed
+// columns.
+func (f postgresObjectsFilter) relationsCondition(schemaname, relname string) string {
+	return regexpCondition(schemaname, f.includeSchemas, f.excludeSchemas) + regexpCondition(relname, f.includeRelations, f.excludeRelations)
+}
+
+// regexpCondition returns SQL condition which matches passed column to include regexp and doesn't match to exclude
+// regexp. Empty regexps are not used. Returned condition is prefixed with AND.
+func regexpCondition(column, include, exclude string) string {
+	var cond string
+	if include != "" {
+		cond += fmt.Sprintf(" AND %s ~ %s", column, quoteLiteral(include))
+	}
+	if exclude != "" {
+		cond += fmt.Sprintf(" AND %s !~ %s", column, quoteLiteral(exclude))
+	}
+	return cond
+}
+
+// quoteLiteral quotes passed string for using it as SQL string literal. It relies on 'standard_conforming_strings'
+// which is always enabled by store package.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// listFilteredDatabases returns slice with names of databases matched to passed filter.
+func listFilteredDatabases(ctx context.Context, db *store.DB, f postgresObjectsFilter) ([]string, error) {
+	rows, err := db.Conn().Query(ctx, "SELECT datname FROM pg_database WHERE NOT datistemplate AND datallowconn"+f.databasesCondition("datname"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list = make([]string, 0, 10)
+	for rows.Next() {
+		var dbname string
+		if err := rows.Scan(&dbname); err != nil {
+			return nil, err
+		}
+		list = append(list, dbname)
+	}
+	return list, rows.Err()
+}
+
+// isInsufficientPrivilege returns true if passed error is returned by Postgres due to lack of privileges.
+func isInsufficientPrivilege(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "42501"
+}
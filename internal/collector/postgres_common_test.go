@@ -1,7 +1,11 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgproto3/v2"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
@@ -105,8 +109,52 @@ func Test_parsePostgresCustomStats(t *testing.T) {
 func Test_listDatabases(t *testing.T) {
 	conn := store.NewTest(t)
 
-	databases, err := listDatabases(conn)
+	databases, err := listDatabases(context.Background(), conn)
 	assert.NoError(t, err)
 	assert.Greater(t, len(databases), 0)
 	conn.Close()
 }
+
+func Test_postgresVersionString(t *testing.T) {
+	assert.Equal(t, "17.0", postgresVersionString(170000))
+	assert.Equal(t, "16.2", postgresVersionString(160002))
+	assert.Equal(t, "10.23", postgresVersionString(100023))
+	assert.Equal(t, "9.6.24", postgresVersionString(90624))
+	assert.Equal(t, "9.5.0", postgresVersionString(90500))
+}
+
+func Test_newPostgresObjectsFilter(t *testing.T) {
+	f, err := newPostgresObjectsFilter(model.CollectorOptions{
+		"include_databases": "^app$",
+		"include_schemas":   "^public$",
+		"exclude_tables":    "^tmp_'",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, " AND datname ~ '^app$'", f.databasesCondition("datname"))
+	assert.Equal(t, " AND s.schemaname ~ '^public$' AND s.relname !~ '^tmp_'''", f.relationsCondition("s.schemaname", "s.relname"))
+
+	f, err = newPostgresObjectsFilter(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", f.databasesCondition("datname"))
+	assert.Equal(t, "", f.relationsCondition("s.schemaname", "s.relname"))
+
+	_, err = newPostgresObjectsFilter(model.CollectorOptions{"exclude_schemas": "[invalid"})
+	assert.Error(t, err)
+}
+
+func Test_listFilteredDatabases(t *testing.T) {
+	db := store.NewTest(t)
+	defer db.Close()
+
+	databases, err := listFilteredDatabases(context.Background(), db, postgresObjectsFilter{includeDatabases: "^pgscv_fixtures$"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pgscv_fixtures"}, databases)
+}
+
+func Test_isInsufficientPrivilege(t *testing.T) {
+	assert.True(t, isInsufficientPrivilege(&pgconn.PgError{Code: "42501"}))
+	assert.True(t, isInsufficientPrivilege(fmt.Errorf("query failed: %w", &pgconn.PgError{Code: "42501"})))
+	assert.False(t, isInsufficientPrivilege(&pgconn.PgError{Code: "42P01"}))
+	assert.False(t, isInsufficientPrivilege(errors.New("example")))
+	assert.False(t, isInsufficientPrivilege(nil))
+}
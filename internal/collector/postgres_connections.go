@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"context"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Before Postgres 10 pg_stat_activity shows client backends only.
+	postgresConnectionsQuery96 = "SELECT current_setting('max_connections')::int AS max_connections, " +
+		"current_setting('superuser_reserved_connections')::int AS superuser_reserved_connections, " +
+		"NULL::int AS reserved_connections, " +
+		"(SELECT count(*) FROM pg_stat_activity WHERE pid <> pg_backend_pid()) AS used"
+
+	postgresConnectionsQuery15 = "SELECT current_setting('max_connections')::int AS max_connections, " +
+		"current_setting('superuser_reserved_connections')::int AS superuser_reserved_connections, " +
+		"NULL::int AS reserved_connections, " +
+		"(SELECT count(*) FROM pg_stat_activity WHERE backend_type = 'client backend' AND pid <> pg_backend_pid()) AS used"
+
+	postgresConnectionsQueryLatest = "SELECT current_setting('max_connections')::int AS max_connections, " +
+		"current_setting('superuser_reserved_connections')::int AS superuser_reserved_connections, " +
+		"current_setting('reserved_connections')::int AS reserved_connections, " +
+		"(SELECT count(*) FROM pg_stat_activity WHERE backend_type = 'client backend' AND pid <> pg_backend_pid()) AS used"
+)
+
+type postgresConnectionsCollector struct {
+	max               typedDesc
+	superuserReserved typedDesc
+	reserved          typedDesc
+	used              typedDesc
+}
+
+// NewPostgresConnectionsCollector returns a new Collector exposing postgres connections limits and usage.
+// For details see https://www.postgresql.org/docs/current/runtime-config-connection.html
+func NewPostgresConnectionsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresConnectionsCollector{
+		max: newBuiltinTypedDesc(
+			descOpts{"postgres", "", "max_connections", "Maximum number of concurrent connections to the database server.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		superuserReserved: newBuiltinTypedDesc(
+			descOpts{"postgres", "", "superuser_reserved_connections", "Number of connection slots reserved for superusers.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		reserved: newBuiltinTypedDesc(
+			descOpts{"postgres", "", "reserved_connections", "Number of connection slots reserved for roles with privileges of pg_use_reserved_connections.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		used: newBuiltinTypedDesc(
+			descOpts{"postgres", "connections", "used", "Number of client connections currently established, excluding the monitoring connection.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresConnectionsCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.QueryContext(ctx, selectConnectionsQuery(config.serverVersionNum))
+	if err != nil {
+		return err
+	}
+
+	stats := parsePostgresGenericStats(res, nil)
+
+	for _, stat := range stats {
+		ch <- c.max.newConstMetric(stat.values["max_connections"])
+		ch <- c.superuserReserved.newConstMetric(stat.values["superuser_reserved_connections"])
+		ch <- c.used.newConstMetric(stat.values["used"])
+
+		// reserved_connections is available since Postgres 16.
+		if v, ok := stat.values["reserved_connections"]; ok {
+			ch <- c.reserved.newConstMetric(v)
+		}
+	}
+
+	return nil
+}
+
+// selectConnectionsQuery returns suitable connections query depending on passed version.
+func selectConnectionsQuery(version int) string {
+	switch {
+	case version < PostgresV10:
+		return postgresConnectionsQuery96
+	case version < PostgresV16:
+		return postgresConnectionsQuery15
+	default:
+		return postgresConnectionsQueryLatest
+	}
+}
@@ -0,0 +1,42 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresConnectionsCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_max_connections",
+			"postgres_superuser_reserved_connections",
+			"postgres_connections_used",
+		},
+		optional: []string{
+			"postgres_reserved_connections",
+		},
+		collector: NewPostgresConnectionsCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_selectConnectionsQuery(t *testing.T) {
+	var testcases = []struct {
+		version int
+		want    string
+	}{
+		{version: PostgresV96, want: postgresConnectionsQuery96},
+		{version: PostgresV10, want: postgresConnectionsQuery15},
+		{version: PostgresV15, want: postgresConnectionsQuery15},
+		{version: PostgresV16, want: postgresConnectionsQueryLatest},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.want, func(t *testing.T) {
+			assert.Equal(t, tc.want, selectConnectionsQuery(tc.version))
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Relation is resolved using regclass which is cheap, it is empty when COPY is made from a query.
+	postgresCopyQuery = "SELECT pid, datname AS database, coalesce(nullif(relid, 0)::regclass::text, '') AS relation, command, " +
+		"bytes_processed, bytes_total, tuples_processed " +
+		"FROM pg_stat_progress_copy"
+)
+
+type postgresCopyCollector struct {
+	bytesProcessed  typedDesc
+	bytesTotal      typedDesc
+	tuplesProcessed typedDesc
+	labelNames      []string
+}
+
+// NewPostgresCopyCollector returns a new Collector exposing progress of running COPY commands.
+// For details see https://www.postgresql.org/docs/current/progress-reporting.html#COPY-PROGRESS-REPORTING
+func NewPostgresCopyCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	var labels = []string{"pid", "database", "relation", "command"}
+
+	return &postgresCopyCollector{
+		labelNames: labels,
+		bytesProcessed: newBuiltinTypedDesc(
+			descOpts{"postgres", "copy", "bytes_processed", "Number of bytes already processed by COPY command.", 0},
+			prometheus.GaugeValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		bytesTotal: newBuiltinTypedDesc(
+			descOpts{"postgres", "copy", "bytes_total", "Size of source file for COPY FROM command, in bytes.", 0},
+			prometheus.GaugeValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		tuplesProcessed: newBuiltinTypedDesc(
+			descOpts{"postgres", "copy", "tuples_processed", "Number of tuples already processed by COPY command.", 0},
+			prometheus.GaugeValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresCopyCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	if config.serverVersionNum < PostgresV14 {
+		log.Debugln("[postgres copy collector]: COPY progress is not available, required Postgres 14 or newer")
+		return nil
+	}
+
+	conn, err := store.NewContext(ctx, config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.QueryContext(ctx, postgresCopyQuery)
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range parsePostgresGenericStats(res, c.labelNames) {
+		labelValues := []string{stat.labels["pid"], stat.labels["database"], stat.labels["relation"], stat.labels["command"]}
+
+		ch <- c.bytesProcessed.newConstMetric(stat.values["bytes_processed"], labelValues...)
+		ch <- c.tuplesProcessed.newConstMetric(stat.values["tuples_processed"], labelValues...)
+
+		// Size is not known when data is read from program or client.
+		if stat.values["bytes_total"] > 0 {
+			ch <- c.bytesTotal.newConstMetric(stat.values["bytes_total"], labelValues...)
+		}
+	}
+
+	return nil
+}
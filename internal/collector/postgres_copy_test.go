@@ -0,0 +1,20 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"testing"
+)
+
+func TestPostgresCopyCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_copy_bytes_processed",
+			"postgres_copy_bytes_total",
+			"postgres_copy_tuples_processed",
+		},
+		collector: NewPostgresCopyCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
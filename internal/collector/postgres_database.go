@@ -1,6 +1,8 @@
 package collector
 
 import (
+	"context"
+	"fmt"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
@@ -12,18 +14,18 @@ const (
 	databasesQuery11 = "SELECT " +
 		"coalesce(datname, 'global') AS database, " +
 		"xact_commit, xact_rollback, blks_read, blks_hit, tup_returned, tup_fetched, tup_inserted, tup_updated, tup_deleted, " +
-		"conflicts, temp_files, temp_bytes, deadlocks, blk_read_time, blk_write_time, pg_database_size(datname) as size_bytes, " +
+		"conflicts, temp_files, temp_bytes, deadlocks, blk_read_time, blk_write_time, " + databaseSizeColumn + ", " +
 		"coalesce(extract('epoch' from age(now(), stats_reset)), 0) as stats_age_seconds " +
-		"FROM pg_stat_database WHERE datname IN (SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate) " +
+		"FROM pg_stat_database WHERE datname IN (SELECT datname FROM pg_database WHERE %s) " +
 		"OR datname IS NULL"
 
 	databasesQuery12 = "SELECT " +
 		"coalesce(datname, 'global') AS database, " +
 		"xact_commit, xact_rollback, blks_read, blks_hit, tup_returned, tup_fetched, tup_inserted, tup_updated, tup_deleted, " +
 		"conflicts, temp_files, temp_bytes, deadlocks, checksum_failures, coalesce(extract(epoch from checksum_last_failure), 0) AS last_checksum_failure_unixtime, " +
-		"blk_read_time, blk_write_time, pg_database_size(datname) as size_bytes, " +
+		"blk_read_time, blk_write_time, " + databaseSizeColumn + ", " +
 		"coalesce(extract('epoch' from age(now(), stats_reset)), 0) as stats_age_seconds " +
-		"FROM pg_stat_database WHERE datname IN (SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate) " +
+		"FROM pg_stat_database WHERE datname IN (SELECT datname FROM pg_database WHERE %s) " +
 		"OR datname IS NULL"
 
 	databasesQueryLatest = "SELECT " +
@@ -32,11 +34,19 @@ const (
 		"conflicts, temp_files, temp_bytes, deadlocks, checksum_failures, coalesce(extract(epoch from checksum_last_failure), 0) AS last_checksum_failure_unixtime, " +
 		"blk_read_time, blk_write_time, " +
 		"session_time, active_time, idle_in_transaction_time, sessions, sessions_abandoned, sessions_fatal, sessions_killed, " +
-		"pg_database_size(datname) as size_bytes, " +
+		databaseSizeColumn + ", " +
 		"coalesce(extract('epoch' from age(now(), stats_reset)), 0) as stats_age_seconds " +
-		"FROM pg_stat_database WHERE datname IN (SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate) " +
+		"FROM pg_stat_database WHERE datname IN (SELECT datname FROM pg_database WHERE %s) " +
 		"OR datname IS NULL"
 
+	// databaseSizeColumn defines how to get database size. Function pg_database_size() fails when user has no CONNECT
+	// privilege on the database, hence check privilege first and return NULL for such databases.
+	databaseSizeColumn = "CASE WHEN has_database_privilege(datname, 'CONNECT') THEN pg_database_size(datname) END AS size_bytes"
+
+	// Databases filters depending on whether templates databases are included or not.
+	databasesFilter          = "datallowconn AND NOT datistemplate"
+	databasesFilterTemplates = "datallowconn OR datistemplate"
+
 	xidLimitQuery = "SELECT 'database' AS src, 2147483647 - greatest(max(age(datfrozenxid)), max(age(coalesce(nullif(datminmxid, 1), datfrozenxid)))) AS to_limit FROM pg_database " +
 		"UNION SELECT 'prepared_xacts' AS src, 2147483647 - coalesce(max(age(transaction)), 0) AS to_limit FROM pg_prepared_xacts " +
 		"UNION SELECT 'replication_slots' AS src, 2147483647 - greatest(coalesce(min(age(xmin)), 0), coalesce(min(age(catalog_xmin)), 0)) AS to_limit FROM pg_replication_slots"
@@ -66,6 +76,7 @@ type postgresDatabasesCollector struct {
 	statsage           typedDesc
 	xidlimit           typedDesc
 	labelNames         []string
+	templates          bool // include template databases
 }
 
 // NewPostgresDatabasesCollector returns a new Collector exposing postgres databases stats.
@@ -73,8 +84,14 @@ type postgresDatabasesCollector struct {
 func NewPostgresDatabasesCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
 	var labels = []string{"database"}
 
+	templates, err := settings.Options.Bool("include_templates", false)
+	if err != nil {
+		return nil, err
+	}
+
 	return &postgresDatabasesCollector{
 		labelNames: labels,
+		templates:  templates,
 		commits: newBuiltinTypedDesc(
 			descOpts{"postgres", "database", "xact_commits_total", "Total number of transactions had been committed.", 0},
 			prometheus.CounterValue,
@@ -211,21 +228,21 @@ func NewPostgresDatabasesCollector(constLabels labels, settings model.CollectorS
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresDatabasesCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *postgresDatabasesCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	res, err := conn.Query(selectDatabasesQuery(config.serverVersionNum))
+	res, err := conn.QueryContext(ctx, selectDatabasesQuery(config.serverVersionNum, c.templates))
 	if err != nil {
 		return err
 	}
 
 	stats := parsePostgresDatabasesStats(res, c.labelNames)
 
-	res, err = conn.Query(xidLimitQuery)
+	res, err = conn.QueryContext(ctx, xidLimitQuery)
 	if err != nil {
 		return err
 	}
@@ -250,7 +267,12 @@ func (c *postgresDatabasesCollector) Update(config Config, ch chan<- prometheus.
 
 		ch <- c.blockstime.newConstMetric(stat.blkreadtime, stat.database, "read")
 		ch <- c.blockstime.newConstMetric(stat.blkwritetime, stat.database, "write")
-		ch <- c.sizes.newConstMetric(stat.sizebytes, stat.database)
+
+		// Size is not available for 'global' stats and for databases without CONNECT privilege.
+		if stat.sizebytes > 0 {
+			ch <- c.sizes.newConstMetric(stat.sizebytes, stat.database)
+		}
+
 		ch <- c.statsage.newConstMetric(stat.statsage, stat.database)
 
 		if config.serverVersionNum >= PostgresV12 {
@@ -454,14 +476,19 @@ func parsePostgresXidLimitStats(r *model.PGResult) xidLimitStats {
 	return stats
 }
 
-// selectDatabasesQuery returns suitable databases query depending on passed version.
-func selectDatabasesQuery(version int) string {
+// selectDatabasesQuery returns suitable databases query depending on passed version and templates inclusion.
+func selectDatabasesQuery(version int, templates bool) string {
+	var filter = databasesFilter
+	if templates {
+		filter = databasesFilterTemplates
+	}
+
 	switch {
 	case version < PostgresV12:
-		return databasesQuery11
+		return fmt.Sprintf(databasesQuery11, filter)
 	case version < PostgresV14:
-		return databasesQuery12
+		return fmt.Sprintf(databasesQuery12, filter)
 	default:
-		return databasesQueryLatest
+		return fmt.Sprintf(databasesQueryLatest, filter)
 	}
 }
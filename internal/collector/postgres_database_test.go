@@ -2,6 +2,7 @@ package collector
 
 import (
 	"database/sql"
+	"fmt"
 	"github.com/jackc/pgproto3/v2"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/stretchr/testify/assert"
@@ -154,15 +155,17 @@ func Test_parsePostgresXidLimitStats(t *testing.T) {
 
 func Test_selectDatabasesQuery(t *testing.T) {
 	testcases := []struct {
-		version int
-		want    string
+		version   int
+		templates bool
+		want      string
 	}{
-		{version: PostgresV10, want: databasesQuery11},
-		{version: PostgresV12, want: databasesQuery12},
-		{version: PostgresV14, want: databasesQueryLatest},
+		{version: PostgresV10, want: fmt.Sprintf(databasesQuery11, databasesFilter)},
+		{version: PostgresV12, want: fmt.Sprintf(databasesQuery12, databasesFilter)},
+		{version: PostgresV14, want: fmt.Sprintf(databasesQueryLatest, databasesFilter)},
+		{version: PostgresV14, templates: true, want: fmt.Sprintf(databasesQueryLatest, databasesFilterTemplates)},
 	}
 
 	for _, tc := range testcases {
-		assert.Equal(t, tc.want, selectDatabasesQuery(tc.version))
+		assert.Equal(t, tc.want, selectDatabasesQuery(tc.version, tc.templates))
 	}
 }
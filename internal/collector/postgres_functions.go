@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/jackc/pgx/v4"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -48,14 +49,15 @@ func NewPostgresFunctionsCollector(constLabels labels, settings model.CollectorS
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresFunctionsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *postgresFunctionsCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
 
-	databases, err := listDatabases(conn)
+	databases, err := listDatabases(ctx, conn)
 	if err != nil {
+		conn.Close()
 		return err
 	}
 
@@ -73,12 +75,12 @@ func (c *postgresFunctionsCollector) Update(config Config, ch chan<- prometheus.
 		}
 
 		pgconfig.Database = d
-		conn, err := store.NewWithConfig(pgconfig)
+		conn, err := store.NewWithConfigContext(ctx, pgconfig)
 		if err != nil {
 			return err
 		}
 
-		res, err := conn.Query(postgresFunctionsQuery)
+		res, err := conn.QueryContext(ctx, postgresFunctionsQuery)
 		conn.Close()
 		if err != nil {
 			log.Warnf("get functions stat of database %s failed: %s", d, err)
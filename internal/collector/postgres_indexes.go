@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/jackc/pgx/v4"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -12,7 +13,14 @@ import (
 
 const (
 	userIndexesQuery = "SELECT current_database() AS database, schemaname AS schema, relname AS table, indexrelname AS index, (i.indisprimary OR i.indisunique) AS key," +
-		"idx_scan, idx_tup_read, idx_tup_fetch, idx_blks_read, idx_blks_hit,pg_relation_size(s1.indexrelid) AS size_bytes " +
+		"idx_scan, idx_tup_read, idx_tup_fetch, idx_blks_read, idx_blks_hit,pg_relation_size(s1.indexrelid) AS size_bytes, " +
+		// Index is considered unused when it has never been scanned and it doesn't back any constraint.
+		"(idx_scan = 0 AND NOT i.indisunique AND NOT EXISTS (SELECT 1 FROM pg_constraint c WHERE c.conindid = s1.indexrelid))::int AS unused, " +
+		// Index is considered duplicate when the same table has another index with the same columns, opclasses, expressions and predicate.
+		"EXISTS (SELECT 1 FROM pg_index d WHERE d.indrelid = i.indrelid AND d.indexrelid <> i.indexrelid " +
+		"AND d.indkey::text = i.indkey::text AND d.indclass::text = i.indclass::text " +
+		"AND coalesce(pg_get_expr(d.indexprs, d.indrelid), '') = coalesce(pg_get_expr(i.indexprs, i.indrelid), '') " +
+		"AND coalesce(pg_get_expr(d.indpred, d.indrelid), '') = coalesce(pg_get_expr(i.indpred, i.indrelid), ''))::int AS duplicate " +
 		"FROM pg_stat_user_indexes s1 " +
 		"JOIN pg_statio_user_indexes s2 USING (schemaname, relname, indexrelname) " +
 		"JOIN pg_index i ON (s1.indexrelid = i.indexrelid) " +
@@ -21,18 +29,30 @@ const (
 
 // postgresIndexesCollector defines metric descriptors and stats store.
 type postgresIndexesCollector struct {
-	indexes typedDesc
-	tuples  typedDesc
-	io      typedDesc
-	sizes   typedDesc
+	indexes   typedDesc
+	tuples    typedDesc
+	tupRead   typedDesc
+	tupFetch  typedDesc
+	io        typedDesc
+	sizes     typedDesc
+	unused    typedDesc
+	duplicate typedDesc
+	objects   postgresObjectsFilter
 }
 
-// NewPostgresIndexesCollector returns a new Collector exposing postgres indexes stats.
+// NewPostgresIndexesCollector returns a new Collector exposing postgres indexes stats. Sequential scans of tables
+// which might need an index are exposed by postgres/tables collector.
 // For details see
 // https://www.postgresql.org/docs/current/monitoring-stats.html#PG-STAT-ALL-INDEXES-VIEW
 // https://www.postgresql.org/docs/current/monitoring-stats.html#PG-STATIO-ALL-INDEXES-VIEW
 func NewPostgresIndexesCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	objects, err := newPostgresObjectsFilter(settings.Options)
+	if err != nil {
+		return nil, err
+	}
+
 	return &postgresIndexesCollector{
+		objects: objects,
 		indexes: newBuiltinTypedDesc(
 			descOpts{"postgres", "index", "scans_total", "Total number of index scans initiated.", 0},
 			prometheus.CounterValue,
@@ -40,11 +60,23 @@ func NewPostgresIndexesCollector(constLabels labels, settings model.CollectorSet
 			settings.Filters,
 		),
 		tuples: newBuiltinTypedDesc(
-			descOpts{"postgres", "index", "tuples_total", "Total number of index entries processed by scans.", 0},
+			descOpts{"postgres", "index", "tuples_total", "Total number of index entries processed by scans. DEPRECATED.", 0},
 			prometheus.CounterValue,
 			[]string{"database", "schema", "table", "index", "tuples"}, constLabels,
 			settings.Filters,
 		),
+		tupRead: newBuiltinTypedDesc(
+			descOpts{"postgres", "index", "tuples_read_total", "Total number of index entries returned by scans on this index.", 0},
+			prometheus.CounterValue,
+			[]string{"database", "schema", "table", "index"}, constLabels,
+			settings.Filters,
+		),
+		tupFetch: newBuiltinTypedDesc(
+			descOpts{"postgres", "index", "tuples_fetched_total", "Total number of live table rows fetched by simple index scans using this index.", 0},
+			prometheus.CounterValue,
+			[]string{"database", "schema", "table", "index"}, constLabels,
+			settings.Filters,
+		),
 		io: newBuiltinTypedDesc(
 			descOpts{"postgres", "index_io", "blocks_total", "Total number of indexes' blocks processed.", 0},
 			prometheus.CounterValue,
@@ -57,18 +89,31 @@ func NewPostgresIndexesCollector(constLabels labels, settings model.CollectorSet
 			[]string{"database", "schema", "table", "index"}, constLabels,
 			settings.Filters,
 		),
+		unused: newBuiltinTypedDesc(
+			descOpts{"postgres", "index", "unused", "Index has never been scanned and doesn't back any constraint.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "index"}, constLabels,
+			settings.Filters,
+		),
+		duplicate: newBuiltinTypedDesc(
+			descOpts{"postgres", "index", "duplicate", "Index has the same definition as another index of the table.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "index"}, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresIndexesCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *postgresIndexesCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
 
-	databases, err := listDatabases(conn)
+	databases, err := listFilteredDatabases(ctx, conn, c.objects)
 	if err != nil {
+		conn.Close()
 		return err
 	}
 
@@ -86,12 +131,12 @@ func (c *postgresIndexesCollector) Update(config Config, ch chan<- prometheus.Me
 		}
 
 		pgconfig.Database = d
-		conn, err := store.NewWithConfig(pgconfig)
+		conn, err := store.NewWithConfigContext(ctx, pgconfig)
 		if err != nil {
 			return err
 		}
 
-		res, err := conn.Query(userIndexesQuery)
+		res, err := conn.QueryContext(ctx, userIndexesQuery+c.objects.relationsCondition("s1.schemaname", "s1.relname"))
 		conn.Close()
 		if err != nil {
 			log.Warnf("get indexes stat of database %s failed: %s", d, err)
@@ -105,9 +150,13 @@ func (c *postgresIndexesCollector) Update(config Config, ch chan<- prometheus.Me
 			ch <- c.indexes.newConstMetric(stat.idxscan, stat.database, stat.schema, stat.table, stat.index, stat.key)
 			ch <- c.sizes.newConstMetric(stat.sizebytes, stat.database, stat.schema, stat.table, stat.index)
 
+			// Ratio of fetched to read entries shows how many heap fetches index scans cause, send both values always.
+			ch <- c.tupRead.newConstMetric(stat.idxtupread, stat.database, stat.schema, stat.table, stat.index)
+			ch <- c.tupFetch.newConstMetric(stat.idxtupfetch, stat.database, stat.schema, stat.table, stat.index)
+
 			// avoid metrics spamming and send metrics only if they greater than zero.
 			if stat.idxtupread > 0 {
-				ch <- c.tuples.newConstMetric(stat.idxread, stat.database, stat.schema, stat.table, stat.index, "read")
+				ch <- c.tuples.newConstMetric(stat.idxtupread, stat.database, stat.schema, stat.table, stat.index, "read")
 			}
 			if stat.idxtupfetch > 0 {
 				ch <- c.tuples.newConstMetric(stat.idxtupfetch, stat.database, stat.schema, stat.table, stat.index, "fetched")
@@ -118,6 +167,14 @@ func (c *postgresIndexesCollector) Update(config Config, ch chan<- prometheus.Me
 			if stat.idxhit > 0 {
 				ch <- c.io.newConstMetric(stat.idxhit, stat.database, stat.schema, stat.table, stat.index, "hit")
 			}
+
+			// send only flagged indexes, healthy indexes are not interesting.
+			if stat.unused > 0 {
+				ch <- c.unused.newConstMetric(stat.unused, stat.database, stat.schema, stat.table, stat.index)
+			}
+			if stat.duplicate > 0 {
+				ch <- c.duplicate.newConstMetric(stat.duplicate, stat.database, stat.schema, stat.table, stat.index)
+			}
 		}
 	}
 
@@ -137,6 +194,8 @@ type postgresIndexStat struct {
 	idxread     float64
 	idxhit      float64
 	sizebytes   float64
+	unused      float64
+	duplicate   float64
 }
 
 // parsePostgresIndexStats parses PGResult and returns structs with stats values.
@@ -202,6 +261,10 @@ func parsePostgresIndexStats(r *model.PGResult, labelNames []string) map[string]
 				s.idxhit = v
 			case "size_bytes":
 				s.sizebytes = v
+			case "unused":
+				s.unused = v
+			case "duplicate":
+				s.duplicate = v
 			default:
 				continue
 			}
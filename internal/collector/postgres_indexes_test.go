@@ -13,8 +13,12 @@ func TestPostgresIndexesCollector_Update(t *testing.T) {
 		optional: []string{
 			"postgres_index_scans_total",
 			"postgres_index_tuples_total",
+			"postgres_index_tuples_read_total",
+			"postgres_index_tuples_fetched_total",
 			"postgres_index_io_blocks_total",
 			"postgres_index_size_bytes",
+			"postgres_index_unused",
+			"postgres_index_duplicate",
 		},
 		collector: NewPostgresIndexesCollector,
 		service:   model.ServiceTypePostgresql,
@@ -33,23 +37,25 @@ func Test_parsePostgresIndexStats(t *testing.T) {
 			name: "normal output",
 			res: &model.PGResult{
 				Nrows: 1,
-				Ncols: 9,
+				Ncols: 11,
 				Colnames: []pgproto3.FieldDescription{
 					{Name: []byte("database")}, {Name: []byte("schema")}, {Name: []byte("table")}, {Name: []byte("index")},
 					{Name: []byte("idx_scan")}, {Name: []byte("idx_tup_read")}, {Name: []byte("idx_tup_fetch")},
 					{Name: []byte("idx_blks_read")}, {Name: []byte("idx_blks_hit")},
+					{Name: []byte("unused")}, {Name: []byte("duplicate")},
 				},
 				Rows: [][]sql.NullString{
 					{
 						{String: "testdb", Valid: true}, {String: "testschema", Valid: true}, {String: "testrelname", Valid: true}, {String: "testindex", Valid: true},
 						{String: "5842", Valid: true}, {String: "84572", Valid: true}, {String: "485", Valid: true}, {String: "4128", Valid: true}, {String: "847", Valid: true},
+						{String: "0", Valid: true}, {String: "1", Valid: true},
 					},
 				},
 			},
 			want: map[string]postgresIndexStat{
 				"testdb/testschema/testrelname/testindex": {
 					database: "testdb", schema: "testschema", table: "testrelname", index: "testindex",
-					idxscan: 5842, idxtupread: 84572, idxtupfetch: 485, idxread: 4128, idxhit: 847,
+					idxscan: 5842, idxtupread: 84572, idxtupfetch: 485, idxread: 4128, idxhit: 847, duplicate: 1,
 				},
 			},
 		},
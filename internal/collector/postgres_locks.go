@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
@@ -19,7 +20,9 @@ const (
 		"count(*) FILTER (WHERE mode = 'ExclusiveLock') AS exclusive_lock, " +
 		"count(*) FILTER (WHERE mode = 'AccessExclusiveLock') AS access_exclusive_lock, " +
 		"count(*) FILTER (WHERE not granted) AS not_granted, " +
-		"count(*) AS total " +
+		"count(*) AS total, " +
+		"(SELECT coalesce(max(extract(epoch FROM clock_timestamp() - a.query_start)), 0) " +
+		"FROM pg_locks l LEFT JOIN pg_stat_activity a ON l.pid = a.pid WHERE NOT l.granted) AS waiting_seconds_max " +
 		"FROM pg_locks"
 )
 
@@ -28,6 +31,7 @@ type postgresLocksCollector struct {
 	locks      typedDesc
 	locksAll   typedDesc
 	notgranted typedDesc
+	waitingMax typedDesc
 }
 
 // NewPostgresLocksCollector creates new postgresLocksCollector.
@@ -51,24 +55,30 @@ func NewPostgresLocksCollector(constLabels labels, settings model.CollectorSetti
 			nil, constLabels,
 			settings.Filters,
 		),
+		waitingMax: newBuiltinTypedDesc(
+			descOpts{"postgres", "locks", "waiting_seconds_max", "Longest time spent by process waiting for lock since its query has been started, in seconds.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
 // Update method collects locks metrics.
-func (c *postgresLocksCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *postgresLocksCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	// get pg_stat_activity stats
-	res, err := conn.Query(locksQuery)
+	// get pg_locks stats
+	res, err := conn.QueryContext(ctx, locksQuery)
 	if err != nil {
 		return err
 	}
 
-	// parse pg_stat_activity stats
+	// parse pg_locks stats
 	stats := parsePostgresLocksStats(res)
 
 	ch <- c.locks.newConstMetric(stats.accessShareLock, "AccessShareLock")
@@ -81,6 +91,7 @@ func (c *postgresLocksCollector) Update(config Config, ch chan<- prometheus.Metr
 	ch <- c.locks.newConstMetric(stats.accessExclusiveLock, "AccessExclusiveLock")
 	ch <- c.notgranted.newConstMetric(stats.notGranted)
 	ch <- c.locksAll.newConstMetric(stats.total)
+	ch <- c.waitingMax.newConstMetric(stats.waitingMax)
 
 	return nil
 }
@@ -97,6 +108,7 @@ type locksStat struct {
 	accessExclusiveLock      float64
 	notGranted               float64
 	total                    float64
+	waitingMax               float64 // locks without related activity (e.g. prepared transactions) are not accounted
 }
 
 // parsePostgresLocksStats parses result returned from Postgres and return locks stats.
@@ -141,6 +153,8 @@ func parsePostgresLocksStats(r *model.PGResult) locksStat {
 				stats.notGranted = v
 			case "total":
 				stats.total = v
+			case "waiting_seconds_max":
+				stats.waitingMax = v
 			default:
 				continue
 			}
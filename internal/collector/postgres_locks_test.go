@@ -14,6 +14,7 @@ func TestPostgresLocksCollector_Update(t *testing.T) {
 			"postgres_locks_in_flight",
 			"postgres_locks_all_in_flight",
 			"postgres_locks_not_granted_in_flight",
+			"postgres_locks_waiting_seconds_max",
 		},
 		collector: NewPostgresLocksCollector,
 		service:   model.ServiceTypePostgresql,
@@ -32,13 +33,14 @@ func Test_parsePostgresLocksStats(t *testing.T) {
 			name: "normal output",
 			res: &model.PGResult{
 				Nrows: 1,
-				Ncols: 10,
+				Ncols: 11,
 				Colnames: []pgproto3.FieldDescription{
 					{Name: []byte("access_share_lock")}, {Name: []byte("row_share_lock")},
 					{Name: []byte("row_exclusive_lock")}, {Name: []byte("share_update_exclusive_lock")},
 					{Name: []byte("share_lock")}, {Name: []byte("share_row_exclusive_lock")},
 					{Name: []byte("exclusive_lock")}, {Name: []byte("access_exclusive_lock")},
 					{Name: []byte("not_granted")}, {Name: []byte("total")},
+					{Name: []byte("waiting_seconds_max")},
 				},
 				Rows: [][]sql.NullString{
 					{
@@ -47,13 +49,14 @@ func Test_parsePostgresLocksStats(t *testing.T) {
 						{String: "7", Valid: true}, {String: "9", Valid: true},
 						{String: "1", Valid: true}, {String: "2", Valid: true},
 						{String: "6", Valid: true}, {String: "47", Valid: true},
+						{String: "12.5", Valid: true},
 					},
 				},
 			},
 			want: locksStat{
 				accessShareLock: 11, rowShareLock: 5, rowExclusiveLock: 4, shareUpdateExclusiveLock: 8,
 				shareLock: 7, shareRowExclusiveLock: 9, exclusiveLock: 1, accessExclusiveLock: 2,
-				notGranted: 6, total: 47,
+				notGranted: 6, total: 47, waitingMax: 12.5,
 			},
 		},
 	}
@@ -2,6 +2,7 @@ package collector
 
 import (
 	"context"
+	"fmt"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
@@ -105,7 +106,7 @@ func NewPostgresLogsCollector(constLabels labels, settings model.CollectorSettin
 }
 
 // Update method generates metrics based on collected log messages.
-func (c *postgresLogsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+func (c *postgresLogsCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
 	if !config.localService {
 		log.Debugln("[postgres log collector]: skip collecting metrics from remote services")
 		return nil
@@ -120,8 +121,12 @@ func (c *postgresLogsCollector) Update(config Config, ch chan<- prometheus.Metri
 		return nil
 	}
 
+	if !config.monitorPrivileges() {
+		return fmt.Errorf("%w: pg_current_logfile() requires superuser or pg_monitor role", errInsufficientPrivilege)
+	}
+
 	// Notify log collector goroutine if logfile has been changed.
-	logfile, err := queryCurrentLogfile(config.ConnString)
+	logfile, err := queryCurrentLogfile(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
@@ -242,18 +247,18 @@ func tailCollect(ctx context.Context, logfile string, init bool, wg *sync.WaitGr
 }
 
 // queryCurrentLogfile returns path to logfile used by database.
-func queryCurrentLogfile(conninfo string) (string, error) {
-	conn, err := store.New(conninfo)
+func queryCurrentLogfile(ctx context.Context, conninfo string) (string, error) {
+	conn, err := store.NewContext(ctx, conninfo)
 	if err != nil {
 		return "", err
 	}
+	defer conn.Close()
 
 	var datadir, logfile string
-	err = conn.Conn().QueryRow(context.TODO(), "SELECT current_setting('data_directory'),pg_current_logfile()").Scan(&datadir, &logfile)
+	err = conn.Conn().QueryRow(ctx, "SELECT current_setting('data_directory'),pg_current_logfile()").Scan(&datadir, &logfile)
 	if err != nil {
 		return "", err
 	}
-	conn.Close()
 
 	if !strings.HasPrefix(logfile, "/") {
 		logfile = datadir + "/" + logfile
@@ -0,0 +1,138 @@
+package collector
+
+import (
+	"context"
+	"math"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Only manual VACUUM and ANALYZE are taken into account, automatic ones are exposed by postgres/tables collector.
+	postgresMaintenanceQuery = "SELECT current_database() AS database, schemaname AS schema, relname AS table, " +
+		"extract(epoch FROM clock_timestamp() - last_vacuum) AS last_vacuum_seconds, " +
+		"extract(epoch FROM clock_timestamp() - last_analyze) AS last_analyze_seconds, " +
+		"vacuum_count, analyze_count " +
+		"FROM pg_stat_user_tables WHERE true"
+
+	// postgresMaintenanceNeverSeconds defines age sent for tables which have never been vacuumed or analyzed. The value
+	// is large enough to exceed any reasonable alerting threshold.
+	postgresMaintenanceNeverSeconds = math.MaxInt32
+)
+
+// postgresMaintenanceCollector defines metric descriptors.
+type postgresMaintenanceCollector struct {
+	lastVacuum  typedDesc
+	lastAnalyze typedDesc
+	vacuums     typedDesc
+	analyzes    typedDesc
+	labelNames  []string
+	objects     postgresObjectsFilter
+}
+
+// NewPostgresMaintenanceCollector returns a new Collector exposing time since tables have been vacuumed or analyzed
+// manually and number of manual vacuums and analyzes. Tables which have never been vacuumed or analyzed have age
+// equal to postgresMaintenanceNeverSeconds.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-ALL-TABLES-VIEW
+func NewPostgresMaintenanceCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	var labels = []string{"database", "schema", "table"}
+
+	objects, err := newPostgresObjectsFilter(settings.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &postgresMaintenanceCollector{
+		labelNames: labels,
+		objects:    objects,
+		lastVacuum: newBuiltinTypedDesc(
+			descOpts{"postgres", "table", "last_vacuum_seconds", "Time since table has been vacuumed manually (not counting VACUUM FULL), in seconds.", 0},
+			prometheus.GaugeValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		lastAnalyze: newBuiltinTypedDesc(
+			descOpts{"postgres", "table", "last_analyze_seconds", "Time since table has been analyzed manually, in seconds.", 0},
+			prometheus.GaugeValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		vacuums: newBuiltinTypedDesc(
+			descOpts{"postgres", "table", "vacuum_count_total", "Total number of times table has been vacuumed manually (not counting VACUUM FULL).", 0},
+			prometheus.CounterValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		analyzes: newBuiltinTypedDesc(
+			descOpts{"postgres", "table", "analyze_count_total", "Total number of times table has been analyzed manually.", 0},
+			prometheus.CounterValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresMaintenanceCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	databases, err := listFilteredDatabases(ctx, conn, c.objects)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	conn.Close()
+
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range databases {
+		// Skip database if not matched to allowed.
+		if config.DatabasesRE != nil && !config.DatabasesRE.MatchString(d) {
+			continue
+		}
+
+		pgconfig.Database = d
+		conn, err := store.NewWithConfigContext(ctx, pgconfig)
+		if err != nil {
+			return err
+		}
+
+		res, err := conn.QueryContext(ctx, postgresMaintenanceQuery+c.objects.relationsCondition("schemaname", "relname"))
+		conn.Close()
+		if err != nil {
+			log.Warnf("get tables maintenance stat of database '%s' failed: %s; skip", d, err)
+			continue
+		}
+
+		for _, stat := range parsePostgresGenericStats(res, c.labelNames) {
+			database, schema, table := stat.labels["database"], stat.labels["schema"], stat.labels["table"]
+
+			ch <- c.lastVacuum.newConstMetric(maintenanceAge(stat.values, "last_vacuum_seconds"), database, schema, table)
+			ch <- c.lastAnalyze.newConstMetric(maintenanceAge(stat.values, "last_analyze_seconds"), database, schema, table)
+			ch <- c.vacuums.newConstMetric(stat.values["vacuum_count"], database, schema, table)
+			ch <- c.analyzes.newConstMetric(stat.values["analyze_count"], database, schema, table)
+		}
+	}
+
+	return nil
+}
+
+// maintenanceAge returns value of passed age column, postgresMaintenanceNeverSeconds is returned when value is NULL.
+func maintenanceAge(values map[string]float64, name string) float64 {
+	v, ok := values[name]
+	if !ok {
+		return postgresMaintenanceNeverSeconds
+	}
+	return v
+}
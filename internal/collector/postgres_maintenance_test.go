@@ -0,0 +1,29 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresMaintenanceCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_table_last_vacuum_seconds",
+			"postgres_table_last_analyze_seconds",
+			"postgres_table_vacuum_count_total",
+			"postgres_table_analyze_count_total",
+		},
+		collector: NewPostgresMaintenanceCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_maintenanceAge(t *testing.T) {
+	values := map[string]float64{"last_vacuum_seconds": 10}
+
+	assert.Equal(t, float64(10), maintenanceAge(values, "last_vacuum_seconds"))
+	assert.Equal(t, float64(postgresMaintenanceNeverSeconds), maintenanceAge(values, "last_analyze_seconds"))
+}
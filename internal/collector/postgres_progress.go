@@ -0,0 +1,187 @@
+package collector
+
+import (
+	"context"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	postgresProgressVacuumQuery = "SELECT pid, datname AS database, relid::regclass::text AS relation, phase, " +
+		"heap_blks_total, heap_blks_scanned, heap_blks_vacuumed, index_vacuum_count " +
+		"FROM pg_stat_progress_vacuum"
+
+	postgresProgressAnalyzeQuery = "SELECT pid, datname AS database, relid::regclass::text AS relation, phase, " +
+		"sample_blks_total, sample_blks_scanned, ext_stats_total, ext_stats_computed, child_tables_total, child_tables_done " +
+		"FROM pg_stat_progress_analyze"
+
+	postgresProgressCreateIndexQuery = "SELECT pid, datname AS database, relid::regclass::text AS relation, phase, " +
+		"blocks_total, blocks_done, tuples_total, tuples_done, partitions_total, partitions_done " +
+		"FROM pg_stat_progress_create_index"
+
+	postgresProgressBasebackupQuery = "SELECT pid, phase, " +
+		"backup_total, backup_streamed, tablespaces_total, tablespaces_streamed " +
+		"FROM pg_stat_progress_basebackup"
+)
+
+// progressValue describes single value column of progress view.
+type progressValue struct {
+	name string
+	help string
+}
+
+// progressView describes progress reporting view and its metrics.
+type progressView struct {
+	name       string // name used as a metric subsystem prefix, e.g. vacuum
+	query      string
+	minVersion int // the Postgres version where view has been introduced
+	labelNames []string
+	values     []progressValue
+	phase      typedDesc
+	descs      map[string]typedDesc
+}
+
+// progressViews defines progress reporting views used by the collector.
+var progressViews = []progressView{
+	{
+		name:       "vacuum",
+		query:      postgresProgressVacuumQuery,
+		minVersion: PostgresV96,
+		labelNames: []string{"pid", "database", "relation"},
+		values: []progressValue{
+			{name: "heap_blks_total", help: "Total number of heap blocks in the table being vacuumed."},
+			{name: "heap_blks_scanned", help: "Number of heap blocks scanned."},
+			{name: "heap_blks_vacuumed", help: "Number of heap blocks vacuumed."},
+			{name: "index_vacuum_count", help: "Number of completed index vacuum cycles."},
+		},
+	},
+	{
+		name:       "analyze",
+		query:      postgresProgressAnalyzeQuery,
+		minVersion: PostgresV13,
+		labelNames: []string{"pid", "database", "relation"},
+		values: []progressValue{
+			{name: "sample_blks_total", help: "Total number of heap blocks that will be sampled."},
+			{name: "sample_blks_scanned", help: "Number of heap blocks scanned."},
+			{name: "ext_stats_total", help: "Number of extended statistics."},
+			{name: "ext_stats_computed", help: "Number of extended statistics computed."},
+			{name: "child_tables_total", help: "Number of child tables."},
+			{name: "child_tables_done", help: "Number of child tables scanned."},
+		},
+	},
+	{
+		name:       "create_index",
+		query:      postgresProgressCreateIndexQuery,
+		minVersion: PostgresV12,
+		labelNames: []string{"pid", "database", "relation"},
+		values: []progressValue{
+			{name: "blocks_total", help: "Total number of blocks to be processed in the current phase."},
+			{name: "blocks_done", help: "Number of blocks already processed in the current phase."},
+			{name: "tuples_total", help: "Total number of tuples to be processed in the current phase."},
+			{name: "tuples_done", help: "Number of tuples already processed in the current phase."},
+			{name: "partitions_total", help: "Total number of partitions on which the index is to be created or attached."},
+			{name: "partitions_done", help: "Number of partitions on which the index has been created or attached."},
+		},
+	},
+	{
+		name:       "basebackup",
+		query:      postgresProgressBasebackupQuery,
+		minVersion: PostgresV13,
+		labelNames: []string{"pid"},
+		values: []progressValue{
+			{name: "backup_total", help: "Total amount of data that will be streamed, in bytes."},
+			{name: "backup_streamed", help: "Amount of data streamed, in bytes."},
+			{name: "tablespaces_total", help: "Total number of tablespaces that will be streamed."},
+			{name: "tablespaces_streamed", help: "Number of tablespaces streamed."},
+		},
+	},
+}
+
+type postgresProgressCollector struct {
+	views []progressView
+}
+
+// NewPostgresProgressCollector returns a new Collector exposing progress of running vacuum, analyze, create index
+// and base backup operations.
+// For details see https://www.postgresql.org/docs/current/progress-reporting.html
+func NewPostgresProgressCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	var views = make([]progressView, 0, len(progressViews))
+
+	for _, v := range progressViews {
+		subsystem := v.name + "_progress"
+
+		v.phase = newBuiltinTypedDesc(
+			descOpts{"postgres", subsystem, "phase", "Current processing phase of the " + v.name + " operation.", 0},
+			prometheus.GaugeValue,
+			append(append([]string{}, v.labelNames...), "phase"), constLabels,
+			settings.Filters,
+		)
+
+		v.descs = map[string]typedDesc{}
+		for _, value := range v.values {
+			v.descs[value.name] = newBuiltinTypedDesc(
+				descOpts{"postgres", subsystem, value.name, value.help, 0},
+				prometheus.GaugeValue,
+				v.labelNames, constLabels,
+				settings.Filters,
+			)
+		}
+
+		views = append(views, v)
+	}
+
+	return &postgresProgressCollector{views: views}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresProgressCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, v := range c.views {
+		if config.serverVersionNum < v.minVersion {
+			log.Debugf("[postgres progress collector]: %s progress view is not available, skip", v.name)
+			continue
+		}
+
+		res, err := conn.QueryContext(ctx, v.query)
+		if err != nil {
+			log.Warnf("get %s progress failed: %s; skip", v.name, err)
+			continue
+		}
+
+		// Nothing is in progress.
+		if res.Nrows == 0 {
+			continue
+		}
+
+		stats := parsePostgresGenericStats(res, append(append([]string{}, v.labelNames...), "phase"))
+
+		for _, stat := range stats {
+			var labelValues = make([]string, 0, len(v.labelNames))
+			for _, name := range v.labelNames {
+				labelValues = append(labelValues, stat.labels[name])
+			}
+
+			ch <- v.phase.newConstMetric(1, append(labelValues, stat.labels["phase"])...)
+
+			for _, value := range v.values {
+				// Skip values which are not available (NULL) in current phase.
+				val, ok := stat.values[value.name]
+				if !ok {
+					continue
+				}
+
+				desc := v.descs[value.name]
+				ch <- desc.newConstMetric(val, labelValues...)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresProgressCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_vacuum_progress_phase",
+			"postgres_vacuum_progress_heap_blks_total",
+			"postgres_vacuum_progress_heap_blks_scanned",
+			"postgres_vacuum_progress_heap_blks_vacuumed",
+			"postgres_vacuum_progress_index_vacuum_count",
+			"postgres_analyze_progress_phase",
+			"postgres_analyze_progress_sample_blks_total",
+			"postgres_analyze_progress_sample_blks_scanned",
+			"postgres_analyze_progress_ext_stats_total",
+			"postgres_analyze_progress_ext_stats_computed",
+			"postgres_analyze_progress_child_tables_total",
+			"postgres_analyze_progress_child_tables_done",
+			"postgres_create_index_progress_phase",
+			"postgres_create_index_progress_blocks_total",
+			"postgres_create_index_progress_blocks_done",
+			"postgres_create_index_progress_tuples_total",
+			"postgres_create_index_progress_tuples_done",
+			"postgres_create_index_progress_partitions_total",
+			"postgres_create_index_progress_partitions_done",
+			"postgres_basebackup_progress_phase",
+			"postgres_basebackup_progress_backup_total",
+			"postgres_basebackup_progress_backup_streamed",
+			"postgres_basebackup_progress_tablespaces_total",
+			"postgres_basebackup_progress_tablespaces_streamed",
+		},
+		collector: NewPostgresProgressCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func TestNewPostgresProgressCollector(t *testing.T) {
+	c, err := NewPostgresProgressCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	pc := c.(*postgresProgressCollector)
+	assert.Len(t, pc.views, len(progressViews))
+
+	for _, v := range pc.views {
+		assert.Len(t, v.descs, len(v.values))
+		assert.Equal(t, append(v.labelNames, "phase"), v.phase.labelNames)
+	}
+
+	// Descriptors must not be shared between views.
+	for _, v := range progressViews {
+		assert.Nil(t, v.descs)
+	}
+}
@@ -1,6 +1,8 @@
 package collector
 
 import (
+	"context"
+	"fmt"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
@@ -9,28 +11,41 @@ import (
 )
 
 const (
-	// Query for Postgres version 9.6 and older.
+	// Query for Postgres version 9.6 and older. The placeholder should be replaced with function which returns current WAL
+	// location - on primary it is the current write location, on standby (in case of cascading replication) it is the
+	// last replayed location.
 	postgresReplicationQuery96 = "SELECT pid, coalesce(host(client_addr), '127.0.0.1') AS client_addr, coalesce(client_port, '0') AS client_port, usename AS user, application_name, state, " +
-		"pg_current_xlog_location() - sent_location AS pending_lag_bytes, " +
+		"%[1]s - sent_location AS pending_lag_bytes, " +
 		"sent_location - write_location AS write_lag_bytes, " +
 		"write_location - flush_location AS flush_lag_bytes, " +
 		"flush_location - replay_location AS replay_lag_bytes, " +
-		"pg_current_xlog_location() - replay_location AS total_lag_bytes, " +
+		"%[1]s - replay_location AS total_lag_bytes, " +
 		"NULL AS write_lag_seconds, NULL AS flush_lag_seconds, NULL AS replay_lag_seconds, NULL AS total_lag_seconds " +
 		"FROM pg_stat_replication"
 
-	// Query for Postgres versions from 10 and newer.
+	// Query for Postgres versions from 10 and newer. The placeholder has the same meaning as for 9.6 query.
 	postgresReplicationQueryLatest = "SELECT pid, coalesce(host(client_addr), '127.0.0.1') AS client_addr, coalesce(client_port, '0') AS client_port, usename AS user, application_name, state, " +
-		"pg_current_wal_lsn() - sent_lsn AS pending_lag_bytes, " +
+		"%[1]s - sent_lsn AS pending_lag_bytes, " +
 		"sent_lsn - write_lsn AS write_lag_bytes, " +
 		"write_lsn - flush_lsn AS flush_lag_bytes, " +
 		"flush_lsn - replay_lsn AS replay_lag_bytes, " +
-		"pg_current_wal_lsn() - replay_lsn AS total_lag_bytes, " +
+		"%[1]s - replay_lsn AS total_lag_bytes, " +
 		"coalesce(extract(epoch from write_lag), 0) AS write_lag_seconds, " +
 		"coalesce(extract(epoch from flush_lag), 0) AS flush_lag_seconds, " +
 		"coalesce(extract(epoch from replay_lag), 0) AS replay_lag_seconds, " +
 		"coalesce(extract(epoch from write_lag+flush_lag+replay_lag), 0) AS total_lag_seconds " +
 		"FROM pg_stat_replication"
+
+	// Query used for detecting role of the Postgres - primary or standby.
+	postgresRecoveryQuery = "SELECT pg_is_in_recovery()::int AS recovery"
+
+	// Queries for getting standby lag based on last replayed transaction. When all received WAL has been replayed,
+	// consider standby has no lag, otherwise lag will grow on idle primary.
+	postgresRecoveryLagQuery96 = "SELECT CASE WHEN pg_last_xlog_receive_location() = pg_last_xlog_replay_location() THEN 0 " +
+		"ELSE extract(epoch from now() - pg_last_xact_replay_timestamp()) END AS lag_seconds"
+
+	postgresRecoveryLagQueryLatest = "SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0 " +
+		"ELSE extract(epoch from now() - pg_last_xact_replay_timestamp()) END AS lag_seconds"
 )
 
 type postgresReplicationCollector struct {
@@ -39,6 +54,7 @@ type postgresReplicationCollector struct {
 	lagseconds      typedDesc
 	lagtotalbytes   typedDesc
 	lagtotalseconds typedDesc
+	recoveryLag     typedDesc
 }
 
 // NewPostgresReplicationCollector returns a new Collector exposing postgres replication stats.
@@ -72,19 +88,49 @@ func NewPostgresReplicationCollector(constLabels labels, settings model.Collecto
 			[]string{"client_addr", "client_port", "user", "application_name", "state"}, constLabels,
 			settings.Filters,
 		),
+		recoveryLag: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery", "lag_seconds", "Number of seconds standby is behind than primary, based on last replayed transaction.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresReplicationCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *postgresReplicationCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	// Get replication stats.
-	res, err := conn.Query(selectReplicationQuery(config.serverVersionNum))
+	// Detect role on every update, because standby could be promoted at any time.
+	recovery, err := isInRecovery(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	// Get standby lag.
+	if recovery {
+		res, err := conn.QueryContext(ctx, selectRecoveryLagQuery(config.serverVersionNum))
+		if err != nil {
+			return err
+		}
+
+		// Lag is unknown (NULL) when no transactions have been replayed yet.
+		if len(res.Rows) > 0 && res.Rows[0][0].Valid {
+			v, err := strconv.ParseFloat(res.Rows[0][0].String, 64)
+			if err != nil {
+				log.Errorf("invalid input, parse '%s' failed: %s; skip", res.Rows[0][0].String, err)
+			} else {
+				ch <- c.recoveryLag.newConstMetric(v)
+			}
+		}
+	}
+
+	// Get replication stats. Standby could also have connected standbys in case of cascading replication.
+	res, err := conn.QueryContext(ctx, selectReplicationQuery(config.serverVersionNum, recovery))
 	if err != nil {
 		return err
 	}
@@ -221,12 +267,42 @@ func parsePostgresReplicationStats(r *model.PGResult, labelNames []string) map[s
 	return stats
 }
 
-// selectReplicationQuery returns suitable replication query depending on passed version.
-func selectReplicationQuery(version int) string {
+// isInRecovery returns true if Postgres is in recovery (is a standby).
+func isInRecovery(ctx context.Context, conn *store.DB) (bool, error) {
+	res, err := conn.QueryContext(ctx, postgresRecoveryQuery)
+	if err != nil {
+		return false, err
+	}
+
+	if len(res.Rows) == 0 || len(res.Rows[0]) == 0 {
+		return false, fmt.Errorf("recovery state not found")
+	}
+
+	return res.Rows[0][0].String == "1", nil
+}
+
+// selectReplicationQuery returns suitable replication query depending on passed version and recovery state.
+func selectReplicationQuery(version int, recovery bool) string {
+	switch {
+	case version < PostgresV10:
+		if recovery {
+			return fmt.Sprintf(postgresReplicationQuery96, "pg_last_xlog_replay_location()")
+		}
+		return fmt.Sprintf(postgresReplicationQuery96, "pg_current_xlog_location()")
+	default:
+		if recovery {
+			return fmt.Sprintf(postgresReplicationQueryLatest, "pg_last_wal_replay_lsn()")
+		}
+		return fmt.Sprintf(postgresReplicationQueryLatest, "pg_current_wal_lsn()")
+	}
+}
+
+// selectRecoveryLagQuery returns suitable standby lag query depending on passed version.
+func selectRecoveryLagQuery(version int) string {
 	switch {
 	case version < PostgresV10:
-		return postgresReplicationQuery96
+		return postgresRecoveryLagQuery96
 	default:
-		return postgresReplicationQueryLatest
+		return postgresRecoveryLagQueryLatest
 	}
 }
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
@@ -11,15 +12,35 @@ import (
 
 const (
 	// Query for Postgres version 9.6 and older.
-	postgresReplicationSlotQuery96 = "SELECT database, slot_name, slot_type, active, pg_current_xlog_location() - restart_lsn AS since_restart_bytes FROM pg_replication_slots"
-
-	// Query for Postgres versions from 10 and newer.
-	postgresReplicationSlotQueryLatest = "SELECT database, slot_name, slot_type, active, pg_current_wal_lsn() - restart_lsn AS since_restart_bytes FROM pg_replication_slots"
+	// 1. pg_current_xlog_location() fails during recovery, on standby use last received location.
+	// 2. restart_lsn is NULL for slots which have never been used, consider such slots retain no WAL.
+	postgresReplicationSlotQuery96 = "SELECT database, slot_name, slot_type, active, " +
+		"coalesce((case pg_is_in_recovery() when 't' then pg_last_xlog_receive_location() else pg_current_xlog_location() end) - restart_lsn, 0) AS since_restart_bytes " +
+		"FROM pg_replication_slots"
+
+	// Query for Postgres versions 10 and 11.
+	// Lag in seconds is taken from walsender which uses the slot, it is NULL for inactive slots. Walsender's flush_lag
+	// is NULL when consumer has caught up and there is no activity.
+	postgresReplicationSlotQuery11 = "SELECT s.database, s.slot_name, s.slot_type, s.active, " +
+		"coalesce((case pg_is_in_recovery() when 't' then pg_last_wal_receive_lsn() else pg_current_wal_lsn() end) - s.restart_lsn, 0) AS since_restart_bytes, " +
+		"CASE WHEN r.pid IS NOT NULL THEN coalesce(extract(epoch FROM r.flush_lag), 0) END AS lag_seconds " +
+		"FROM pg_replication_slots s LEFT JOIN pg_stat_replication r ON r.pid = s.active_pid"
+
+	// Query for Postgres versions from 12 and newer.
+	// When consumer has not confirmed all sent WAL, the data is at least as old as the consumer's last reply.
+	postgresReplicationSlotQueryLatest = "SELECT s.database, s.slot_name, s.slot_type, s.active, " +
+		"coalesce((case pg_is_in_recovery() when 't' then pg_last_wal_receive_lsn() else pg_current_wal_lsn() end) - s.restart_lsn, 0) AS since_restart_bytes, " +
+		"CASE WHEN r.pid IS NOT NULL THEN greatest(coalesce(extract(epoch FROM r.flush_lag), 0), " +
+		"CASE WHEN r.flush_lsn < r.sent_lsn THEN extract(epoch FROM clock_timestamp() - r.reply_time) END) END AS lag_seconds " +
+		"FROM pg_replication_slots s LEFT JOIN pg_stat_replication r ON r.pid = s.active_pid"
 )
 
 //
 type postgresReplicationSlotCollector struct {
-	restart typedDesc
+	restart      typedDesc
+	active       typedDesc
+	lag          typedDesc
+	lagAvailable typedDesc
 }
 
 // NewPostgresReplicationSlotsCollector returns a new Collector exposing postgres replication slots stats.
@@ -32,18 +53,36 @@ func NewPostgresReplicationSlotsCollector(constLabels labels, settings model.Col
 			[]string{"database", "slot_name", "slot_type", "active"}, constLabels,
 			settings.Filters,
 		),
+		active: newBuiltinTypedDesc(
+			descOpts{"postgres", "replication_slot", "active", "Value is 1 if slot is currently actively being used, 0 otherwise.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "slot_name", "slot_type"}, constLabels,
+			settings.Filters,
+		),
+		lag: newBuiltinTypedDesc(
+			descOpts{"postgres", "replication_slot", "lag_seconds", "Age of the oldest data not yet flushed by slot's consumer, in seconds.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "slot_name", "slot_type"}, constLabels,
+			settings.Filters,
+		),
+		lagAvailable: newBuiltinTypedDesc(
+			descOpts{"postgres", "replication_slot", "lag_seconds_available", "Value is 1 if slot's lag in seconds could be calculated, 0 otherwise.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "slot_name", "slot_type"}, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresReplicationSlotCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *postgresReplicationSlotCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	res, err := conn.Query(selectReplicationSlotQuery(config.serverVersionNum))
+	res, err := conn.QueryContext(ctx, selectReplicationSlotQuery(config.serverVersionNum))
 	if err != nil {
 		return err
 	}
@@ -53,6 +92,20 @@ func (c *postgresReplicationSlotCollector) Update(config Config, ch chan<- prome
 
 	for _, stat := range stats {
 		ch <- c.restart.newConstMetric(stat.retainedBytes, stat.database, stat.slotname, stat.slottype, stat.active)
+
+		var active float64
+		if stat.active == "t" || stat.active == "true" {
+			active = 1
+		}
+		ch <- c.active.newConstMetric(active, stat.database, stat.slotname, stat.slottype)
+
+		// Lag in seconds is not available for inactive slots and older Postgres versions.
+		if stat.lagAvailable {
+			ch <- c.lag.newConstMetric(stat.lagSeconds, stat.database, stat.slotname, stat.slottype)
+			ch <- c.lagAvailable.newConstMetric(1, stat.database, stat.slotname, stat.slottype)
+		} else {
+			ch <- c.lagAvailable.newConstMetric(0, stat.database, stat.slotname, stat.slottype)
+		}
 	}
 
 	return nil
@@ -65,6 +118,8 @@ type postgresReplicationSlotStat struct {
 	slottype      string
 	active        string
 	retainedBytes float64
+	lagSeconds    float64
+	lagAvailable  bool
 }
 
 // parsePostgresReplicationSlotStats parses PGResult and returns struct with stats values.
@@ -121,6 +176,9 @@ func parsePostgresReplicationSlotStats(r *model.PGResult, labelNames []string) m
 			switch string(colname.Name) {
 			case "since_restart_bytes":
 				s.retainedBytes = v
+			case "lag_seconds":
+				s.lagSeconds = v
+				s.lagAvailable = true
 			default:
 				continue
 			}
@@ -137,6 +195,8 @@ func selectReplicationSlotQuery(version int) string {
 	switch {
 	case version < PostgresV10:
 		return postgresReplicationSlotQuery96
+	case version < PostgresV12:
+		return postgresReplicationSlotQuery11
 	default:
 		return postgresReplicationSlotQueryLatest
 	}
@@ -13,6 +13,9 @@ func TestPostgresReplicationSlotCollector_Update(t *testing.T) {
 		required: []string{},
 		optional: []string{
 			"postgres_replication_slot_wal_retain_bytes",
+			"postgres_replication_slot_active",
+			"postgres_replication_slot_lag_seconds",
+			"postgres_replication_slot_lag_seconds_available",
 		},
 		collector: NewPostgresReplicationSlotsCollector,
 		service:   model.ServiceTypePostgresql,
@@ -45,6 +48,28 @@ func Test_parsePostgresReplicationSlotStats(t *testing.T) {
 				"testdb/testslot/testtype": {slotname: "testslot", slottype: "testtype", database: "testdb", active: "t", retainedBytes: 25485425},
 			},
 		},
+		{
+			name: "lag seconds",
+			res: &model.PGResult{
+				Nrows: 2,
+				Ncols: 6,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("slot_name")}, {Name: []byte("slot_type")}, {Name: []byte("database")}, {Name: []byte("active")}, {Name: []byte("since_restart_bytes")}, {Name: []byte("lag_seconds")},
+				},
+				Rows: [][]sql.NullString{
+					{
+						{String: "slot1", Valid: true}, {String: "physical", Valid: true}, {String: "", Valid: false}, {String: "t", Valid: true}, {String: "1024", Valid: true}, {String: "1.5", Valid: true},
+					},
+					{
+						{String: "slot2", Valid: true}, {String: "logical", Valid: true}, {String: "testdb", Valid: true}, {String: "f", Valid: true}, {String: "2048", Valid: true}, {String: "", Valid: false},
+					},
+				},
+			},
+			want: map[string]postgresReplicationSlotStat{
+				"/slot1/physical":      {slotname: "slot1", slottype: "physical", active: "t", retainedBytes: 1024, lagSeconds: 1.5, lagAvailable: true},
+				"testdb/slot2/logical": {slotname: "slot2", slottype: "logical", database: "testdb", active: "f", retainedBytes: 2048},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -62,8 +87,10 @@ func Test_selectReplicationSlotQuery(t *testing.T) {
 	}{
 		{version: 90600, want: postgresReplicationSlotQuery96},
 		{version: 90605, want: postgresReplicationSlotQuery96},
-		{version: 100000, want: postgresReplicationSlotQueryLatest},
-		{version: 100005, want: postgresReplicationSlotQueryLatest},
+		{version: 100000, want: postgresReplicationSlotQuery11},
+		{version: 110005, want: postgresReplicationSlotQuery11},
+		{version: 120000, want: postgresReplicationSlotQueryLatest},
+		{version: 160002, want: postgresReplicationSlotQueryLatest},
 	}
 
 	for _, tc := range testcases {
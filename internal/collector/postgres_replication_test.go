@@ -1,9 +1,12 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"github.com/jackc/pgproto3/v2"
 	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -16,7 +19,9 @@ func TestPostgresReplicationCollector_Update(t *testing.T) {
 			"postgres_replication_lag_seconds",
 			"postgres_replication_lag_all_seconds",
 		},
-		optional:  []string{},
+		optional: []string{
+			"postgres_recovery_lag_seconds",
+		},
 		collector: NewPostgresReplicationCollector,
 		service:   model.ServiceTypePostgresql,
 	}
@@ -86,18 +91,34 @@ func Test_parsePostgresReplicationStats(t *testing.T) {
 
 func Test_selectReplicationQuery(t *testing.T) {
 	var testcases = []struct {
-		version int
-		want    string
+		version  int
+		recovery bool
+		want     string
 	}{
-		{version: 90600, want: postgresReplicationQuery96},
-		{version: 90605, want: postgresReplicationQuery96},
-		{version: 100000, want: postgresReplicationQueryLatest},
-		{version: 100005, want: postgresReplicationQueryLatest},
+		{version: 90600, want: fmt.Sprintf(postgresReplicationQuery96, "pg_current_xlog_location()")},
+		{version: 90605, recovery: true, want: fmt.Sprintf(postgresReplicationQuery96, "pg_last_xlog_replay_location()")},
+		{version: 100000, want: fmt.Sprintf(postgresReplicationQueryLatest, "pg_current_wal_lsn()")},
+		{version: 100005, recovery: true, want: fmt.Sprintf(postgresReplicationQueryLatest, "pg_last_wal_replay_lsn()")},
 	}
 
 	for _, tc := range testcases {
 		t.Run("", func(t *testing.T) {
-			assert.Equal(t, tc.want, selectReplicationQuery(tc.version))
+			got := selectReplicationQuery(tc.version, tc.recovery)
+			assert.Equal(t, tc.want, got)
+			assert.NotContains(t, got, "%")
 		})
 	}
 }
+
+func Test_selectRecoveryLagQuery(t *testing.T) {
+	assert.Equal(t, postgresRecoveryLagQuery96, selectRecoveryLagQuery(90600))
+	assert.Equal(t, postgresRecoveryLagQueryLatest, selectRecoveryLagQuery(100000))
+}
+
+func Test_isInRecovery(t *testing.T) {
+	conn := store.NewTest(t)
+	defer conn.Close()
+
+	_, err := isInRecovery(context.Background(), conn)
+	assert.NoError(t, err)
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
-	"golang.org/x/net/context"
+	"context"
 	"strings"
 )
 
@@ -18,6 +18,8 @@ type postgresSchemaCollector struct {
 	nonidxfkey   typedDesc
 	redundantidx typedDesc
 	sequences    typedDesc
+	seqLastValue typedDesc
+	seqMaxValue  typedDesc
 	difftypefkey typedDesc
 }
 
@@ -61,6 +63,18 @@ func NewPostgresSchemasCollector(constLabels labels, settings model.CollectorSet
 			[]string{"database", "schema", "sequence"}, constLabels,
 			settings.Filters,
 		),
+		seqLastValue: newBuiltinTypedDesc(
+			descOpts{"postgres", "schema", "sequence_last_value", "The last value of the sequence.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "sequence"}, constLabels,
+			settings.Filters,
+		),
+		seqMaxValue: newBuiltinTypedDesc(
+			descOpts{"postgres", "schema", "sequence_max_value", "Effective maximum value of the sequence accordingly to its MAXVALUE and type of the owning column.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "sequence"}, constLabels,
+			settings.Filters,
+		),
 		difftypefkey: newBuiltinTypedDesc(
 			descOpts{"postgres", "schema", "mistyped_fkeys", "Number of foreign key constraints with different data type.", 0},
 			prometheus.GaugeValue,
@@ -71,14 +85,15 @@ func NewPostgresSchemasCollector(constLabels labels, settings model.CollectorSet
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresSchemaCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *postgresSchemaCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
 
-	databases, err := listDatabases(conn)
+	databases, err := listDatabases(ctx, conn)
 	if err != nil {
+		conn.Close()
 		return err
 	}
 
@@ -97,16 +112,16 @@ func (c *postgresSchemaCollector) Update(config Config, ch chan<- prometheus.Met
 		}
 
 		pgconfig.Database = d
-		conn, err := store.NewWithConfig(pgconfig)
+		conn, err := store.NewWithConfigContext(ctx, pgconfig)
 		if err != nil {
 			return err
 		}
 
 		// 1. get system catalog size in bytes.
-		collectSystemCatalogSize(conn, ch, c.syscatalog)
+		collectSystemCatalogSize(ctx, conn, ch, c.syscatalog)
 
 		// 2. collect metrics related to tables with no primary/unique key constraints.
-		collectSchemaNonPKTables(conn, ch, c.nonpktables)
+		collectSchemaNonPKTables(ctx, conn, ch, c.nonpktables)
 
 		// Functions below uses queries with casting to regnamespace data type, which is introduced in Postgres 9.5.
 		if config.serverVersionNum < PostgresV95 {
@@ -116,16 +131,16 @@ func (c *postgresSchemaCollector) Update(config Config, ch chan<- prometheus.Met
 		}
 
 		// 3. collect metrics related to invalid indexes.
-		collectSchemaInvalidIndexes(conn, ch, c.invalididx)
+		collectSchemaInvalidIndexes(ctx, conn, ch, c.invalididx)
 
 		// 4. collect metrics related to non indexed foreign key constraints.
-		collectSchemaNonIndexedFK(conn, ch, c.nonidxfkey)
+		collectSchemaNonIndexedFK(ctx, conn, ch, c.nonidxfkey)
 
 		// 5. collect metric related to redundant indexes.
-		collectSchemaRedundantIndexes(conn, ch, c.redundantidx)
+		collectSchemaRedundantIndexes(ctx, conn, ch, c.redundantidx)
 
 		// 6. collect metrics related to foreign key constraints with different data types.
-		collectSchemaFKDatatypeMismatch(conn, ch, c.difftypefkey)
+		collectSchemaFKDatatypeMismatch(ctx, conn, ch, c.difftypefkey)
 
 		// Function below uses queries pg_sequences which is introduced in Postgres 10.
 		if config.serverVersionNum < PostgresV10 {
@@ -135,7 +150,7 @@ func (c *postgresSchemaCollector) Update(config Config, ch chan<- prometheus.Met
 		}
 
 		// 7. collect metrics related to sequences (available since Postgres 10).
-		collectSchemaSequences(conn, ch, c.sequences)
+		collectSchemaSequences(ctx, conn, ch, c.sequences, c.seqLastValue, c.seqMaxValue)
 
 		conn.Close()
 	}
@@ -144,9 +159,9 @@ func (c *postgresSchemaCollector) Update(config Config, ch chan<- prometheus.Met
 }
 
 // collectSystemCatalogSize collects system catalog size metrics.
-func collectSystemCatalogSize(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
+func collectSystemCatalogSize(ctx context.Context, conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
 	datname := conn.Conn().Config().Database
-	size, err := getSystemCatalogSize(conn)
+	size, err := getSystemCatalogSize(ctx, conn)
 	if err != nil {
 		log.Errorf("get system catalog size of database %s failed: %s; skip", datname, err)
 		return
@@ -158,19 +173,19 @@ func collectSystemCatalogSize(conn *store.DB, ch chan<- prometheus.Metric, desc
 }
 
 // getSystemCatalogSize returns size of system catalog in bytes.
-func getSystemCatalogSize(conn *store.DB) (float64, error) {
+func getSystemCatalogSize(ctx context.Context, conn *store.DB) (float64, error) {
 	var query = `SELECT sum(pg_total_relation_size(relname::regclass)) AS bytes FROM pg_stat_sys_tables WHERE schemaname = 'pg_catalog'`
 	var size int64 = 0
-	if err := conn.Conn().QueryRow(context.Background(), query).Scan(&size); err != nil {
+	if err := conn.Conn().QueryRow(ctx, query).Scan(&size); err != nil {
 		return 0, err
 	}
 	return float64(size), nil
 }
 
 // collectSchemaNonPKTables collects metrics related to non-PK tables.
-func collectSchemaNonPKTables(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
+func collectSchemaNonPKTables(ctx context.Context, conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
 	datname := conn.Conn().Config().Database
-	tables, err := getSchemaNonPKTables(conn)
+	tables, err := getSchemaNonPKTables(ctx, conn)
 	if err != nil {
 		log.Errorf("collect non-pk tables in database %s failed: %s; skip", datname, err)
 		return
@@ -188,13 +203,13 @@ func collectSchemaNonPKTables(conn *store.DB, ch chan<- prometheus.Metric, desc
 }
 
 // getSchemaNonPKTables searches tables with no PRIMARY or UNIQUE keys in the database and return its names.
-func getSchemaNonPKTables(conn *store.DB) ([]string, error) {
+func getSchemaNonPKTables(ctx context.Context, conn *store.DB) ([]string, error) {
 	var query = "SELECT n.nspname AS schema, c.relname AS table " +
 		"FROM pg_class c JOIN pg_namespace n ON c.relnamespace = n.oid " +
 		"WHERE NOT EXISTS (SELECT 1 FROM pg_index i WHERE c.oid = i.indrelid AND (i.indisprimary OR i.indisunique)) " +
 		"AND c.relkind = 'r' AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')"
 
-	rows, err := conn.Conn().Query(context.Background(), query)
+	rows, err := conn.Conn().Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -219,9 +234,9 @@ func getSchemaNonPKTables(conn *store.DB) ([]string, error) {
 }
 
 // collectSchemaInvalidIndexes collects metrics related to invalid indexes.
-func collectSchemaInvalidIndexes(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
+func collectSchemaInvalidIndexes(ctx context.Context, conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
 	database := conn.Conn().Config().Database
-	stats, err := getSchemaInvalidIndexes(conn)
+	stats, err := getSchemaInvalidIndexes(ctx, conn)
 	if err != nil {
 		log.Errorf("get invalid indexes stats of database %s failed: %s; skip", database, err)
 		return
@@ -245,11 +260,11 @@ func collectSchemaInvalidIndexes(conn *store.DB, ch chan<- prometheus.Metric, de
 }
 
 // getSchemaInvalidIndexes searches invalid indexes in the database and return its names if such indexes have been found.
-func getSchemaInvalidIndexes(conn *store.DB) (map[string]postgresGenericStat, error) {
+func getSchemaInvalidIndexes(ctx context.Context, conn *store.DB) (map[string]postgresGenericStat, error) {
 	var query = "SELECT c1.relnamespace::regnamespace::text AS schema, c2.relname AS table, c1.relname AS index, " +
 		"pg_relation_size(c1.relname::regclass) AS bytes " +
 		"FROM pg_index i JOIN pg_class c1 ON i.indexrelid = c1.oid JOIN pg_class c2 ON i.indrelid = c2.oid WHERE NOT i.indisvalid"
-	res, err := conn.Query(query)
+	res, err := conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -258,9 +273,9 @@ func getSchemaInvalidIndexes(conn *store.DB) (map[string]postgresGenericStat, er
 }
 
 // collectSchemaNonIndexedFK collects metrics related to non indexed foreign key constraints.
-func collectSchemaNonIndexedFK(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
+func collectSchemaNonIndexedFK(ctx context.Context, conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
 	database := conn.Conn().Config().Database
-	stats, err := getSchemaNonIndexedFK(conn)
+	stats, err := getSchemaNonIndexedFK(ctx, conn)
 	if err != nil {
 		log.Errorf("get non-indexed fkeys stats of database %s failed: %s; skip", database, err)
 		return
@@ -285,7 +300,7 @@ func collectSchemaNonIndexedFK(conn *store.DB, ch chan<- prometheus.Metric, desc
 }
 
 // getSchemaNonIndexedFK searches non indexes foreign key constraints and return its names.
-func getSchemaNonIndexedFK(conn *store.DB) (map[string]postgresGenericStat, error) {
+func getSchemaNonIndexedFK(ctx context.Context, conn *store.DB) (map[string]postgresGenericStat, error) {
 	var query = "SELECT c.connamespace::regnamespace::text AS schema, s.relname AS table, " +
 		"string_agg(a.attname, ',' ORDER BY x.n) AS columns, c.conname AS constraint, " +
 		"c.confrelid::regclass::text AS referenced " +
@@ -296,7 +311,7 @@ func getSchemaNonIndexedFK(conn *store.DB) (map[string]postgresGenericStat, erro
 		"AND c.contype = 'f' " +
 		"GROUP BY c.connamespace,s.relname,c.conname,c.confrelid"
 
-	res, err := conn.Query(query)
+	res, err := conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -305,9 +320,9 @@ func getSchemaNonIndexedFK(conn *store.DB) (map[string]postgresGenericStat, erro
 }
 
 // collectSchemaRedundantIndexes collects metrics related to invalid indexes
-func collectSchemaRedundantIndexes(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
+func collectSchemaRedundantIndexes(ctx context.Context, conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
 	database := conn.Conn().Config().Database
-	stats, err := getSchemaRedundantIndexes(conn)
+	stats, err := getSchemaRedundantIndexes(ctx, conn)
 	if err != nil {
 		log.Errorf("get redundant indexes stats of database %s failed: %s; skip", database, err)
 		return
@@ -333,7 +348,7 @@ func collectSchemaRedundantIndexes(conn *store.DB, ch chan<- prometheus.Metric,
 }
 
 // getSchemaRedundantIndexes searches redundant indexes and returns its sizes
-func getSchemaRedundantIndexes(conn *store.DB) (map[string]postgresGenericStat, error) {
+func getSchemaRedundantIndexes(ctx context.Context, conn *store.DB) (map[string]postgresGenericStat, error) {
 	var query = "WITH index_data AS (SELECT *, string_to_array(indkey::text,' ') AS key_array, array_length(string_to_array(indkey::text,' '),1) AS nkeys FROM pg_index) " +
 		"SELECT c1.relnamespace::regnamespace::text AS schema, c1.relname AS table, c2.relname AS index, " +
 		"pg_get_indexdef(i1.indexrelid) AS indexdef, pg_get_indexdef(i2.indexrelid) AS redundantdef, " +
@@ -347,7 +362,7 @@ func getSchemaRedundantIndexes(conn *store.DB) (map[string]postgresGenericStat,
 		"OR (NOT i1.indisunique AND NOT i2.indisunique AND (i1.indexrelid>i2.indexrelid)) " +
 		"OR (i1.indisunique AND NOT i2.indisunique)))) AND i1.key_array[1:i2.nkeys]=i2.key_array"
 
-	res, err := conn.Query(query)
+	res, err := conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -356,9 +371,9 @@ func getSchemaRedundantIndexes(conn *store.DB) (map[string]postgresGenericStat,
 }
 
 // collectSchemaSequences collects metrics related to sequences attached to poor-typed columns.
-func collectSchemaSequences(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
+func collectSchemaSequences(ctx context.Context, conn *store.DB, ch chan<- prometheus.Metric, ratioDesc, lastValueDesc, maxValueDesc typedDesc) {
 	database := conn.Conn().Config().Database
-	stats, err := getSchemaSequences(conn)
+	stats, err := getSchemaSequences(ctx, conn)
 	if err != nil {
 		log.Errorf("get sequences stats of database %s failed: %s; skip", database, err)
 		return
@@ -368,7 +383,6 @@ func collectSchemaSequences(conn *store.DB, ch chan<- prometheus.Metric, desc ty
 		var (
 			schema   = s.labels["schema"]
 			sequence = s.labels["sequence"]
-			value    = s.values["ratio"]
 		)
 
 		if schema == "" || sequence == "" {
@@ -376,15 +390,31 @@ func collectSchemaSequences(conn *store.DB, ch chan<- prometheus.Metric, desc ty
 			continue
 		}
 
-		ch <- desc.newConstMetric(value, database, schema, sequence)
+		ch <- ratioDesc.newConstMetric(s.values["ratio"], database, schema, sequence)
+		ch <- lastValueDesc.newConstMetric(s.values["last_value"], database, schema, sequence)
+		ch <- maxValueDesc.newConstMetric(s.values["max_value"], database, schema, sequence)
 	}
 }
 
-// getSchemaSequences searches sequences attached to the poor-typed columns with risk of exhaustion.
-func getSchemaSequences(conn *store.DB) (map[string]postgresGenericStat, error) {
-	var query = `SELECT schemaname AS schema, sequencename AS sequence, coalesce(last_value, 0) / max_value::float AS ratio FROM pg_sequences`
-
-	res, err := conn.Query(query)
+// getSchemaSequences searches sequences attached to the poor-typed columns with risk of exhaustion. Effective max
+// value of the sequence is the least of its MAXVALUE and max value of the owning column's type (e.g. bigint sequence
+// owned by integer column is exhausted when reaches integer max value). Sequences which are not readable by the
+// current user are skipped.
+func getSchemaSequences(ctx context.Context, conn *store.DB) (map[string]postgresGenericStat, error) {
+	var query = "WITH owned AS (" +
+		"SELECT d.objid, min(CASE a.atttypid WHEN 'int2'::regtype THEN 32767 WHEN 'int4'::regtype THEN 2147483647 " +
+		"ELSE 9223372036854775807 END) AS max_value " +
+		"FROM pg_depend d JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid " +
+		"WHERE d.classid = 'pg_class'::regclass AND d.refclassid = 'pg_class'::regclass AND d.deptype IN ('a', 'i') " +
+		"GROUP BY d.objid), " +
+		"seqs AS (" +
+		"SELECT schemaname, sequencename, coalesce(last_value, 0) AS last_value, " +
+		"least(s.max_value, coalesce(o.max_value, s.max_value)) AS max_value " +
+		"FROM pg_sequences s LEFT JOIN owned o ON o.objid = format('%I.%I', s.schemaname, s.sequencename)::regclass " +
+		"WHERE has_sequence_privilege(format('%I.%I', s.schemaname, s.sequencename), 'SELECT,USAGE')) " +
+		"SELECT schemaname AS schema, sequencename AS sequence, last_value, max_value, last_value / max_value::float AS ratio FROM seqs"
+
+	res, err := conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -393,9 +423,9 @@ func getSchemaSequences(conn *store.DB) (map[string]postgresGenericStat, error)
 }
 
 // collectSchemaFKDatatypeMismatch collects metrics related to foreign key constraints with different data types.
-func collectSchemaFKDatatypeMismatch(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
+func collectSchemaFKDatatypeMismatch(ctx context.Context, conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
 	database := conn.Conn().Config().Database
-	stats, err := getSchemaFKDatatypeMismatch(conn)
+	stats, err := getSchemaFKDatatypeMismatch(ctx, conn)
 	if err != nil {
 		log.Errorf("get foreign keys data types stats of database %s failed: %s; skip", database, err)
 		return
@@ -421,7 +451,7 @@ func collectSchemaFKDatatypeMismatch(conn *store.DB, ch chan<- prometheus.Metric
 }
 
 // getSchemaFKDatatypeMismatch searches foreign key constraints with different data types.
-func getSchemaFKDatatypeMismatch(conn *store.DB) (map[string]postgresGenericStat, error) {
+func getSchemaFKDatatypeMismatch(ctx context.Context, conn *store.DB) (map[string]postgresGenericStat, error) {
 	var query = "SELECT c1.relnamespace::regnamespace::text AS schema, c1.relname AS table, a1.attname||'::'||t1.typname AS column, " +
 		"c2.relnamespace::regnamespace::text AS refschema, c2.relname AS reftable, a2.attname||'::'||t2.typname AS refcolumn " +
 		"FROM pg_constraint JOIN pg_class c1 ON c1.oid = conrelid JOIN pg_class c2 ON c2.oid = confrelid " +
@@ -431,7 +461,7 @@ func getSchemaFKDatatypeMismatch(conn *store.DB) (map[string]postgresGenericStat
 		"JOIN pg_type t2 ON t2.oid = a2.atttypid " +
 		"WHERE a1.atttypid <> a2.atttypid AND contype = 'f'"
 
-	res, err := conn.Query(query)
+	res, err := conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
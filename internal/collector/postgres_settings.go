@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -14,7 +15,9 @@ import (
 
 // postgresSettingsCollector defines metric descriptors and stats store.
 type postgresSettingsCollector struct {
+	version  typedDesc
 	settings typedDesc
+	pending  typedDesc
 	files    typedDesc
 }
 
@@ -23,12 +26,24 @@ type postgresSettingsCollector struct {
 // and https://www.postgresql.org/docs/current/view-pg-file-settings.html
 func NewPostgresSettingsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
 	return &postgresSettingsCollector{
+		version: newBuiltinTypedDesc(
+			descOpts{"postgres", "", "version_info", "Labeled information about Postgres version.", 0},
+			prometheus.GaugeValue,
+			[]string{"version", "version_num"}, constLabels,
+			settings.Filters,
+		),
 		settings: newBuiltinTypedDesc(
 			descOpts{"postgres", "service", "settings_info", "Labeled information about Postgres configuration settings.", 0},
 			prometheus.GaugeValue,
 			[]string{"name", "setting", "unit", "vartype", "source"}, constLabels,
 			settings.Filters,
 		),
+		pending: newBuiltinTypedDesc(
+			descOpts{"postgres", "settings", "pending_restart", "Configuration settings changed in configuration files but not applied until server restart.", 0},
+			prometheus.GaugeValue,
+			[]string{"name"}, constLabels,
+			settings.Filters,
+		),
 		files: newBuiltinTypedDesc(
 			descOpts{"postgres", "service", "files_info", "Labeled information about Postgres system files.", 0},
 			prometheus.GaugeValue,
@@ -39,17 +54,22 @@ func NewPostgresSettingsCollector(constLabels labels, settings model.CollectorSe
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresSettingsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *postgresSettingsCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
+	// Server version is detected once per scrape, when service config is updated.
+	if config.serverVersionNum > 0 {
+		ch <- c.version.newConstMetric(1, postgresVersionString(config.serverVersionNum), strconv.Itoa(config.serverVersionNum))
+	}
+
 	// For complete list of displayable names of GUC's sources types check guc.c (see GucSource_Names[]).
 	query := "SELECT name, setting, unit, vartype FROM pg_show_all_settings() " +
 		"WHERE source IN ('default','configuration file','override','environment variable','command line','global')"
-	res, err := conn.Query(query)
+	res, err := conn.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -60,6 +80,15 @@ func (c *postgresSettingsCollector) Update(config Config, ch chan<- prometheus.M
 		ch <- c.settings.newConstMetric(s.value, s.name, s.setting, s.unit, s.vartype, "main")
 	}
 
+	res, err = conn.QueryContext(ctx, "SELECT name FROM pg_settings WHERE pending_restart")
+	if err != nil {
+		return err
+	}
+
+	for _, row := range res.Rows {
+		ch <- c.pending.newConstMetric(1, row[0].String)
+	}
+
 	// Collecting metrics about filesystem attributes of configuration files, requires
 	// direct access to filesystem, which is impossible for remote services. If service
 	// is remote, stop here and return.
@@ -69,7 +98,7 @@ func (c *postgresSettingsCollector) Update(config Config, ch chan<- prometheus.M
 	}
 
 	query = `SELECT name, setting FROM pg_show_all_settings() WHERE name IN ('config_file','hba_file','ident_file','data_directory')`
-	res, err = conn.Query(query)
+	res, err = conn.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
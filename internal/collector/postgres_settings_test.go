@@ -12,9 +12,13 @@ import (
 func TestPostgresSettingsCollector_Update(t *testing.T) {
 	var input = pipelineInput{
 		required: []string{
+			"postgres_version_info",
 			"postgres_service_settings_info",
 			"postgres_service_files_info",
 		},
+		optional: []string{
+			"postgres_settings_pending_restart",
+		},
 		collector: NewPostgresSettingsCollector,
 		service:   model.ServiceTypePostgresql,
 	}
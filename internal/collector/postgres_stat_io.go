@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"context"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	postgresStatIOQuery = "SELECT backend_type, object, context, " +
+		"reads, read_time, writes, write_time, writebacks, writeback_time, " +
+		"extends, extend_time, hits, evictions, fsyncs, fsync_time " +
+		"FROM pg_stat_io"
+)
+
+type postgresStatIOCollector struct {
+	reads      typedDesc
+	writes     typedDesc
+	writebacks typedDesc
+	extends    typedDesc
+	hits       typedDesc
+	evictions  typedDesc
+	fsyncs     typedDesc
+	opTime     typedDesc
+	labelNames []string
+}
+
+// NewPostgresStatIOCollector returns a new Collector exposing postgres IO stats.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-IO-VIEW
+func NewPostgresStatIOCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	var labels = []string{"backend_type", "object", "context"}
+
+	return &postgresStatIOCollector{
+		labelNames: labels,
+		reads: newBuiltinTypedDesc(
+			descOpts{"postgres", "io", "reads_total", "Total number of read operations.", 0},
+			prometheus.CounterValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		writes: newBuiltinTypedDesc(
+			descOpts{"postgres", "io", "writes_total", "Total number of write operations.", 0},
+			prometheus.CounterValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		writebacks: newBuiltinTypedDesc(
+			descOpts{"postgres", "io", "writebacks_total", "Total number of requests to the kernel to write data to permanent storage.", 0},
+			prometheus.CounterValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		extends: newBuiltinTypedDesc(
+			descOpts{"postgres", "io", "extends_total", "Total number of relation extend operations.", 0},
+			prometheus.CounterValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		hits: newBuiltinTypedDesc(
+			descOpts{"postgres", "io", "hits_total", "Total number of times a desired block was found in a shared buffer.", 0},
+			prometheus.CounterValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		evictions: newBuiltinTypedDesc(
+			descOpts{"postgres", "io", "evictions_total", "Total number of times a block has been written out from a shared or local buffer in order to make it available for another use.", 0},
+			prometheus.CounterValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		fsyncs: newBuiltinTypedDesc(
+			descOpts{"postgres", "io", "fsyncs_total", "Total number of fsync calls.", 0},
+			prometheus.CounterValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		opTime: newBuiltinTypedDesc(
+			descOpts{"postgres", "io", "op_time_seconds_total", "Total time spent in each type of IO operations, in seconds.", .001},
+			prometheus.CounterValue,
+			[]string{"backend_type", "object", "context", "op"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresStatIOCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	if config.serverVersionNum < PostgresV16 {
+		log.Debugln("[postgres stat io collector]: some system views are not available, required Postgres 16 or newer")
+		return nil
+	}
+
+	conn, err := store.NewContext(ctx, config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.QueryContext(ctx, postgresStatIOQuery)
+	if err != nil {
+		return err
+	}
+
+	stats := parsePostgresGenericStats(res, c.labelNames)
+
+	for _, stat := range stats {
+		backendType, object, ioContext := stat.labels["backend_type"], stat.labels["object"], stat.labels["context"]
+
+		// Operations which are not applicable for exact backend type, object and context have NULL values, skip them.
+		for name, desc := range map[string]typedDesc{
+			"reads":      c.reads,
+			"writes":     c.writes,
+			"writebacks": c.writebacks,
+			"extends":    c.extends,
+			"hits":       c.hits,
+			"evictions":  c.evictions,
+			"fsyncs":     c.fsyncs,
+		} {
+			if v, ok := stat.values[name]; ok {
+				ch <- desc.newConstMetric(v, backendType, object, ioContext)
+			}
+		}
+
+		for _, op := range []string{"read", "write", "writeback", "extend", "fsync"} {
+			if v, ok := stat.values[op+"_time"]; ok {
+				ch <- c.opTime.newConstMetric(v, backendType, object, ioContext, op)
+			}
+		}
+	}
+
+	return nil
+}
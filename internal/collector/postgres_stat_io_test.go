@@ -0,0 +1,25 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"testing"
+)
+
+func TestPostgresStatIOCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_io_reads_total",
+			"postgres_io_writes_total",
+			"postgres_io_writebacks_total",
+			"postgres_io_extends_total",
+			"postgres_io_hits_total",
+			"postgres_io_evictions_total",
+			"postgres_io_fsyncs_total",
+			"postgres_io_op_time_seconds_total",
+		},
+		collector: NewPostgresStatIOCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
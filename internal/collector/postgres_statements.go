@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"github.com/jackc/pgx/v4"
 	"github.com/cherts/pgscv/internal/log"
@@ -33,10 +34,14 @@ const (
 		"nullif(p.temp_blks_read, 0) AS temp_blks_read, nullif(p.temp_blks_written, 0) AS temp_blks_written, " +
 		"nullif(p.wal_records, 0) AS wal_records, nullif(p.wal_fpi, 0) AS wal_fpi, nullif(p.wal_bytes, 0) AS wal_bytes " +
 		"FROM %s.pg_stat_statements p JOIN pg_database d ON d.oid=p.dbid"
+
+	// postgresStatementsTopQuery defines suffix for statements query which limits result by top N the most time-consuming statements.
+	postgresStatementsTopQuery = " ORDER BY %s DESC LIMIT %d"
 )
 
 // postgresStatementsCollector ...
 type postgresStatementsCollector struct {
+	topN          int
 	query         typedDesc
 	calls         typedDesc
 	rows          typedDesc
@@ -60,7 +65,17 @@ type postgresStatementsCollector struct {
 // NewPostgresStatementsCollector returns a new Collector exposing postgres statements stats.
 // For details see https://www.postgresql.org/docs/current/pgstatstatements.html
 func NewPostgresStatementsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	topN, err := settings.Options.Int("top_n", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if topN < 0 {
+		return nil, fmt.Errorf("invalid value '%d' of option 'top_n': must be greater or equal to zero", topN)
+	}
+
 	return &postgresStatementsCollector{
+		topN: topN,
 		query: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "query_info", "Labeled info about statements has been executed.", 0},
 			prometheus.GaugeValue,
@@ -173,7 +188,7 @@ func NewPostgresStatementsCollector(constLabels labels, settings model.Collector
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresStatementsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+func (c *postgresStatementsCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
 	// nothing to do, pg_stat_statements not found in shared_preload_libraries
 	if !config.pgStatStatements {
 		return nil
@@ -191,7 +206,7 @@ func (c *postgresStatementsCollector) Update(config Config, ch chan<- prometheus
 
 	pgconfig.Database = config.pgStatStatementsDatabase
 
-	conn, err := store.NewWithConfig(pgconfig)
+	conn, err := store.NewWithConfigContext(ctx, pgconfig)
 	if err != nil {
 		return err
 	}
@@ -199,7 +214,7 @@ func (c *postgresStatementsCollector) Update(config Config, ch chan<- prometheus
 	defer conn.Close()
 
 	// get pg_stat_statements stats
-	res, err := conn.Query(selectStatementsQuery(config.serverVersionNum, config.pgStatStatementsSchema))
+	res, err := conn.QueryContext(ctx, selectStatementsQuery(config.serverVersionNum, config.pgStatStatementsSchema, c.topN))
 	if err != nil {
 		return err
 	}
@@ -222,11 +237,14 @@ func (c *postgresStatementsCollector) Update(config Config, ch chan<- prometheus
 
 		ch <- c.query.newConstMetric(1, stat.user, stat.database, stat.queryid, query)
 
-		ch <- c.calls.newConstMetric(stat.calls, stat.user, stat.database, stat.queryid)
+		// Attach queryid as exemplar to calls and time counters, it allows correlating slow queries with traces.
+		exemplar := prometheus.Labels{"queryid": stat.queryid}
+
+		ch <- c.calls.newConstMetricWithExemplar(stat.calls, exemplar, stat.user, stat.database, stat.queryid)
 		ch <- c.rows.newConstMetric(stat.rows, stat.user, stat.database, stat.queryid)
 
 		// total = planning + execution; execution already includes io time.
-		ch <- c.allTimes.newConstMetric(stat.totalPlanTime+stat.totalExecTime, stat.user, stat.database, stat.queryid)
+		ch <- c.allTimes.newConstMetricWithExemplar(stat.totalPlanTime+stat.totalExecTime, exemplar, stat.user, stat.database, stat.queryid)
 		ch <- c.times.newConstMetric(stat.totalPlanTime, stat.user, stat.database, stat.queryid, "planning")
 
 		// execution time = execution - io times.
@@ -417,12 +435,20 @@ func parsePostgresStatementsStats(r *model.PGResult, labelNames []string) map[st
 	return stats
 }
 
-// selectStatementsQuery returns suitable statements query depending on passed version.
-func selectStatementsQuery(version int, schema string) string {
+// selectStatementsQuery returns suitable statements query depending on passed version. When topN is
+// greater than zero, the query is limited by top N statements with the highest total time.
+func selectStatementsQuery(version int, schema string, topN int) string {
+	var query, totalTime string
 	switch {
 	case version < PostgresV13:
-		return fmt.Sprintf(postgresStatementsQuery12, schema)
+		query, totalTime = fmt.Sprintf(postgresStatementsQuery12, schema), "p.total_time"
 	default:
-		return fmt.Sprintf(postgresStatementsQueryLatest, schema)
+		query, totalTime = fmt.Sprintf(postgresStatementsQueryLatest, schema), "p.total_exec_time + p.total_plan_time"
 	}
+
+	if topN > 0 {
+		query += fmt.Sprintf(postgresStatementsTopQuery, totalTime, topN)
+	}
+
+	return query
 }
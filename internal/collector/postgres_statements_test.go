@@ -40,6 +40,17 @@ func TestPostgresStatementsCollector_Update(t *testing.T) {
 	pipeline(t, input)
 }
 
+func TestNewPostgresStatementsCollector(t *testing.T) {
+	c, err := NewPostgresStatementsCollector(labels{}, model.CollectorSettings{Options: model.CollectorOptions{"top_n": "100"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 100, c.(*postgresStatementsCollector).topN)
+
+	for _, v := range []string{"invalid", "-1"} {
+		_, err = NewPostgresStatementsCollector(labels{}, model.CollectorSettings{Options: model.CollectorOptions{"top_n": v}})
+		assert.Error(t, err)
+	}
+}
+
 func Test_parsePostgresStatementsStats(t *testing.T) {
 	var testCases = []struct {
 		name string
@@ -164,13 +175,16 @@ func Test_parsePostgresStatementsStats(t *testing.T) {
 func Test_selectStatementsQuery(t *testing.T) {
 	testcases := []struct {
 		version int
+		topN    int
 		want    string
 	}{
 		{version: PostgresV12, want: fmt.Sprintf(postgresStatementsQuery12, "example")},
 		{version: PostgresV13, want: fmt.Sprintf(postgresStatementsQueryLatest, "example")},
+		{version: PostgresV12, topN: 100, want: fmt.Sprintf(postgresStatementsQuery12, "example") + " ORDER BY p.total_time DESC LIMIT 100"},
+		{version: PostgresV13, topN: 100, want: fmt.Sprintf(postgresStatementsQueryLatest, "example") + " ORDER BY p.total_exec_time + p.total_plan_time DESC LIMIT 100"},
 	}
 
 	for _, tc := range testcases {
-		assert.Equal(t, tc.want, selectStatementsQuery(tc.version, "example"))
+		assert.Equal(t, tc.want, selectStatementsQuery(tc.version, "example", tc.topN))
 	}
 }
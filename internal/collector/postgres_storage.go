@@ -101,7 +101,7 @@ func NewPostgresStorageCollector(constLabels labels, settings model.CollectorSet
 }
 
 // Update method collects statistics, parse it and produces metrics.
-func (c *postgresStorageCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+func (c *postgresStorageCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
 	// Following directory listing functions are available since:
 	// - pg_ls_dir(), pg_ls_waldir() since Postgres 10
 	// - pg_ls_tmpdir() since Postgres 12
@@ -110,7 +110,11 @@ func (c *postgresStorageCollector) Update(config Config, ch chan<- prometheus.Me
 		return nil
 	}
 
-	conn, err := store.New(config.ConnString)
+	if !config.monitorPrivileges() {
+		return fmt.Errorf("%w: directory listing functions require superuser or pg_monitor role", errInsufficientPrivilege)
+	}
+
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
@@ -118,7 +122,7 @@ func (c *postgresStorageCollector) Update(config Config, ch chan<- prometheus.Me
 
 	// Collecting in-flight temp only since Postgres 12.
 	if config.serverVersionNum >= PostgresV12 {
-		res, err := conn.Query(postgresTempFilesInflightQuery)
+		res, err := conn.QueryContext(ctx, postgresTempFilesInflightQuery)
 		if err != nil {
 			log.Warnf("get in-flight temp files failed: %s; skip", err)
 		}
@@ -141,7 +145,7 @@ func (c *postgresStorageCollector) Update(config Config, ch chan<- prometheus.Me
 	}
 
 	// Collecting other server-directories stats (DATADIR and tablespaces, WALDIR, LOGDIR, TEMPDIR).
-	dirstats, tblspcStats, err := newPostgresDirStat(conn, config.dataDirectory, config.loggingCollector, config.serverVersionNum)
+	dirstats, tblspcStats, err := newPostgresDirStat(ctx, conn, config.dataDirectory, config.loggingCollector, config.serverVersionNum)
 	if err != nil {
 		return err
 	}
@@ -266,7 +270,7 @@ type postgresDirStat struct {
 }
 
 // newPostgresDirStat returns sizes of Postgres server directories.
-func newPostgresDirStat(conn *store.DB, datadir string, logcollector bool, version int) (*postgresDirStat, []tablespaceStat, error) {
+func newPostgresDirStat(ctx context.Context, conn *store.DB, datadir string, logcollector bool, version int) (*postgresDirStat, []tablespaceStat, error) {
 	// Get directories mountpoints.
 	mounts, err := getMountpoints()
 	if err != nil {
@@ -280,25 +284,25 @@ func newPostgresDirStat(conn *store.DB, datadir string, logcollector bool, versi
 	}
 
 	// Get tablespaces stats.
-	tblspcStat, err := getTablespacesStat(conn, mounts)
+	tblspcStat, err := getTablespacesStat(ctx, conn, mounts)
 	if err != nil {
 		log.Errorln(err)
 	}
 
 	// Get WALDIR properties.
-	waldirDevice, waldirPath, waldirMountpoint, waldirSize, waldirFilesCount, err := getWaldirStat(conn, mounts)
+	waldirDevice, waldirPath, waldirMountpoint, waldirSize, waldirFilesCount, err := getWaldirStat(ctx, conn, mounts)
 	if err != nil {
 		log.Errorln(err)
 	}
 
 	// Get LOGDIR properties.
-	logdirDevice, logdirPath, logdirMountpoint, logdirSize, logdirFilesCount, err := getLogdirStat(conn, logcollector, datadir, mounts)
+	logdirDevice, logdirPath, logdirMountpoint, logdirSize, logdirFilesCount, err := getLogdirStat(ctx, conn, logcollector, datadir, mounts)
 	if err != nil {
 		log.Errorln(err)
 	}
 
 	// Get temp files and directories properties.
-	tmpfilesSize, tmpfilesCount, err := getTempfilesStat(conn, version)
+	tmpfilesSize, tmpfilesCount, err := getTempfilesStat(ctx, conn, version)
 	if err != nil {
 		log.Errorln(err)
 	}
@@ -352,9 +356,9 @@ type tablespaceStat struct {
 }
 
 // getTablespacesStat returns filesystem info related to WALDIR.
-func getTablespacesStat(conn *store.DB, mounts []mount) ([]tablespaceStat, error) {
+func getTablespacesStat(ctx context.Context, conn *store.DB, mounts []mount) ([]tablespaceStat, error) {
 	rows, err := conn.Conn().
-		Query(context.Background(), "select spcname, coalesce(nullif(pg_tablespace_location(oid), ''), current_setting('data_directory')) as path, pg_tablespace_size(oid) as size from pg_tablespace")
+		Query(ctx, "select spcname, coalesce(nullif(pg_tablespace_location(oid), ''), current_setting('data_directory')) as path, pg_tablespace_size(oid) as size from pg_tablespace")
 	if err != nil {
 		return nil, fmt.Errorf("get tablespaces stats failed: %s", err)
 	}
@@ -390,11 +394,11 @@ func getTablespacesStat(conn *store.DB, mounts []mount) ([]tablespaceStat, error
 }
 
 // getWaldirStat returns filesystem info related to WALDIR.
-func getWaldirStat(conn *store.DB, mounts []mount) (string, string, string, int64, int64, error) {
+func getWaldirStat(ctx context.Context, conn *store.DB, mounts []mount) (string, string, string, int64, int64, error) {
 	var path string
 	var size, count int64
 	err := conn.Conn().
-		QueryRow(context.Background(), "SELECT current_setting('data_directory')||'/pg_wal' AS path, sum(size) AS bytes, count(name) AS count FROM pg_ls_waldir()").
+		QueryRow(ctx, "SELECT current_setting('data_directory')||'/pg_wal' AS path, sum(size) AS bytes, count(name) AS count FROM pg_ls_waldir()").
 		Scan(&path, &size, &count)
 	if err != nil {
 		return "", "", "", 0, 0, fmt.Errorf("get WAL directory size failed: %s", err)
@@ -411,7 +415,7 @@ func getWaldirStat(conn *store.DB, mounts []mount) (string, string, string, int6
 }
 
 // getLogdirStat returns filesystem info related to LOGDIR.
-func getLogdirStat(conn *store.DB, logcollector bool, datadir string, mounts []mount) (string, string, string, int64, int64, error) {
+func getLogdirStat(ctx context.Context, conn *store.DB, logcollector bool, datadir string, mounts []mount) (string, string, string, int64, int64, error) {
 	if !logcollector {
 		// Disabled logging_collector means all logs are written to stdout.
 		// There is no reliable way to understand file location of stdout (it can be a symlink from /proc/pid/fd/1 -> somewhere)
@@ -421,7 +425,7 @@ func getLogdirStat(conn *store.DB, logcollector bool, datadir string, mounts []m
 	var size, count int64
 	var path string
 	err := conn.Conn().
-		QueryRow(context.Background(), "SELECT current_setting('log_directory') AS path, coalesce(sum(size), 0) AS bytes, coalesce(count(name), 0) AS count FROM pg_ls_logdir()").
+		QueryRow(ctx, "SELECT current_setting('log_directory') AS path, coalesce(sum(size), 0) AS bytes, coalesce(count(name), 0) AS count FROM pg_ls_logdir()").
 		Scan(&path, &size, &count)
 	if err != nil {
 		return "", "", "", 0, 0, fmt.Errorf("get log directory size failed: %s", err)
@@ -444,14 +448,14 @@ func getLogdirStat(conn *store.DB, logcollector bool, datadir string, mounts []m
 }
 
 // getTempfilesStat returns filesystem info related to temp files and directories.
-func getTempfilesStat(conn *store.DB, version int) (int64, int64, error) {
+func getTempfilesStat(ctx context.Context, conn *store.DB, version int) (int64, int64, error) {
 	if version < PostgresV12 {
 		return 0, 0, nil
 	}
 
 	var size, count int64
 	err := conn.Conn().
-		QueryRow(context.Background(), "SELECT coalesce(sum(size), 0) AS bytes, coalesce(count(name), 0) AS count FROM (SELECT (pg_ls_tmpdir(oid)).* FROM pg_tablespace WHERE spcname != 'pg_global') tablespaces").
+		QueryRow(ctx, "SELECT coalesce(sum(size), 0) AS bytes, coalesce(count(name), 0) AS count FROM (SELECT (pg_ls_tmpdir(oid)).* FROM pg_tablespace WHERE spcname != 'pg_global') tablespaces").
 		Scan(&size, &count)
 	if err != nil {
 		return 0, 0, fmt.Errorf("get total size of temp files failed: %s", err)
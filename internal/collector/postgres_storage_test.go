@@ -1,10 +1,12 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"github.com/jackc/pgproto3/v2"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
@@ -30,6 +32,14 @@ func TestPostgresStorageCollector_Update(t *testing.T) {
 	pipeline(t, input)
 }
 
+func TestPostgresStorageCollector_Update_insufficientPrivilege(t *testing.T) {
+	c, err := NewPostgresStorageCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	config := Config{postgresServiceConfig: postgresServiceConfig{serverVersionNum: PostgresV12}}
+	assert.ErrorIs(t, c.Update(context.Background(), config, make(chan prometheus.Metric)), errInsufficientPrivilege)
+}
+
 func Test_parsePostgresTempFileInflght(t *testing.T) {
 	var testCases = []struct {
 		name string
@@ -85,7 +95,7 @@ func Test_getTablespacesStat(t *testing.T) {
 
 	conn := store.NewTest(t)
 
-	ts, err := getTablespacesStat(conn, mounts)
+	ts, err := getTablespacesStat(context.Background(), conn, mounts)
 	assert.NoError(t, err)
 	assert.NotEqual(t, 0, ts)
 
@@ -102,7 +112,7 @@ func Test_getWaldirStat(t *testing.T) {
 
 	conn := store.NewTest(t)
 
-	s1, s2, s3, i1, i2, err := getWaldirStat(conn, mounts)
+	s1, s2, s3, i1, i2, err := getWaldirStat(context.Background(), conn, mounts)
 	assert.NoError(t, err)
 	assert.NotEqual(t, "", s1)
 	assert.NotEqual(t, "", s2)
@@ -119,7 +129,7 @@ func Test_getLogdirStat(t *testing.T) {
 
 	conn := store.NewTest(t)
 
-	s1, s2, s3, i1, i2, err := getLogdirStat(conn, true, "/tmp", mounts)
+	s1, s2, s3, i1, i2, err := getLogdirStat(context.Background(), conn, true, "/tmp", mounts)
 	assert.NoError(t, err)
 	assert.NotEqual(t, "", s1)
 	assert.NotEqual(t, "", s2)
@@ -133,7 +143,7 @@ func Test_getLogdirStat(t *testing.T) {
 func Test_getTempfilesStat(t *testing.T) {
 	conn := store.NewTest(t)
 
-	_, _, err := getTempfilesStat(conn, 120000)
+	_, _, err := getTempfilesStat(context.Background(), conn, 120000)
 	assert.NoError(t, err)
 
 	conn.Close()
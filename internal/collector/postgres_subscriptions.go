@@ -0,0 +1,126 @@
+package collector
+
+import (
+	"context"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Query for Postgres versions from 10 to 15. Main apply worker is the only worker which has no relid.
+	postgresSubscriptionsQuery15 = "SELECT d.datname AS database, s.subname, s.subenabled::int AS enabled, " +
+		"(SELECT count(*) FROM pg_stat_subscription w WHERE w.subid = s.oid AND w.pid IS NOT NULL) AS workers, " +
+		"a.received_lsn - '0/0' AS received_lsn, a.latest_end_lsn - '0/0' AS latest_end_lsn, " +
+		"a.received_lsn - a.latest_end_lsn AS lag_bytes " +
+		"FROM pg_subscription s JOIN pg_database d ON d.oid = s.subdbid " +
+		"LEFT JOIN pg_stat_subscription a ON a.subid = s.oid AND a.relid IS NULL"
+
+	// Query for Postgres versions from 16 and newer. Since Postgres 16 parallel apply workers have no relid too,
+	// but have leader_pid.
+	postgresSubscriptionsQueryLatest = "SELECT d.datname AS database, s.subname, s.subenabled::int AS enabled, " +
+		"(SELECT count(*) FROM pg_stat_subscription w WHERE w.subid = s.oid AND w.pid IS NOT NULL) AS workers, " +
+		"a.received_lsn - '0/0' AS received_lsn, a.latest_end_lsn - '0/0' AS latest_end_lsn, " +
+		"a.received_lsn - a.latest_end_lsn AS lag_bytes " +
+		"FROM pg_subscription s JOIN pg_database d ON d.oid = s.subdbid " +
+		"LEFT JOIN pg_stat_subscription a ON a.subid = s.oid AND a.relid IS NULL AND a.leader_pid IS NULL"
+)
+
+type postgresSubscriptionCollector struct {
+	enabled      typedDesc
+	workers      typedDesc
+	receivedLSN  typedDesc
+	latestEndLSN typedDesc
+	lag          typedDesc
+}
+
+// NewPostgresSubscriptionsCollector returns a new Collector exposing postgres logical replication subscriptions stats.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-SUBSCRIPTION
+func NewPostgresSubscriptionsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	var labelNames = []string{"database", "subname"}
+
+	return &postgresSubscriptionCollector{
+		enabled: newBuiltinTypedDesc(
+			descOpts{"postgres", "subscription", "enabled", "Value is 1 if subscription is enabled, 0 otherwise.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		workers: newBuiltinTypedDesc(
+			descOpts{"postgres", "subscription", "worker_count", "Number of running subscription workers, enabled subscription expected to have at least one.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		receivedLSN: newBuiltinTypedDesc(
+			descOpts{"postgres", "subscription", "received_lsn", "Last write-ahead log location received by subscription apply worker.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		latestEndLSN: newBuiltinTypedDesc(
+			descOpts{"postgres", "subscription", "latest_end_lsn", "Last write-ahead log location reported to origin WAL sender.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		lag: newBuiltinTypedDesc(
+			descOpts{"postgres", "subscription", "lag_bytes", "Number of bytes received by subscription apply worker, but not yet reported to origin WAL sender.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresSubscriptionCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	if config.serverVersionNum < PostgresV10 {
+		log.Debugln("[postgres subscriptions collector]: logical replication subscriptions are not available, required Postgres 10 or newer")
+		return nil
+	}
+
+	conn, err := store.NewContext(ctx, config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.QueryContext(ctx, selectSubscriptionsQuery(config.serverVersionNum))
+	if err != nil {
+		return err
+	}
+
+	stats := parsePostgresGenericStats(res, c.enabled.labelNames)
+
+	for _, stat := range stats {
+		database, subname := stat.labels["database"], stat.labels["subname"]
+
+		ch <- c.enabled.newConstMetric(stat.values["enabled"], database, subname)
+		ch <- c.workers.newConstMetric(stat.values["workers"], database, subname)
+
+		// LSN values are not available when apply worker is not running.
+		if v, ok := stat.values["received_lsn"]; ok {
+			ch <- c.receivedLSN.newConstMetric(v, database, subname)
+		}
+		if v, ok := stat.values["latest_end_lsn"]; ok {
+			ch <- c.latestEndLSN.newConstMetric(v, database, subname)
+		}
+		if v, ok := stat.values["lag_bytes"]; ok {
+			ch <- c.lag.newConstMetric(v, database, subname)
+		}
+	}
+
+	return nil
+}
+
+// selectSubscriptionsQuery returns suitable subscriptions query depending on passed version.
+func selectSubscriptionsQuery(version int) string {
+	switch {
+	case version < PostgresV16:
+		return postgresSubscriptionsQuery15
+	default:
+		return postgresSubscriptionsQueryLatest
+	}
+}
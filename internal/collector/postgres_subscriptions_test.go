@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresSubscriptionsCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_subscription_enabled",
+			"postgres_subscription_worker_count",
+			"postgres_subscription_received_lsn",
+			"postgres_subscription_latest_end_lsn",
+			"postgres_subscription_lag_bytes",
+		},
+		collector: NewPostgresSubscriptionsCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_selectSubscriptionsQuery(t *testing.T) {
+	var testcases = []struct {
+		version int
+		want    string
+	}{
+		{version: PostgresV10, want: postgresSubscriptionsQuery15},
+		{version: PostgresV15, want: postgresSubscriptionsQuery15},
+		{version: PostgresV16, want: postgresSubscriptionsQueryLatest},
+		{version: PostgresV17, want: postgresSubscriptionsQueryLatest},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.want, func(t *testing.T) {
+			assert.Equal(t, tc.want, selectSubscriptionsQuery(tc.version))
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package collector
 
 import (
+	"context"
+	"fmt"
 	"github.com/jackc/pgx/v4"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -47,6 +49,8 @@ type postgresTablesCollector struct {
 	sizes                typedDesc
 	reltuples            typedDesc
 	labelNames           []string
+	minDeadTuples        float64 // skip tables with fewer dead tuples
+	objects              postgresObjectsFilter
 }
 
 // NewPostgresTablesCollector returns a new Collector exposing postgres tables stats.
@@ -56,8 +60,24 @@ type postgresTablesCollector struct {
 func NewPostgresTablesCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
 	var labels = []string{"database", "schema", "table"}
 
+	minDeadTuples, err := settings.Options.Float("min_dead_tuples", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if minDeadTuples < 0 {
+		return nil, fmt.Errorf("invalid value '%.0f' of option 'min_dead_tuples': must be greater or equal to zero", minDeadTuples)
+	}
+
+	objects, err := newPostgresObjectsFilter(settings.Options)
+	if err != nil {
+		return nil, err
+	}
+
 	return &postgresTablesCollector{
-		labelNames: labels,
+		labelNames:    labels,
+		minDeadTuples: minDeadTuples,
+		objects:       objects,
 		seqscan: newBuiltinTypedDesc(
 			descOpts{"postgres", "table", "seq_scan_total", "The total number of sequential scans have been done.", 0},
 			prometheus.CounterValue,
@@ -119,7 +139,7 @@ func NewPostgresTablesCollector(constLabels labels, settings model.CollectorSett
 			settings.Filters,
 		),
 		tupModified: newBuiltinTypedDesc(
-			descOpts{"postgres", "table", "tuples_modified_total", "Estimated total number of modified tuples in the table since last vacuum.", 0},
+			descOpts{"postgres", "table", "tuples_modified_total", "Estimated total number of modified tuples in the table since last analyze.", 0},
 			prometheus.GaugeValue,
 			labels, constLabels,
 			settings.Filters,
@@ -176,14 +196,15 @@ func NewPostgresTablesCollector(constLabels labels, settings model.CollectorSett
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresTablesCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *postgresTablesCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
 
-	databases, err := listDatabases(conn)
+	databases, err := listFilteredDatabases(ctx, conn, c.objects)
 	if err != nil {
+		conn.Close()
 		return err
 	}
 
@@ -201,12 +222,12 @@ func (c *postgresTablesCollector) Update(config Config, ch chan<- prometheus.Met
 		}
 
 		pgconfig.Database = d
-		conn, err := store.NewWithConfig(pgconfig)
+		conn, err := store.NewWithConfigContext(ctx, pgconfig)
 		if err != nil {
 			return err
 		}
 
-		res, err := conn.Query(userTablesQuery)
+		res, err := conn.QueryContext(ctx, userTablesQuery+c.objects.relationsCondition("s1.schemaname", "s1.relname"))
 		conn.Close()
 		if err != nil {
 			log.Warnf("get tables stat of database '%s' failed: %s; skip", d, err)
@@ -216,6 +237,11 @@ func (c *postgresTablesCollector) Update(config Config, ch chan<- prometheus.Met
 		stats := parsePostgresTableStats(res, c.labelNames)
 
 		for _, stat := range stats {
+			// Tables with few dead tuples don't need vacuum, skip them to reduce number of metrics if requested.
+			if stat.dead < c.minDeadTuples {
+				continue
+			}
+
 			// scan stats
 			ch <- c.seqscan.newConstMetric(stat.seqscan, stat.database, stat.schema, stat.table)
 			ch <- c.seqtupread.newConstMetric(stat.seqtupread, stat.database, stat.schema, stat.table)
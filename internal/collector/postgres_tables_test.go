@@ -1,11 +1,17 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"github.com/jackc/pgproto3/v2"
 	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestPostgresTablesCollector_Update(t *testing.T) {
@@ -40,6 +46,51 @@ func TestPostgresTablesCollector_Update(t *testing.T) {
 	pipeline(t, input)
 }
 
+func TestNewPostgresTablesCollector(t *testing.T) {
+	c, err := NewPostgresTablesCollector(labels{}, model.CollectorSettings{Options: model.CollectorOptions{"min_dead_tuples": "1000"}})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1000), c.(*postgresTablesCollector).minDeadTuples)
+
+	for _, v := range []string{"invalid", "-1"} {
+		_, err = NewPostgresTablesCollector(labels{}, model.CollectorSettings{Options: model.CollectorOptions{"min_dead_tuples": v}})
+		assert.Error(t, err)
+	}
+}
+
+func TestPostgresTablesCollector_Update_releaseConn(t *testing.T) {
+	store.SetPoolConfig(store.PoolConfig{MaxOpen: 1})
+	defer func() {
+		store.ClosePools()
+		store.SetPoolConfig(store.PoolConfig{})
+	}()
+
+	c, err := NewPostgresTablesCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	// Regexp is invalid for Postgres, hence listing databases fails.
+	c.(*postgresTablesCollector).objects = postgresObjectsFilter{includeDatabases: "("}
+
+	ctx, cancel := context.WithTimeout(store.WithService(context.Background(), "test:0"), 5*time.Second)
+	defer cancel()
+
+	// Connection must be released after failure, otherwise the second run is blocked by max_open limit.
+	for i := 0; i < 2; i++ {
+		err = c.Update(ctx, Config{ConnString: store.TestPostgresConnStr}, make(chan prometheus.Metric))
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, context.DeadlineExceeded)
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(store.NewPoolCollector())
+
+	want := `# HELP pgscv_conn_pool_connections Number of connections in collectors connections pools by state.
+# TYPE pgscv_conn_pool_connections gauge
+pgscv_conn_pool_connections{service_id="test:0",state="idle"} 0
+pgscv_conn_pool_connections{service_id="test:0",state="in_use"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "pgscv_conn_pool_connections"))
+}
+
 func Test_parsePostgresTableStats(t *testing.T) {
 	var testCases = []struct {
 		name string
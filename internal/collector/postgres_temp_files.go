@@ -0,0 +1,235 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Temp files are named as pgsql_tmp<PID>.<N>, PID of backend is extracted from file name and used for getting
+	// backend's properties from pg_stat_activity.
+	postgresTempFilesBackendsQuery = "SELECT coalesce(a.datname, '') AS database, coalesce(a.usename, '') AS user, coalesce(t.pid::text, '') AS pid, " +
+		"count(*) AS files, sum(t.size) AS bytes, coalesce(extract(epoch from clock_timestamp() - min(t.modification)), 0) AS max_age_seconds " +
+		"FROM (SELECT substring(name from '^pgsql_tmp([0-9]+)')::int AS pid, size, modification " +
+		"FROM (SELECT (pg_ls_tmpdir(oid)).* FROM pg_tablespace WHERE spcname != 'pg_global') ls) t " +
+		"LEFT JOIN pg_stat_activity a ON a.pid = t.pid GROUP BY 1, 2, 3"
+
+	postgresTempFilesActivityQuery = "SELECT pid::text AS pid, coalesce(datname, '') AS database, coalesce(usename, '') AS user FROM pg_stat_activity"
+)
+
+// tempFileNameRE matches names of temp files and extracts PID of backend which created the file.
+var tempFileNameRE = regexp.MustCompile(`^pgsql_tmp(\d+)`)
+
+type postgresTempFilesCollector struct {
+	files      typedDesc
+	bytes      typedDesc
+	maxAge     typedDesc
+	filesystem bool // read temp directories directly when pg_ls_tmpdir() is not available
+	labelNames []string
+}
+
+// NewPostgresTempFilesCollector returns a new Collector exposing temporary files currently used by backends.
+// For details see https://www.postgresql.org/docs/current/functions-admin.html#FUNCTIONS-ADMIN-GENFILE
+func NewPostgresTempFilesCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	var labels = []string{"database", "user", "pid"}
+
+	filesystem, err := settings.Options.Bool("filesystem", false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &postgresTempFilesCollector{
+		labelNames: labels,
+		filesystem: filesystem,
+		files: newBuiltinTypedDesc(
+			descOpts{"postgres", "temp_files", "current", "Number of temporary files currently used by backend.", 0},
+			prometheus.GaugeValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		bytes: newBuiltinTypedDesc(
+			descOpts{"postgres", "temp_bytes", "current", "Number of bytes occupied by temporary files currently used by backend.", 0},
+			prometheus.GaugeValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		maxAge: newBuiltinTypedDesc(
+			descOpts{"postgres", "temp_files", "backend_max_age_seconds", "The age of the oldest temporary file used by backend, in seconds.", 0},
+			prometheus.GaugeValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresTempFilesCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var (
+		stats []postgresTempFilesStat
+		found bool
+	)
+
+	// pg_ls_tmpdir() is available since Postgres 12 and requires pg_monitor privileges.
+	if config.serverVersionNum >= PostgresV12 && config.monitorPrivileges() {
+		res, err := conn.QueryContext(ctx, postgresTempFilesBackendsQuery)
+		if err == nil {
+			stats, found = parsePostgresTempFilesStats(res, c.labelNames), true
+		} else {
+			if !c.filesystem {
+				return err
+			}
+			log.Debugf("[postgres temp files collector]: get temp files failed: %s; fallback to reading temp directories", err)
+		}
+	}
+
+	if !found {
+		if config.serverVersionNum >= PostgresV12 && !config.monitorPrivileges() && (!c.filesystem || !config.localService) {
+			return fmt.Errorf("%w: pg_ls_tmpdir() requires superuser or pg_monitor role", errInsufficientPrivilege)
+		}
+
+		if !c.filesystem || !config.localService {
+			log.Debugln("[postgres temp files collector]: temp files are not available, required Postgres 12 or local service with 'filesystem' option enabled")
+			return nil
+		}
+
+		res, err := conn.QueryContext(ctx, postgresTempFilesActivityQuery)
+		if err != nil {
+			return err
+		}
+
+		activity := map[string]postgresGenericStat{}
+		for _, s := range parsePostgresGenericStats(res, []string{"pid", "database", "user"}) {
+			activity[s.labels["pid"]] = s
+		}
+
+		stats = readPostgresTempFilesStats(config.dataDirectory, activity)
+	}
+
+	for _, stat := range stats {
+		ch <- c.files.newConstMetric(stat.files, stat.database, stat.user, stat.pid)
+		ch <- c.bytes.newConstMetric(stat.bytes, stat.database, stat.user, stat.pid)
+		ch <- c.maxAge.newConstMetric(stat.maxAge, stat.database, stat.user, stat.pid)
+	}
+
+	return nil
+}
+
+// postgresTempFilesStat represents temp files used by single backend.
+type postgresTempFilesStat struct {
+	database string
+	user     string
+	pid      string
+	files    float64
+	bytes    float64
+	maxAge   float64
+}
+
+// parsePostgresTempFilesStats parses PGResult and returns temp files stats of backends.
+func parsePostgresTempFilesStats(r *model.PGResult, labelNames []string) []postgresTempFilesStat {
+	log.Debug("parse postgres temp files stats")
+
+	var stats []postgresTempFilesStat
+
+	for _, s := range parsePostgresGenericStats(r, labelNames) {
+		stats = append(stats, postgresTempFilesStat{
+			database: s.labels["database"],
+			user:     s.labels["user"],
+			pid:      s.labels["pid"],
+			files:    s.values["files"],
+			bytes:    s.values["bytes"],
+			maxAge:   s.values["max_age_seconds"],
+		})
+	}
+
+	return stats
+}
+
+// readPostgresTempFilesStats reads temp directories of default and user-defined tablespaces and returns temp files
+// stats of backends. Backends' database and user are taken from passed activity stats keyed by PID. Unreadable
+// directories are skipped.
+func readPostgresTempFilesStats(datadir string, activity map[string]postgresGenericStat) []postgresTempFilesStat {
+	dirs, err := filepath.Glob(filepath.Join(datadir, "pg_tblspc", "*", "*", "pgsql_tmp"))
+	if err != nil {
+		log.Warnf("find tablespaces temp directories failed: %s; skip", err)
+	}
+	dirs = append([]string{filepath.Join(datadir, "base", "pgsql_tmp")}, dirs...)
+
+	var (
+		stats  = map[string]*postgresTempFilesStat{}
+		pids   []string
+		oldest = map[string]time.Time{}
+	)
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.Warnf("read temp directory failed: %s; skip", err)
+			}
+			continue
+		}
+
+		for _, e := range entries {
+			m := tempFileNameRE.FindStringSubmatch(e.Name())
+			if m == nil {
+				continue
+			}
+
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+
+			size := info.Size()
+			// Shared filesets used by parallel queries are directories.
+			if info.IsDir() {
+				size, err = getDirectorySize(filepath.Join(dir, e.Name()))
+				if err != nil {
+					continue
+				}
+			}
+
+			pid := m[1]
+			s, ok := stats[pid]
+			if !ok {
+				s = &postgresTempFilesStat{pid: pid}
+				if a, ok := activity[pid]; ok {
+					s.database, s.user = a.labels["database"], a.labels["user"]
+				}
+				stats[pid] = s
+				pids = append(pids, pid)
+			}
+
+			s.files++
+			s.bytes += float64(size)
+
+			if t, ok := oldest[pid]; !ok || info.ModTime().Before(t) {
+				oldest[pid] = info.ModTime()
+			}
+		}
+	}
+
+	result := make([]postgresTempFilesStat, 0, len(pids))
+	for _, pid := range pids {
+		s := stats[pid]
+		s.maxAge = time.Since(oldest[pid]).Seconds()
+		result = append(result, *s)
+	}
+
+	return result
+}
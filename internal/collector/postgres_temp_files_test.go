@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresTempFilesCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_temp_files_current",
+			"postgres_temp_bytes_current",
+			"postgres_temp_files_backend_max_age_seconds",
+		},
+		collector: NewPostgresTempFilesCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresTempFilesStats(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 6,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("user")}, {Name: []byte("pid")},
+			{Name: []byte("files")}, {Name: []byte("bytes")}, {Name: []byte("max_age_seconds")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "testdb", Valid: true}, {String: "testuser", Valid: true}, {String: "1234", Valid: true},
+				{String: "2", Valid: true}, {String: "8192", Valid: true}, {String: "15", Valid: true},
+			},
+		},
+	}
+
+	want := []postgresTempFilesStat{
+		{database: "testdb", user: "testuser", pid: "1234", files: 2, bytes: 8192, maxAge: 15},
+	}
+
+	assert.Equal(t, want, parsePostgresTempFilesStats(res, []string{"database", "user", "pid"}))
+}
+
+func Test_readPostgresTempFilesStats(t *testing.T) {
+	datadir := t.TempDir()
+
+	tmpdir := filepath.Join(datadir, "base", "pgsql_tmp")
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpdir, "pgsql_tmp4321.0.fileset"), 0700))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpdir, "pgsql_tmp1234.0"), make([]byte, 100), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpdir, "pgsql_tmp1234.1"), make([]byte, 50), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpdir, "pgsql_tmp4321.0.fileset", "o0of1.p0.0"), make([]byte, 10), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpdir, "unknown"), make([]byte, 10), 0600))
+
+	activity := map[string]postgresGenericStat{
+		"1234": {labels: map[string]string{"pid": "1234", "database": "testdb", "user": "testuser"}},
+	}
+
+	stats := readPostgresTempFilesStats(datadir, activity)
+	assert.Len(t, stats, 2)
+
+	for _, s := range stats {
+		switch s.pid {
+		case "1234":
+			assert.Equal(t, "testdb", s.database)
+			assert.Equal(t, "testuser", s.user)
+			assert.Equal(t, float64(2), s.files)
+			assert.Equal(t, float64(150), s.bytes)
+		case "4321":
+			assert.Equal(t, "", s.database)
+			assert.Equal(t, float64(1), s.files)
+			assert.Equal(t, float64(10), s.bytes)
+		default:
+			t.Errorf("unexpected pid %s", s.pid)
+		}
+	}
+
+	// Missing directories are skipped.
+	assert.Len(t, readPostgresTempFilesStats(filepath.Join(datadir, "missing"), nil), 0)
+}
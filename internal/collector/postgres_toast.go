@@ -0,0 +1,100 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	postgresToastQuery = "SELECT current_database() AS database, n.nspname AS schema, c.relname AS table, " +
+		"pg_relation_size(c.oid) AS heap, pg_indexes_size(c.oid) AS indexes, pg_total_relation_size(c.reltoastrelid) AS toast " +
+		"FROM pg_class c JOIN pg_namespace n ON c.relnamespace = n.oid " +
+		"WHERE c.relkind IN ('r', 'm') AND c.reltoastrelid != 0 AND n.nspname NOT IN ('pg_catalog', 'information_schema') " +
+		"AND NOT EXISTS (SELECT 1 FROM pg_locks WHERE relation = c.oid AND mode = 'AccessExclusiveLock' AND granted)"
+)
+
+// postgresToastCollector defines metric descriptors.
+type postgresToastCollector struct {
+	sizes      typedDesc
+	labelNames []string
+	objects    postgresObjectsFilter
+}
+
+// NewPostgresToastCollector returns a new Collector exposing sizes of tables which have TOAST tables, sizes of heap,
+// indexes and TOAST are exposed separately.
+// For details see https://www.postgresql.org/docs/current/storage-toast.html
+func NewPostgresToastCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	var labels = []string{"database", "schema", "table"}
+
+	objects, err := newPostgresObjectsFilter(settings.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &postgresToastCollector{
+		labelNames: labels,
+		objects:    objects,
+		sizes: newBuiltinTypedDesc(
+			descOpts{"postgres", "toast", "size_bytes", "Size of the table having TOAST table by each part (heap, indexes, toast), in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "type"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresToastCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	databases, err := listFilteredDatabases(ctx, conn, c.objects)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	conn.Close()
+
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range databases {
+		// Skip database if not matched to allowed.
+		if config.DatabasesRE != nil && !config.DatabasesRE.MatchString(d) {
+			continue
+		}
+
+		pgconfig.Database = d
+		conn, err := store.NewWithConfigContext(ctx, pgconfig)
+		if err != nil {
+			return err
+		}
+
+		res, err := conn.QueryContext(ctx, postgresToastQuery+c.objects.relationsCondition("n.nspname", "c.relname"))
+		conn.Close()
+		if err != nil {
+			log.Warnf("get toast tables stat of database '%s' failed: %s; skip", d, err)
+			continue
+		}
+
+		for _, stat := range parsePostgresGenericStats(res, c.labelNames) {
+			database, schema, table := stat.labels["database"], stat.labels["schema"], stat.labels["table"]
+
+			for _, part := range []string{"heap", "indexes", "toast"} {
+				ch <- c.sizes.newConstMetric(stat.values[part], database, schema, table, part)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"testing"
+)
+
+func TestPostgresToastCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_toast_size_bytes",
+		},
+		collector: NewPostgresToastCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
@@ -0,0 +1,147 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Idle client backends wait for client's commands and are not interesting, own backend is excluded too.
+	postgresWaitSamplingQuery = "SELECT wait_event_type AS type, wait_event AS event, count(*) AS waits " +
+		"FROM pg_stat_activity WHERE wait_event IS NOT NULL AND pid != pg_backend_pid() AND state IS DISTINCT FROM 'idle' " +
+		"GROUP BY wait_event_type, wait_event"
+
+	// postgresWaitSamplingDefaultSamples defines default number of samples taken during single scrape.
+	postgresWaitSamplingDefaultSamples = 10
+	// postgresWaitSamplingDefaultInterval defines default interval between samples.
+	postgresWaitSamplingDefaultInterval = 100 * time.Millisecond
+)
+
+type postgresWaitSamplingCollector struct {
+	samples    int           // number of samples taken during single scrape
+	interval   time.Duration // interval between samples
+	mu         sync.Mutex
+	waits      map[postgresWaitEvent]float64 // number of times backends have been seen waiting on each event
+	total      float64                       // number of samples taken since start
+	events     typedDesc
+	samplesCnt typedDesc
+}
+
+// postgresWaitEvent identifies wait event.
+type postgresWaitEvent struct {
+	etype string
+	event string
+}
+
+// NewPostgresWaitSamplingCollector returns a new Collector exposing wait events sampled from pg_stat_activity several
+// times during single scrape. It approximates pg_wait_sampling extension without requiring it.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#WAIT-EVENT-TABLE
+func NewPostgresWaitSamplingCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	samples, err := settings.Options.Int("samples", postgresWaitSamplingDefaultSamples)
+	if err != nil {
+		return nil, err
+	}
+
+	if samples < 1 {
+		samples = 1
+	}
+
+	interval, err := settings.Options.Duration("interval", postgresWaitSamplingDefaultInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &postgresWaitSamplingCollector{
+		samples:  samples,
+		interval: interval,
+		waits:    map[postgresWaitEvent]float64{},
+		events: newBuiltinTypedDesc(
+			descOpts{"postgres", "wait_events", "total", "Total number of times backends have been sampled waiting on each wait event.", 0},
+			prometheus.CounterValue,
+			[]string{"type", "event"}, constLabels,
+			settings.Filters,
+		),
+		samplesCnt: newBuiltinTypedDesc(
+			descOpts{"postgres", "wait_events", "samples_total", "Total number of pg_stat_activity samples taken for wait events sampling.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresWaitSamplingCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	if config.serverVersionNum < PostgresV96 {
+		log.Debugln("[postgres wait sampling collector]: wait events are not available, required Postgres 9.6 or newer")
+		return nil
+	}
+
+	conn, err := store.NewContext(ctx, config.ConnString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	waits, taken, err := c.sample(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.total += float64(taken)
+	for ev, v := range waits {
+		c.waits[ev] += v
+	}
+
+	for ev, v := range c.waits {
+		ch <- c.events.newConstMetric(v, ev.etype, ev.event)
+	}
+	ch <- c.samplesCnt.newConstMetric(c.total)
+
+	return nil
+}
+
+// sample takes configured number of samples of wait events and returns number of waits of each event and number of
+// taken samples. Sampling is stopped earlier when next sample could not be taken before the context's deadline.
+func (c *postgresWaitSamplingCollector) sample(ctx context.Context, conn *store.DB) (map[postgresWaitEvent]float64, int, error) {
+	waits := map[postgresWaitEvent]float64{}
+
+	var taken int
+	for taken < c.samples {
+		if taken > 0 {
+			// Keep a spare interval for sending metrics.
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < 2*c.interval {
+				log.Debugf("[postgres wait sampling collector]: stop sampling after %d samples to not exceed timeout", taken)
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, 0, ctx.Err()
+			case <-time.After(c.interval):
+			}
+		}
+
+		res, err := conn.QueryContext(ctx, postgresWaitSamplingQuery)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, stat := range parsePostgresGenericStats(res, []string{"type", "event"}) {
+			waits[postgresWaitEvent{etype: stat.labels["type"], event: stat.labels["event"]}] += stat.values["waits"]
+		}
+
+		taken++
+	}
+
+	return waits, taken, nil
+}
@@ -0,0 +1,45 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresWaitSamplingCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_wait_events_samples_total",
+		},
+		optional: []string{
+			"postgres_wait_events_total",
+		},
+		collector: NewPostgresWaitSamplingCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func TestPostgresWaitSamplingCollector_sample(t *testing.T) {
+	c, err := NewPostgresWaitSamplingCollector(labels{}, model.CollectorSettings{
+		Options: model.CollectorOptions{"samples": "100", "interval": "10ms"},
+	})
+	assert.NoError(t, err)
+
+	conn := store.NewTest(t)
+	defer conn.Close()
+
+	// Sampling should be stopped before deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, taken, err := c.(*postgresWaitSamplingCollector).sample(ctx, conn)
+	assert.NoError(t, err)
+	assert.Greater(t, taken, 0)
+	assert.Less(t, taken, 100)
+}
@@ -1,11 +1,13 @@
 package collector
 
 import (
+	"context"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 	"strconv"
+	"sync"
 )
 
 const (
@@ -22,6 +24,9 @@ const (
 )
 
 type postgresWalCollector struct {
+	writtenMu    sync.Mutex
+	writtenLast  float64 // last observed WAL location
+	writtenShift float64 // accumulated shift used for keeping written bytes counter monotonic
 	recovery     typedDesc
 	records      typedDesc
 	fpi          typedDesc
@@ -33,6 +38,7 @@ type postgresWalCollector struct {
 	secondsAll   typedDesc
 	seconds      typedDesc
 	resetUnix    typedDesc
+	segmentSize  typedDesc
 }
 
 // NewPostgresWalCollector returns a new Collector exposing postgres WAL stats.
@@ -105,19 +111,25 @@ func NewPostgresWalCollector(constLabels labels, settings model.CollectorSetting
 			nil, constLabels,
 			settings.Filters,
 		),
+		segmentSize: newBuiltinTypedDesc(
+			descOpts{"postgres", "wal", "segment_size_bytes", "Size of WAL segment, in bytes.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
-func (c *postgresWalCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString)
+func (c *postgresWalCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
 	// Get WAL usage stats.
-	res, err := conn.Query(selectWalQuery(config.serverVersionNum))
+	res, err := conn.QueryContext(ctx, selectWalQuery(config.serverVersionNum))
 	if err != nil {
 		return err
 	}
@@ -135,7 +147,7 @@ func (c *postgresWalCollector) Update(config Config, ch chan<- prometheus.Metric
 		case "wal_bytes":
 			ch <- c.bytes.newConstMetric(v)
 		case "wal_written":
-			ch <- c.writtenBytes.newConstMetric(v)
+			ch <- c.writtenBytes.newConstMetric(c.monotonicWritten(v))
 		case "wal_buffers_full":
 			ch <- c.buffersFull.newConstMetric(v)
 		case "wal_write":
@@ -155,9 +167,30 @@ func (c *postgresWalCollector) Update(config Config, ch chan<- prometheus.Metric
 		}
 	}
 
+	if config.walSegmentSize > 0 {
+		ch <- c.segmentSize.newConstMetric(float64(config.walSegmentSize))
+	}
+
 	return nil
 }
 
+// monotonicWritten accepts current WAL location and returns value which never decreases. WAL location could go
+// backwards (e.g. after pg_resetwal or when standby is switched to another upstream), in this case accumulated
+// shift is increased and counter continues from the last value instead of producing a false reset.
+func (c *postgresWalCollector) monotonicWritten(v float64) float64 {
+	c.writtenMu.Lock()
+	defer c.writtenMu.Unlock()
+
+	if v < c.writtenLast {
+		log.Warnf("WAL location went backwards from %.0f to %.0f, keep written bytes counter monotonic", c.writtenLast, v)
+		c.writtenShift += c.writtenLast - v
+	}
+
+	c.writtenLast = v
+
+	return v + c.writtenShift
+}
+
 // parsePostgresWalStats parses PGResult and returns struct with data values
 func parsePostgresWalStats(r *model.PGResult) map[string]float64 {
 	log.Debug("parse postgres WAL stats")
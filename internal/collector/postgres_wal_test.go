@@ -26,6 +26,7 @@ func TestPostgresWalCollector_Update(t *testing.T) {
 			"postgres_wal_seconds_all_total",
 			"postgres_wal_seconds_total",
 			"postgres_wal_stats_reset_time",
+			"postgres_wal_segment_size_bytes",
 		},
 		collector: NewPostgresWalCollector,
 		service:   model.ServiceTypePostgresql,
@@ -34,6 +35,18 @@ func TestPostgresWalCollector_Update(t *testing.T) {
 	pipeline(t, input)
 }
 
+func TestPostgresWalCollector_monotonicWritten(t *testing.T) {
+	c := &postgresWalCollector{}
+
+	assert.Equal(t, float64(100), c.monotonicWritten(100))
+	assert.Equal(t, float64(200), c.monotonicWritten(200))
+
+	// WAL location goes backwards, counter should not decrease.
+	assert.Equal(t, float64(200), c.monotonicWritten(50))
+	assert.Equal(t, float64(250), c.monotonicWritten(100))
+	assert.Equal(t, float64(250), c.monotonicWritten(100))
+}
+
 func Test_parsePostgresWalStats(t *testing.T) {
 	var testCases = []struct {
 		name string
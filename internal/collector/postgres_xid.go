@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"context"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	postgresDatabaseXidQuery = "SELECT datname AS database, age(datfrozenxid) AS xid_age, " +
+		"current_setting('autovacuum_freeze_max_age')::bigint AS freeze_max_age " +
+		"FROM pg_database WHERE datallowconn AND NOT datistemplate"
+
+	postgresRelationXidQuery = "SELECT current_database() AS database, coalesce(max(age(relfrozenxid)), 0) AS xid_age_max " +
+		"FROM pg_class WHERE relkind IN ('r', 'm', 't')"
+
+	// xidWraparoundLimit defines the max age of transaction ID after which Postgres stops accepting commands.
+	xidWraparoundLimit = 2147483647
+)
+
+type postgresXidCollector struct {
+	databaseAge typedDesc
+	relationAge typedDesc
+	remaining   typedDesc
+}
+
+// NewPostgresXidCollector returns a new Collector exposing postgres transaction ID age stats.
+// For details see https://www.postgresql.org/docs/current/routine-vacuuming.html#VACUUM-FOR-WRAPAROUND
+func NewPostgresXidCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresXidCollector{
+		databaseAge: newBuiltinTypedDesc(
+			descOpts{"postgres", "database", "xid_age", "Age of the oldest unfrozen transaction ID in the database.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		relationAge: newBuiltinTypedDesc(
+			descOpts{"postgres", "relation", "xid_age_max", "Age of the oldest unfrozen transaction ID among relations in the database.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		remaining: newBuiltinTypedDesc(
+			descOpts{"postgres", "xid", "remaining_until_wraparound", "Number of transactions left before reaching the limit: forced anti-wraparound autovacuum or force shutdown.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "limit"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresXidCollector) Update(ctx context.Context, config Config, ch chan<- prometheus.Metric) error {
+	conn, err := store.NewContext(ctx, config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	res, err := conn.QueryContext(ctx, postgresDatabaseXidQuery)
+	conn.Close()
+	if err != nil {
+		return err
+	}
+
+	stats := parsePostgresGenericStats(res, []string{"database"})
+
+	for _, stat := range stats {
+		database, age := stat.labels["database"], stat.values["xid_age"]
+
+		ch <- c.databaseAge.newConstMetric(age, database)
+		ch <- c.remaining.newConstMetric(xidRemaining(stat.values["freeze_max_age"], age), database, "autovacuum")
+		ch <- c.remaining.newConstMetric(xidRemaining(xidWraparoundLimit, age), database, "wraparound")
+	}
+
+	// Relations are stored per-database, connect to each database and get the oldest relation.
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range stats {
+		d := stat.labels["database"]
+
+		// Skip database if not matched to allowed.
+		if config.DatabasesRE != nil && !config.DatabasesRE.MatchString(d) {
+			continue
+		}
+
+		pgconfig.Database = d
+		conn, err := store.NewWithConfigContext(ctx, pgconfig)
+		if err != nil {
+			return err
+		}
+
+		res, err := conn.QueryContext(ctx, postgresRelationXidQuery)
+		conn.Close()
+		if err != nil {
+			log.Warnf("get relations xid age of database '%s' failed: %s; skip", d, err)
+			continue
+		}
+
+		for _, s := range parsePostgresGenericStats(res, []string{"database"}) {
+			ch <- c.relationAge.newConstMetric(s.values["xid_age_max"], s.labels["database"])
+		}
+	}
+
+	return nil
+}
+
+// xidRemaining returns number of transactions left before transaction ID age reaches the limit.
+func xidRemaining(limit, age float64) float64 {
+	if age >= limit {
+		return 0
+	}
+	return limit - age
+}
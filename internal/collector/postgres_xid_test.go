@@ -0,0 +1,28 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPostgresXidCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_database_xid_age",
+			"postgres_relation_xid_age_max",
+			"postgres_xid_remaining_until_wraparound",
+		},
+		collector: NewPostgresXidCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_xidRemaining(t *testing.T) {
+	assert.Equal(t, float64(199999000), xidRemaining(200000000, 1000))
+	assert.Equal(t, float64(0), xidRemaining(200000000, 200000000))
+	assert.Equal(t, float64(0), xidRemaining(200000000, 250000000))
+	assert.Equal(t, float64(2147482647), xidRemaining(xidWraparoundLimit, 1000))
+}
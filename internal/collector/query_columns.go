@@ -0,0 +1,393 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/cherts/pgscv/internal/model"
+	"strings"
+	"unicode"
+)
+
+// queryToken kinds.
+const (
+	tokenIdent       = iota // unquoted identifier or keyword
+	tokenQuotedIdent        // double-quoted identifier
+	tokenOpen               // opening parenthesis or bracket
+	tokenClose              // closing parenthesis or bracket
+	tokenComma
+	tokenDot
+	tokenSemicolon
+	tokenOther // literals, operators, parameters
+)
+
+// queryToken is a lexical token of SQL query.
+type queryToken struct {
+	kind  int
+	value string
+}
+
+// keyword returns true if token is unquoted identifier equal to passed lower-case keyword.
+func (t queryToken) keyword(kw string) bool {
+	return t.kind == tokenIdent && strings.ToLower(t.value) == kw
+}
+
+// identifier returns name of identifier accordingly to Postgres rules: unquoted identifiers are folded to lower case.
+func (t queryToken) identifier() (string, bool) {
+	switch t.kind {
+	case tokenIdent:
+		return strings.ToLower(t.value), true
+	case tokenQuotedIdent:
+		return t.value, true
+	default:
+		return "", false
+	}
+}
+
+// operatorChars defines characters which operators consist of.
+const operatorChars = "+-*/<>=~!@#%^&|`?:"
+
+// selectListEnd defines keywords which finish select list of SELECT statement.
+var selectListEnd = []string{
+	"from", "into", "where", "group", "having", "window", "order", "limit", "offset", "fetch", "for",
+	"union", "intersect", "except",
+}
+
+// expressionKeywords defines keywords which start expressions or SQL-standard functions. Postgres names columns of
+// such expressions not by the keyword or differently depending on version (e.g. 'date_part' for EXTRACT before
+// Postgres 14).
+var expressionKeywords = []string{
+	"all", "any", "array", "case", "cast", "collation", "current_catalog", "current_date", "current_role",
+	"current_schema", "current_time", "current_timestamp", "current_user", "distinct", "exists", "extract", "false",
+	"interval", "localtime", "localtimestamp", "normalize", "not", "null", "overlay", "position", "row",
+	"session_user", "some", "substring", "treat", "trim", "true", "user",
+}
+
+// CheckSubsystemColumns checks that columns used by metrics of user-defined subsystem are returned by subsystem's
+// query. Columns are determined from query text, if it's not possible (e.g. 'SELECT *' is used or expression has no
+// alias), the check is skipped and columns are checked when query is executed.
+func CheckSubsystemColumns(subsystem model.MetricsSubsystem) error {
+	colnames, ok := queryColumns(subsystem.Query)
+	if !ok {
+		return nil
+	}
+
+	for _, m := range subsystem.Metrics {
+		d := typedDesc{value: m.Value, labeledValues: m.LabeledValues, labelNames: userMetricLabelNames(subsystem, m)}
+		err := checkDescColumns(d, colnames, subsystem.Databases != "")
+		if err != nil {
+			return fmt.Errorf("metric '%s': %s", m.ShortName, err)
+		}
+	}
+
+	return nil
+}
+
+// queryColumns returns names of columns returned by passed query. Names are taken from select list of the top-level
+// SELECT statement. False is returned if names could not be determined, e.g. for other statements, 'SELECT *' or
+// expressions without alias.
+func queryColumns(query string) ([]string, bool) {
+	tokens, ok := tokenizeQuery(query)
+	if !ok {
+		return nil, false
+	}
+
+	// Look for the top-level SELECT, common table expressions and subqueries are enclosed in parentheses.
+	var start = -1
+	var depth int
+	for i, t := range tokens {
+		if t.kind == tokenOpen {
+			depth++
+		} else if t.kind == tokenClose {
+			depth--
+		} else if depth == 0 && t.keyword("select") {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return nil, false
+	}
+
+	tokens = skipSelectQuantifier(tokens[start:])
+
+	// Split select list into items.
+	var items [][]queryToken
+	var item []queryToken
+	depth = 0
+
+loop:
+	for _, t := range tokens {
+		switch {
+		case t.kind == tokenOpen:
+			depth++
+		case t.kind == tokenClose:
+			depth--
+		case depth == 0 && t.kind == tokenSemicolon:
+			break loop
+		case depth == 0 && t.kind == tokenIdent && stringsContains(selectListEnd, strings.ToLower(t.value)):
+			break loop
+		case depth == 0 && t.kind == tokenComma:
+			items = append(items, item)
+			item = nil
+			continue
+		}
+		item = append(item, t)
+	}
+	items = append(items, item)
+
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		name, ok := columnName(item)
+		if !ok {
+			return nil, false
+		}
+		names = append(names, name)
+	}
+
+	return names, true
+}
+
+// skipSelectQuantifier skips DISTINCT, DISTINCT ON (...) or ALL at the beginning of select list.
+func skipSelectQuantifier(tokens []queryToken) []queryToken {
+	if len(tokens) > 0 && tokens[0].keyword("all") {
+		return tokens[1:]
+	}
+
+	if len(tokens) == 0 || !tokens[0].keyword("distinct") {
+		return tokens
+	}
+
+	tokens = tokens[1:]
+	if len(tokens) < 2 || !tokens[0].keyword("on") || tokens[1].kind != tokenOpen {
+		return tokens
+	}
+
+	if end := closingToken(tokens, 1); end > 0 {
+		return tokens[end+1:]
+	}
+
+	return nil
+}
+
+// columnName returns name of the column defined by select list item. Name is known for items with alias, column
+// references and function calls.
+func columnName(item []queryToken) (string, bool) {
+	n := len(item)
+	if n == 0 {
+		return "", false
+	}
+
+	// Explicit alias.
+	if n >= 3 && item[n-2].keyword("as") {
+		return item[n-1].identifier()
+	}
+
+	// Column reference or function call, optionally followed by alias without AS.
+	if item[0].kind == tokenIdent && stringsContains(expressionKeywords, strings.ToLower(item[0].value)) {
+		return "", false
+	}
+
+	name, ok := item[0].identifier()
+	if !ok {
+		return "", false
+	}
+
+	i := 1
+	for i+1 < n && item[i].kind == tokenDot {
+		name, ok = item[i+1].identifier()
+		if !ok {
+			return "", false
+		}
+		i += 2
+	}
+
+	if i < n && item[i].kind == tokenOpen && item[i].value == "(" {
+		end := closingToken(item, i)
+		if end < 0 {
+			return "", false
+		}
+		i = end + 1
+	}
+
+	switch n - i {
+	case 0:
+		return name, true
+	case 1:
+		return item[i].identifier()
+	default:
+		return "", false
+	}
+}
+
+// closingToken returns index of token which closes parenthesis or bracket opened by token with passed index, or -1
+// if there is no such token.
+func closingToken(tokens []queryToken, open int) int {
+	var depth int
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].kind {
+		case tokenOpen:
+			depth++
+		case tokenClose:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// tokenizeQuery splits query into tokens. Comments and whitespaces are skipped, string constants and operators are
+// not interpreted. False is returned if query has unterminated string, quoted identifier or comment.
+func tokenizeQuery(query string) ([]queryToken, bool) {
+	var tokens []queryToken
+	s := []rune(query)
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '-' && i+1 < len(s) && s[i+1] == '-':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			// Block comments could be nested.
+			depth := 0
+			for ; i < len(s); i++ {
+				if s[i] == '/' && i+1 < len(s) && s[i+1] == '*' {
+					depth++
+					i++
+				} else if s[i] == '*' && i+1 < len(s) && s[i+1] == '/' {
+					depth--
+					i++
+					if depth == 0 {
+						i++
+						break
+					}
+				}
+			}
+			if depth != 0 {
+				return nil, false
+			}
+		case c == '\'':
+			end := quotedEnd(s, i, '\'', false)
+			if end < 0 {
+				return nil, false
+			}
+			tokens = append(tokens, queryToken{kind: tokenOther, value: string(s[i:end])})
+			i = end
+		case c == '"':
+			end := quotedEnd(s, i, '"', false)
+			if end < 0 {
+				return nil, false
+			}
+			tokens = append(tokens, queryToken{kind: tokenQuotedIdent, value: strings.ReplaceAll(string(s[i+1:end-1]), `""`, `"`)})
+			i = end
+		case (c == 'e' || c == 'E') && i+1 < len(s) && s[i+1] == '\'':
+			// String constant with C-style escapes.
+			end := quotedEnd(s, i+1, '\'', true)
+			if end < 0 {
+				return nil, false
+			}
+			tokens = append(tokens, queryToken{kind: tokenOther, value: string(s[i:end])})
+			i = end
+		case c == '$':
+			end := dollarQuotedEnd(s, i)
+			if end < 0 {
+				return nil, false
+			}
+			tokens = append(tokens, queryToken{kind: tokenOther, value: string(s[i:end])})
+			i = end
+		case c == '_' || unicode.IsLetter(c):
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || s[j] == '$' || unicode.IsLetter(s[j]) || unicode.IsDigit(s[j])) {
+				j++
+			}
+			tokens = append(tokens, queryToken{kind: tokenIdent, value: string(s[i:j])})
+			i = j
+		case unicode.IsDigit(c):
+			j := i + 1
+			for j < len(s) && (s[j] == '.' || s[j] == '_' || unicode.IsLetter(s[j]) || unicode.IsDigit(s[j])) {
+				j++
+			}
+			tokens = append(tokens, queryToken{kind: tokenOther, value: string(s[i:j])})
+			i = j
+		case c == '(' || c == '[':
+			tokens = append(tokens, queryToken{kind: tokenOpen, value: string(c)})
+			i++
+		case c == ')' || c == ']':
+			tokens = append(tokens, queryToken{kind: tokenClose, value: string(c)})
+			i++
+		case c == ',':
+			tokens = append(tokens, queryToken{kind: tokenComma, value: ","})
+			i++
+		case c == ';':
+			tokens = append(tokens, queryToken{kind: tokenSemicolon, value: ";"})
+			i++
+		case c == '.':
+			tokens = append(tokens, queryToken{kind: tokenDot, value: "."})
+			i++
+		case strings.ContainsRune(operatorChars, c):
+			// Operators are taken as a whole, e.g. '::' or '>='.
+			j := i + 1
+			for j < len(s) && strings.ContainsRune(operatorChars, s[j]) {
+				j++
+			}
+			tokens = append(tokens, queryToken{kind: tokenOther, value: string(s[i:j])})
+			i = j
+		default:
+			tokens = append(tokens, queryToken{kind: tokenOther, value: string(c)})
+			i++
+		}
+	}
+
+	return tokens, true
+}
+
+// quotedEnd returns index next to the closing quote of string or identifier starting at passed index, or -1 if it's
+// not terminated. Doubled quotes are considered as escaped quote, backslash escapes are considered if required.
+func quotedEnd(s []rune, start int, quote rune, backslash bool) int {
+	for i := start + 1; i < len(s); i++ {
+		switch {
+		case backslash && s[i] == '\\':
+			i++
+		case s[i] == quote && i+1 < len(s) && s[i+1] == quote:
+			i++
+		case s[i] == quote:
+			return i + 1
+		}
+	}
+
+	return -1
+}
+
+// dollarQuotedEnd returns index next to the end of dollar-quoted string constant (e.g. $tag$...$tag$) or positional
+// parameter (e.g. $1) starting at passed index, or -1 if string is not terminated.
+func dollarQuotedEnd(s []rune, start int) int {
+	i := start + 1
+	if i < len(s) && unicode.IsDigit(s[i]) {
+		for i < len(s) && unicode.IsDigit(s[i]) {
+			i++
+		}
+		return i
+	}
+
+	for i < len(s) && (s[i] == '_' || unicode.IsLetter(s[i]) || unicode.IsDigit(s[i])) {
+		i++
+	}
+	if i >= len(s) || s[i] != '$' {
+		return -1
+	}
+
+	tag := s[start : i+1]
+	for j := i + 1; j+len(tag) <= len(s); j++ {
+		if string(s[j:j+len(tag)]) == string(tag) {
+			return j + len(tag)
+		}
+	}
+
+	return -1
+}
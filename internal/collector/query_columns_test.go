@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheckSubsystemColumns(t *testing.T) {
+	testcases := []struct {
+		valid     bool
+		subsystem model.MetricsSubsystem
+	}{
+		{
+			valid: true,
+			subsystem: model.MetricsSubsystem{
+				Query: "SELECT schemaname, relname, seq_scan, n_tup_ins, n_tup_upd, n_tup_del FROM pg_stat_user_tables",
+				Metrics: model.Metrics{
+					{ShortName: "seq_scans", Value: "seq_scan", Labels: []string{"schemaname", "relname"}},
+					{ShortName: "tuples", LabeledValues: map[string][]string{"tuples": {"n_tup_ins", "n_tup_upd/upd", "n_tup_del"}}, Labels: []string{"relname"}},
+				},
+			},
+		},
+		{
+			// 'database' label is filled with name of visited database.
+			valid: true,
+			subsystem: model.MetricsSubsystem{
+				Databases: "^db$",
+				Query:     "SELECT count(*) AS total FROM pg_class",
+				Metrics:   model.Metrics{{ShortName: "total", Value: "total", Labels: []string{"database"}}},
+			},
+		},
+		{
+			// Columns could not be determined, they are checked when query is executed.
+			valid: true,
+			subsystem: model.MetricsSubsystem{
+				Query:   "SELECT * FROM pg_stat_user_tables",
+				Metrics: model.Metrics{{ShortName: "seq_scans", Value: "seq_scan"}},
+			},
+		},
+		{
+			valid: false,
+			subsystem: model.MetricsSubsystem{
+				Query:   "SELECT relname, seq_scan FROM pg_stat_user_tables",
+				Metrics: model.Metrics{{ShortName: "seq_scans", Value: "seq_scans", Labels: []string{"relname"}}},
+			},
+		},
+		{
+			valid: false,
+			subsystem: model.MetricsSubsystem{
+				Query:   "SELECT relname, seq_scan FROM pg_stat_user_tables",
+				Metrics: model.Metrics{{ShortName: "seq_scans", Value: "seq_scan", Labels: []string{"schemaname"}}},
+			},
+		},
+		{
+			valid: false,
+			subsystem: model.MetricsSubsystem{
+				Query:   "SELECT relname, n_tup_ins FROM pg_stat_user_tables",
+				Metrics: model.Metrics{{ShortName: "tuples", LabeledValues: map[string][]string{"tuples": {"n_tup_ins", "n_tup_upd"}}}},
+			},
+		},
+		{
+			valid: false,
+			subsystem: model.MetricsSubsystem{
+				Query:   "SELECT count(*) AS total FROM pg_class",
+				Metrics: model.Metrics{{ShortName: "total", Value: "total", Labels: []string{"database"}}},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.subsystem.Query, func(t *testing.T) {
+			if tc.valid {
+				assert.NoError(t, CheckSubsystemColumns(tc.subsystem))
+			} else {
+				assert.Error(t, CheckSubsystemColumns(tc.subsystem))
+			}
+		})
+	}
+}
+
+func Test_queryColumns(t *testing.T) {
+	testcases := []struct {
+		query string
+		want  []string
+		ok    bool
+	}{
+		{query: "SELECT a, b FROM t", want: []string{"a", "b"}, ok: true},
+		{query: "select 1 as one, 'x' AS \"Two\", t.c, s.t.d", want: []string{"one", "Two", "c", "d"}, ok: true},
+		{query: "SELECT count(*), max(x) m, pg_catalog.now() FROM t GROUP BY y", want: []string{"count", "m", "now"}, ok: true},
+		{query: "SELECT DISTINCT ON (a, b) a, b FROM t ORDER BY a", want: []string{"a", "b"}, ok: true},
+		{query: "SELECT ALL a FROM t;", want: []string{"a"}, ok: true},
+		{query: "SELECT Relname, \"seq_Scan\" FROM t", want: []string{"relname", "seq_Scan"}, ok: true},
+		{query: "SELECT coalesce(a, 0)::float AS a, (SELECT count(*) FROM u WHERE u.x = t.x) AS cnt FROM t", want: []string{"a", "cnt"}, ok: true},
+		{query: "SELECT a FROM t UNION ALL SELECT b FROM u", want: []string{"a"}, ok: true},
+		{query: "WITH x AS (SELECT 1 AS one) SELECT one AS value FROM x", want: []string{"value"}, ok: true},
+		{query: "SELECT count(*) FILTER (WHERE state = 'active') AS active, a -- comment, b\nFROM t", want: []string{"active", "a"}, ok: true},
+		{query: "SELECT /* comment, /* nested */ b */ a, 'it''s, ok' AS s, E'it\\'s, ok' AS e, $$x, y$$ AS d, $q$z$q$ AS q FROM t", want: []string{"a", "s", "e", "d", "q"}, ok: true},
+		{query: "SELECT * FROM t"},
+		{query: "SELECT t.* FROM t"},
+		{query: "SELECT a + b FROM t"},
+		{query: "SELECT a::int FROM t"},
+		{query: "SELECT 1"},
+		{query: "SELECT CAST(a AS int) FROM t"},
+		{query: "SELECT extract(epoch FROM now())"},
+		{query: "SELECT CASE WHEN a THEN 1 ELSE 0 END FROM t"},
+		{query: "SELECT a FROM t WHERE b = 'unterminated"},
+		{query: "SHOW max_connections"},
+		{query: ""},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.query, func(t *testing.T) {
+			got, ok := queryColumns(tc.query)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/prometheus/client_golang/prometheus"
@@ -40,7 +41,7 @@ func pipeline(t *testing.T, input pipelineInput) {
 	switch input.service {
 	case model.ServiceTypePostgresql:
 		config.ConnString = "postgres://pgscv@127.0.0.1/postgres"
-		cfg, err := newPostgresServiceConfig(config.ConnString)
+		cfg, err := newPostgresServiceConfig(context.Background(), config.ConnString)
 		assert.NoError(t, err)
 		config.postgresServiceConfig = cfg
 	case model.ServiceTypePgbouncer:
@@ -48,7 +49,7 @@ func pipeline(t *testing.T, input pipelineInput) {
 	}
 
 	go func() {
-		err := collector.Update(config, ch)
+		err := collector.Update(context.Background(), config, ch)
 		assert.NoError(t, err)
 		close(ch)
 	}()
@@ -84,3 +85,22 @@ func pipeline(t *testing.T, input pipelineInput) {
 		}
 	}
 }
+
+// collectMetrics runs collector's Update with passed config and returns collected metrics, nil metrics are skipped.
+// Test fails if Update returns error.
+func collectMetrics(t *testing.T, c Collector, config Config) []prometheus.Metric {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		assert.NoError(t, c.Update(context.Background(), config, ch))
+		close(ch)
+	}()
+
+	var metrics []prometheus.Metric
+	for m := range ch {
+		if m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+
+	return metrics
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// cachingGatherer wraps prometheus.Gatherer and reuses gathered metrics during TTL. Concurrent requests arrived
+// while metrics are being gathered wait for the result instead of gathering metrics on their own.
+type cachingGatherer struct {
+	gatherer prometheus.Gatherer
+	ttl      time.Duration
+	hits     prometheus.Counter
+	misses   prometheus.Counter
+
+	mu        sync.Mutex
+	families  []*dto.MetricFamily
+	err       error
+	updatedAt time.Time
+}
+
+// newCachingGatherer creates new cachingGatherer and registers its metrics using passed registerer.
+func newCachingGatherer(gatherer prometheus.Gatherer, registerer prometheus.Registerer, ttl time.Duration) (*cachingGatherer, error) {
+	hits, err := registerCounter(registerer, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pgscv_cache_hit_total",
+		Help: "Total number of scrapes served from cache.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	misses, err := registerCounter(registerer, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pgscv_cache_miss_total",
+		Help: "Total number of scrapes which required gathering metrics.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &cachingGatherer{gatherer: gatherer, ttl: ttl, hits: hits, misses: misses}, nil
+}
+
+// Gather implements prometheus.Gatherer.
+func (g *cachingGatherer) Gather() ([]*dto.MetricFamily, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.updatedAt.IsZero() && time.Since(g.updatedAt) < g.ttl {
+		g.hits.Inc()
+		return g.families, g.err
+	}
+
+	g.misses.Inc()
+	g.families, g.err = g.gatherer.Gather()
+	g.updatedAt = time.Now()
+
+	return g.families, g.err
+}
+
+// registerCounter registers counter, or returns already registered one.
+func registerCounter(registerer prometheus.Registerer, c prometheus.Counter) (prometheus.Counter, error) {
+	if err := registerer.Register(c); err != nil {
+		are := prometheus.AlreadyRegisteredError{}
+		if !errors.As(err, &are) {
+			return nil, err
+		}
+		return are.ExistingCollector.(prometheus.Counter), nil
+	}
+
+	return c, nil
+}
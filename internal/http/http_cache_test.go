@@ -0,0 +1,54 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_cachingGatherer(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_total", Help: "test"})
+	reg.MustRegister(counter)
+
+	g, err := newCachingGatherer(reg, reg, 100*time.Millisecond)
+	assert.NoError(t, err)
+
+	// First gather is a miss.
+	families, err := g.Gather()
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), findValue(families, "test_total"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(g.misses))
+
+	// Gather within TTL returns cached result.
+	counter.Inc()
+	families, err = g.Gather()
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), findValue(families, "test_total"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(g.hits))
+
+	// Gather after TTL expiry returns fresh result.
+	time.Sleep(150 * time.Millisecond)
+	families, err = g.Gather()
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), findValue(families, "test_total"))
+	assert.Equal(t, float64(2), testutil.ToFloat64(g.misses))
+
+	// Gatherer with the same registerer reuses registered counters.
+	g2, err := newCachingGatherer(reg, reg, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, g.hits, g2.hits)
+}
+
+func findValue(families []*dto.MetricFamily, name string) float64 {
+	for _, mf := range families {
+		if mf.GetName() == name {
+			return mf.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	return -1
+}
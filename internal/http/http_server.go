@@ -1,37 +1,72 @@
 package http
 
 import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/cherts/pgscv/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // AuthConfig defines configuration settings for authentication.
 type AuthConfig struct {
-	EnableAuth bool   // flag tells about authentication should be enabled
-	Username   string `yaml:"username"` // username used for basic authentication
-	Password   string `yaml:"password"` // password used for basic authentication
-	EnableTLS  bool   // flag tells about TLS should be enabled
-	Keyfile    string `yaml:"keyfile"`  // path to key file
-	Certfile   string `yaml:"certfile"` // path to certificate file
+	EnableAuth   bool   // flag tells about authentication should be enabled
+	Username     string `yaml:"username"`      // username used for basic authentication
+	Password     string `yaml:"password"`      // password used for basic authentication
+	PasswordHash string `yaml:"password_hash"` // bcrypt hash of password used for basic authentication, alternative to password
+	BearerToken  string `yaml:"bearer_token"`  // token used for bearer authentication, alternative to basic authentication
+	EnableTLS    bool   // flag tells about TLS should be enabled
+	Keyfile      string `yaml:"keyfile"`       // path to key file
+	Certfile     string `yaml:"certfile"`      // path to certificate file
+	ClientCAfile string `yaml:"client_cafile"` // path to CA certificate file used for verifying client certificates (mTLS)
 }
 
 // Validate check authentication options of AuthConfig and returns toggle flags.
 func (cfg AuthConfig) Validate() (bool, bool, error) {
 	var enableAuth, enableTLS bool
 
-	if (cfg.Username == "" && cfg.Password != "") || (cfg.Username != "" && cfg.Password == "") {
+	if cfg.Password != "" && cfg.PasswordHash != "" {
+		return false, false, fmt.Errorf("authentication settings invalid: password and password_hash are mutually exclusive")
+	}
+
+	password := cfg.Password
+	if cfg.PasswordHash != "" {
+		if _, err := bcrypt.Cost([]byte(cfg.PasswordHash)); err != nil {
+			return false, false, fmt.Errorf("authentication settings invalid: password_hash: %s", err)
+		}
+		password = cfg.PasswordHash
+	}
+
+	if (cfg.Username == "" && password != "") || (cfg.Username != "" && password == "") {
 		return false, false, fmt.Errorf("authentication settings invalid")
 	}
 
+	if cfg.BearerToken != "" && cfg.Username != "" {
+		return false, false, fmt.Errorf("authentication settings invalid: basic and bearer authentication are mutually exclusive")
+	}
+
 	if (cfg.Keyfile == "" && cfg.Certfile != "") || (cfg.Keyfile != "" && cfg.Certfile == "") {
 		return false, false, fmt.Errorf("TLS settings invalid")
 	}
 
-	if cfg.Username != "" && cfg.Password != "" {
+	if (cfg.Username != "" && password != "") || cfg.BearerToken != "" {
 		enableAuth = true
 	}
 
@@ -39,6 +74,10 @@ func (cfg AuthConfig) Validate() (bool, bool, error) {
 		enableTLS = true
 	}
 
+	if cfg.ClientCAfile != "" && !enableTLS {
+		return false, false, fmt.Errorf("TLS settings invalid: client_cafile requires keyfile and certfile")
+	}
+
 	return enableAuth, enableTLS, nil
 }
 
@@ -46,6 +85,13 @@ func (cfg AuthConfig) Validate() (bool, bool, error) {
 type ServerConfig struct {
 	Addr string
 	AuthConfig
+	// CacheTTL defines how long gathered metrics are reused by subsequent scrapes, zero disables caching.
+	CacheTTL time.Duration
+	// CollectorsStatus returns state of services' collectors served by '/debug/collectors' endpoint, nil disables
+	// the endpoint.
+	CollectorsStatus func() interface{}
+	// Gatherer is used for gathering served metrics, nil means prometheus.DefaultGatherer.
+	Gatherer prometheus.Gatherer
 }
 
 // Server defines HTTP server.
@@ -60,10 +106,28 @@ func NewServer(cfg ServerConfig) *Server {
 
 	mux.Handle("/", handleRoot())
 
-	if cfg.EnableAuth {
-		mux.Handle("/metrics", basicAuth(cfg.AuthConfig, promhttp.Handler()))
-	} else {
-		mux.Handle("/metrics", promhttp.Handler())
+	gatherer := cfg.Gatherer
+	if gatherer == nil {
+		gatherer = prometheus.DefaultGatherer
+	}
+
+	metricsHandler := handleMetrics(gatherer, cfg.CacheTTL)
+	logLevelHandler := handleLogLevel()
+
+	handlers := map[string]http.Handler{
+		"/metrics":         metricsHandler,
+		"/debug/log-level": logLevelHandler,
+	}
+
+	if cfg.CollectorsStatus != nil {
+		handlers["/debug/collectors"] = handleCollectors(cfg.CollectorsStatus)
+	}
+
+	for path, handler := range handlers {
+		if cfg.EnableAuth {
+			handler = authenticate(cfg.AuthConfig, handler)
+		}
+		mux.Handle(path, handler)
 	}
 
 	return &Server{
@@ -80,13 +144,118 @@ func NewServer(cfg ServerConfig) *Server {
 
 // Serve method starts listening and serving requests.
 func (s *Server) Serve() error {
+	if s.config.EnableTLS {
+		tlsConfig, reloader, err := newTLSConfig(s.config.AuthConfig)
+		if err != nil {
+			return err
+		}
+		s.server.TLSConfig = tlsConfig
+
+		// Reload certificate on SIGHUP, useful for certificates rotation.
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGHUP)
+		defer signal.Stop(sigCh)
+
+		go func() {
+			for range sigCh {
+				if err := reloader.reload(); err != nil {
+					log.Errorf("reload TLS certificate failed: %s, continue with previous one", err)
+					continue
+				}
+				log.Infoln("TLS certificate reloaded")
+			}
+		}()
+	}
+
+	ln, err := listen(s.server.Addr)
+	if err != nil {
+		return err
+	}
+
 	if s.config.EnableTLS {
 		log.Infof("listen on https://%s", s.server.Addr)
-		return s.server.ListenAndServeTLS(s.config.Certfile, s.config.Keyfile)
+		return s.server.ServeTLS(ln, "", "")
 	}
 
 	log.Infof("listen on http://%s", s.server.Addr)
-	return s.server.ListenAndServe()
+	return s.server.Serve(ln)
+}
+
+// unixAddressPrefix defines prefix of listen address used for listening on unix socket, e.g. 'unix:/run/pgscv.sock'.
+const unixAddressPrefix = "unix:"
+
+// ParseListenAddress checks listen address and returns its network and address. Address could be specified as
+// 'host:port' (IPv6 host should be enclosed in square brackets, e.g. '[::1]:9890'), or as path to unix socket
+// prefixed by 'unix:'.
+func ParseListenAddress(addr string) (string, string, error) {
+	if strings.HasPrefix(addr, unixAddressPrefix) {
+		path := strings.TrimPrefix(addr, unixAddressPrefix)
+		if path == "" {
+			return "", "", fmt.Errorf("invalid listen address '%s': empty unix socket path", addr)
+		}
+		return "unix", path, nil
+	}
+
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid listen address '%s': %s", addr, err)
+	}
+
+	if _, err := net.LookupPort("tcp", port); err != nil {
+		return "", "", fmt.Errorf("invalid listen address '%s': %s", addr, err)
+	}
+
+	return "tcp", addr, nil
+}
+
+// listen creates listener on passed address. Stale unix socket left after unclean shutdown is removed, socket is
+// considered stale when nobody accepts connections on it.
+func listen(addr string) (net.Listener, error) {
+	network, address, err := ParseListenAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if network == "unix" {
+		if err := removeStaleSocket(address); err != nil {
+			return nil, err
+		}
+	}
+
+	return net.Listen(network, address)
+}
+
+// removeStaleSocket removes unix socket at passed path if connection to the socket is refused. Socket which is in use
+// by another process is kept, and listening on it fails then.
+func removeStaleSocket(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		_ = conn.Close()
+		return nil
+	}
+
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return nil
+	}
+
+	log.Warnf("remove stale unix socket %s", path)
+	return os.Remove(path)
+}
+
+// Shutdown gracefully stops the server: stops accepting new requests and waits until in-flight requests are
+// finished. If context expires before, remaining connections are closed forcibly.
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.server.Shutdown(ctx)
+	if err != nil {
+		_ = s.server.Close()
+	}
+
+	return err
 }
 
 // handleRoot defines handler for '/' endpoint.
@@ -108,18 +277,195 @@ pgSCV / PostgreSQL metrics collector, for more info visit <a href="https://githu
 	})
 }
 
+// handleMetrics defines handler for '/metrics' endpoint, metrics are gathered using passed gatherer and cached if
+// positive TTL is passed. Metrics are served in OpenMetrics format (including exemplars) when client asks for it using
+// Accept header, and gzip-compressed when client asks for it using Accept-Encoding header.
+func handleMetrics(gatherer prometheus.Gatherer, ttl time.Duration) http.Handler {
+	if ttl > 0 {
+		g, err := newCachingGatherer(gatherer, prometheus.DefaultRegisterer, ttl)
+		if err != nil {
+			log.Errorf("create metrics cache failed: %s, continue without cache", err)
+		} else {
+			gatherer = g
+		}
+	}
+
+	handler := promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer, promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)
+
+	// Compression is done by promhttp, but response depends on Accept-Encoding, and intermediate caches should know it.
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// handleLogLevel defines handler for '/debug/log-level' endpoint: GET returns current log level, PUT sets new one.
+func handleLogLevel() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			_, err := fmt.Fprintln(w, log.GetLevel())
+			if err != nil {
+				log.Warnln("response write failed: ", err)
+			}
+		case http.MethodPut:
+			body, err := io.ReadAll(io.LimitReader(r.Body, 64))
+			if err != nil {
+				http.Error(w, err.Error(), StatusBadRequest)
+				return
+			}
+
+			level := strings.TrimSpace(string(body))
+			if err := log.UpdateLevel(level); err != nil {
+				http.Error(w, err.Error(), StatusBadRequest)
+				return
+			}
+
+			log.Infof("log level changed to '%s'", level)
+
+			_, err = fmt.Fprintln(w, log.GetLevel())
+			if err != nil {
+				log.Warnln("response write failed: ", err)
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// handleCollectors defines handler for '/debug/collectors' endpoint which returns state of services' collectors
+// in JSON format.
+func handleCollectors(status func() interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := json.MarshalIndent(status(), "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(append(body, '\n'))
+		if err != nil {
+			log.Warnln("response write failed: ", err)
+		}
+	})
+}
+
+// authenticate is a middleware for authentication, it uses bearer or basic authentication depending on AuthConfig.
+func authenticate(cfg AuthConfig, next http.Handler) http.Handler {
+	if cfg.BearerToken != "" {
+		return bearerAuth(cfg, next)
+	}
+
+	return basicAuth(cfg, next)
+}
+
+// bearerAuth is a middleware for bearer token authentication.
+func bearerAuth(cfg AuthConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(cfg.BearerToken)) == 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("WWW-Authenticate", `Bearer realm="restricted"`)
+		http.Error(w, "Unauthorized", StatusUnauthorized)
+	})
+}
+
 // basicAuth is a middleware for basic authentication.
 func basicAuth(cfg AuthConfig, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		username, password, ok := r.BasicAuth()
-		if ok {
-			if username == cfg.Username && password == cfg.Password {
-				next.ServeHTTP(w, r)
-				return
-			}
+		if ok && checkCredentials(cfg, username, password) {
+			next.ServeHTTP(w, r)
+			return
 		}
 
 		w.Header().Set("WWW-Authenticate", `Basic realm="restricted", charset="UTF-8"`)
 		http.Error(w, "Unauthorized", StatusUnauthorized)
 	})
 }
+
+// checkCredentials checks passed credentials against configured username and password (or password hash).
+func checkCredentials(cfg AuthConfig, username, password string) bool {
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(cfg.Username)) == 1
+
+	var passOK bool
+	if cfg.PasswordHash != "" {
+		passOK = bcrypt.CompareHashAndPassword([]byte(cfg.PasswordHash), []byte(password)) == nil
+	} else {
+		passOK = subtle.ConstantTimeCompare([]byte(password), []byte(cfg.Password)) == 1
+	}
+
+	return userOK && passOK
+}
+
+// newTLSConfig creates TLS configuration for HTTP server accordingly to passed AuthConfig.
+func newTLSConfig(cfg AuthConfig) (*tls.Config, *certReloader, error) {
+	reloader := &certReloader{certfile: cfg.Certfile, keyfile: cfg.Keyfile}
+	if err := reloader.reload(); err != nil {
+		return nil, nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	}
+
+	if cfg.ClientCAfile != "" {
+		data, err := os.ReadFile(filepath.Clean(cfg.ClientCAfile))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, nil, fmt.Errorf("no valid certificates found in %s", cfg.ClientCAfile)
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, reloader, nil
+}
+
+// certReloader keeps TLS certificate used by HTTP server and allows to reload it without restart.
+type certReloader struct {
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certfile string
+	keyfile  string
+}
+
+// reload loads certificate and key from files.
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certfile, r.keyfile)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.mu.Unlock()
+
+	return nil
+}
+
+// getCertificate returns current certificate, it implements tls.Config.GetCertificate.
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
@@ -1,14 +1,24 @@
 package http
 
 import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestAuthConfig_Validate(t *testing.T) {
@@ -23,8 +33,16 @@ func TestAuthConfig_Validate(t *testing.T) {
 		{valid: true, cfg: AuthConfig{Keyfile: "key", Certfile: "cert"}, wantAuth: false, wantTls: true},
 		{valid: false, cfg: AuthConfig{Username: "user", Password: ""}},
 		{valid: false, cfg: AuthConfig{Username: "", Password: "pass"}},
+		{valid: true, cfg: AuthConfig{Username: "user", PasswordHash: "$2a$04$4HKosZ1ehgJ1ctm5pHP00.z1djaZrqxYo5hMvTcsCSBmIpTYZ7w2."}, wantAuth: true, wantTls: false},
+		{valid: false, cfg: AuthConfig{Username: "user", PasswordHash: "invalid"}},
+		{valid: false, cfg: AuthConfig{Username: "user", Password: "pass", PasswordHash: "$2a$04$4HKosZ1ehgJ1ctm5pHP00.z1djaZrqxYo5hMvTcsCSBmIpTYZ7w2."}},
+		{valid: false, cfg: AuthConfig{PasswordHash: "$2a$04$4HKosZ1ehgJ1ctm5pHP00.z1djaZrqxYo5hMvTcsCSBmIpTYZ7w2."}},
+		{valid: true, cfg: AuthConfig{BearerToken: "token"}, wantAuth: true, wantTls: false},
+		{valid: false, cfg: AuthConfig{Username: "user", Password: "pass", BearerToken: "token"}},
 		{valid: false, cfg: AuthConfig{Keyfile: "key", Certfile: ""}},
 		{valid: false, cfg: AuthConfig{Keyfile: "", Certfile: "cert"}},
+		{valid: true, cfg: AuthConfig{Keyfile: "key", Certfile: "cert", ClientCAfile: "ca"}, wantAuth: false, wantTls: true},
+		{valid: false, cfg: AuthConfig{ClientCAfile: "ca"}},
 	}
 
 	for _, tc := range testcases {
@@ -96,6 +114,117 @@ func TestServer_Serve_HTTPS(t *testing.T) {
 	}
 }
 
+func TestServer_Shutdown(t *testing.T) {
+	addr := "127.0.0.1:17892"
+	srv := NewServer(ServerConfig{Addr: addr})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve()
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	cl := NewClient(ClientConfig{})
+	resp, err := cl.Get("http://" + addr + "/")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusOK, resp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, srv.Shutdown(ctx))
+	assert.ErrorIs(t, <-errCh, http.ErrServerClosed)
+
+	// Stopped server doesn't accept new requests.
+	_, err = cl.Get("http://" + addr + "/")
+	assert.Error(t, err)
+}
+
+func TestServer_Serve_Unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pgscv.sock")
+	srv := NewServer(ServerConfig{Addr: "unix:" + path})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve()
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	cl := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+
+	resp, err := cl.Get("http://localhost/metrics")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusOK, resp.StatusCode)
+	assert.NoError(t, resp.Body.Close())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, srv.Shutdown(ctx))
+	assert.ErrorIs(t, <-errCh, http.ErrServerClosed)
+}
+
+func Test_listen_unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pgscv.sock")
+
+	// Socket in use by another listener is kept.
+	l, err := listen("unix:" + path)
+	assert.NoError(t, err)
+
+	_, err = listen("unix:" + path)
+	assert.Error(t, err)
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+
+	// Stale socket left after unclean shutdown is removed.
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	assert.NoError(t, l.Close())
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+
+	l, err = listen("unix:" + path)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Close())
+}
+
+func TestParseListenAddress(t *testing.T) {
+	testcases := []struct {
+		valid   bool
+		addr    string
+		network string
+		address string
+	}{
+		{valid: true, addr: "127.0.0.1:9890", network: "tcp", address: "127.0.0.1:9890"},
+		{valid: true, addr: "[::1]:9890", network: "tcp", address: "[::1]:9890"},
+		{valid: true, addr: "[::]:9890", network: "tcp", address: "[::]:9890"},
+		{valid: true, addr: ":9890", network: "tcp", address: ":9890"},
+		{valid: true, addr: "localhost:9890", network: "tcp", address: "localhost:9890"},
+		{valid: true, addr: "unix:/run/pgscv.sock", network: "unix", address: "/run/pgscv.sock"},
+		{valid: false, addr: ""},
+		{valid: false, addr: "127.0.0.1"},
+		{valid: false, addr: "::1:9890"},
+		{valid: false, addr: "127.0.0.1:99999"},
+		{valid: false, addr: "unix:"},
+	}
+
+	for _, tc := range testcases {
+		network, address, err := ParseListenAddress(tc.addr)
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.network, network)
+			assert.Equal(t, tc.address, address)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
 func Test_handleRoot(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	res := httptest.NewRecorder()
@@ -112,6 +241,100 @@ func Test_handleRoot(t *testing.T) {
 	res.Flush()
 }
 
+func Test_handleMetrics(t *testing.T) {
+	for _, ttl := range []time.Duration{0, time.Second} {
+		handler := handleMetrics(prometheus.DefaultGatherer, ttl)
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		assert.Equal(t, StatusOK, res.Code)
+		assert.Contains(t, res.Header().Get("Content-Type"), "text/plain")
+
+		// OpenMetrics format is served when client asks for it.
+		req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		assert.Equal(t, StatusOK, res.Code)
+		assert.Contains(t, res.Header().Get("Content-Type"), "application/openmetrics-text")
+
+		// Response is not compressed unless client asks for it.
+		req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		assert.Equal(t, StatusOK, res.Code)
+		assert.Empty(t, res.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", res.Header().Get("Vary"))
+		assert.Contains(t, res.Body.String(), "# TYPE")
+
+		req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		assert.Equal(t, StatusOK, res.Code)
+		assert.Equal(t, "gzip", res.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", res.Header().Get("Vary"))
+
+		gz, err := gzip.NewReader(res.Body)
+		assert.NoError(t, err)
+		body, err := io.ReadAll(gz)
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), "# TYPE")
+	}
+}
+
+func Test_handleLogLevel(t *testing.T) {
+	defer log.SetLevel(log.GetLevel())
+	log.SetLevel("info")
+
+	testcases := []struct {
+		method string
+		body   string
+		status int
+		want   string
+	}{
+		{method: http.MethodGet, status: StatusOK, want: "info\n"},
+		{method: http.MethodPut, body: "debug\n", status: StatusOK, want: "debug\n"},
+		{method: http.MethodGet, status: StatusOK, want: "debug\n"},
+		{method: http.MethodPut, body: "invalid", status: StatusBadRequest},
+		{method: http.MethodGet, status: StatusOK, want: "debug\n"},
+		{method: http.MethodPost, body: "info", status: http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range testcases {
+		req := httptest.NewRequest(tc.method, "/debug/log-level", strings.NewReader(tc.body))
+		res := httptest.NewRecorder()
+
+		handleLogLevel().ServeHTTP(res, req)
+
+		assert.Equal(t, tc.status, res.Code)
+		if tc.want != "" {
+			assert.Equal(t, tc.want, res.Body.String())
+		}
+	}
+}
+
+func Test_handleCollectors(t *testing.T) {
+	status := func() interface{} {
+		return []map[string]string{{"service_id": "test:0", "error": "test"}}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/collectors", nil)
+	res := httptest.NewRecorder()
+	handleCollectors(status).ServeHTTP(res, req)
+
+	assert.Equal(t, StatusOK, res.Code)
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	assert.JSONEq(t, `[{"service_id": "test:0", "error": "test"}]`, res.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/debug/collectors", nil)
+	res = httptest.NewRecorder()
+	handleCollectors(status).ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+}
+
 func Test_basicAuth(t *testing.T) {
 	testcases := []struct {
 		name   string
@@ -126,17 +349,92 @@ func Test_basicAuth(t *testing.T) {
 		{name: "invalid pass", user: "user", pass: "invalid", status: StatusUnauthorized},
 	}
 
+	hash, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	for _, cfg := range []AuthConfig{
+		{Username: "user", Password: "pass"},
+		{Username: "user", PasswordHash: string(hash)},
+	} {
+		for _, tc := range testcases {
+			t.Run(tc.name, func(t *testing.T) {
+				mux := http.NewServeMux()
+				mux.Handle("/", basicAuth(cfg, handleRoot()))
+
+				res := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.SetBasicAuth(tc.user, tc.pass)
+				mux.ServeHTTP(res, req)
+				assert.Equal(t, tc.status, res.Code)
+				if tc.status == StatusUnauthorized {
+					assert.NotEmpty(t, res.Header().Get("WWW-Authenticate"))
+				}
+				res.Flush()
+			})
+		}
+	}
+}
+
+func Test_bearerAuth(t *testing.T) {
+	testcases := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{name: "valid", header: "Bearer token", status: StatusOK},
+		{name: "empty header", header: "", status: StatusUnauthorized},
+		{name: "empty token", header: "Bearer ", status: StatusUnauthorized},
+		{name: "invalid token", header: "Bearer invalid", status: StatusUnauthorized},
+		{name: "invalid scheme", header: "Basic token", status: StatusUnauthorized},
+	}
+
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
 			mux := http.NewServeMux()
-			mux.Handle("/", basicAuth(AuthConfig{Username: "user", Password: "pass"}, handleRoot()))
+			mux.Handle("/", authenticate(AuthConfig{BearerToken: "token"}, handleRoot()))
 
 			res := httptest.NewRecorder()
 			req := httptest.NewRequest(http.MethodGet, "/", nil)
-			req.SetBasicAuth(tc.user, tc.pass)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
 			mux.ServeHTTP(res, req)
 			assert.Equal(t, tc.status, res.Code)
-			res.Flush()
+			if tc.status == StatusUnauthorized {
+				assert.NotEmpty(t, res.Header().Get("WWW-Authenticate"))
+			}
 		})
 	}
 }
+
+func Test_newTLSConfig(t *testing.T) {
+	cfg, reloader, err := newTLSConfig(AuthConfig{Keyfile: "./testdata/example.key", Certfile: "./testdata/example.crt"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+	assert.Equal(t, tls.NoClientCert, cfg.ClientAuth)
+
+	cert, err := cfg.GetCertificate(nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, cert)
+
+	// Reloaded certificate replaces the previous one.
+	assert.NoError(t, reloader.reload())
+	cert2, err := cfg.GetCertificate(nil)
+	assert.NoError(t, err)
+	assert.NotSame(t, cert, cert2)
+
+	// Client certificates verification.
+	cfg, _, err = newTLSConfig(AuthConfig{Keyfile: "./testdata/example.key", Certfile: "./testdata/example.crt", ClientCAfile: "./testdata/example.crt"})
+	assert.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, cfg.ClientAuth)
+	assert.NotNil(t, cfg.ClientCAs)
+
+	for _, c := range []AuthConfig{
+		{Keyfile: "./testdata/invalid.key", Certfile: "./testdata/example.crt"},
+		{Keyfile: "./testdata/example.key", Certfile: "./testdata/example.crt", ClientCAfile: "./testdata/invalid.crt"},
+		{Keyfile: "./testdata/example.key", Certfile: "./testdata/example.crt", ClientCAfile: "./testdata/example.key"},
+	} {
+		_, _, err = newTLSConfig(c)
+		assert.Error(t, err)
+	}
+}
@@ -3,28 +3,76 @@ package log
 import (
 	"fmt"
 	"github.com/rs/zerolog"
+	"io"
 	"os"
+	"time"
 )
 
 // Logger is the global logger with predefined settings
 var Logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
 
+// output and format define where and how log messages are written.
+var (
+	output io.Writer = os.Stdout
+	format           = "json"
+)
+
 // KV is a simple key-value store
 type KV map[string]string
 
-// SetLevel sets logging level
+// levels defines supported logging levels.
+var levels = map[string]zerolog.Level{
+	"debug": zerolog.DebugLevel,
+	"info":  zerolog.InfoLevel,
+	"warn":  zerolog.WarnLevel,
+	"error": zerolog.ErrorLevel,
+}
+
+// SetLevel sets logging level, unknown level is considered as 'info'.
 func SetLevel(level string) {
-	switch level {
-	case "debug":
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	case "info":
+	if err := UpdateLevel(level); err != nil {
 		zerolog.SetGlobalLevel(zerolog.InfoLevel)
-	case "warn":
-		zerolog.SetGlobalLevel(zerolog.WarnLevel)
-	case "error":
-		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+	}
+}
+
+// UpdateLevel sets logging level, returns error if level is unknown. Level is changed atomically and affects
+// all goroutines, so it is safe to use at runtime.
+func UpdateLevel(level string) error {
+	l, ok := levels[level]
+	if !ok {
+		return fmt.Errorf("unknown log level '%s'", level)
+	}
+
+	zerolog.SetGlobalLevel(l)
+	return nil
+}
+
+// GetLevel returns current logging level.
+func GetLevel() string {
+	return zerolog.GlobalLevel().String()
+}
+
+// SetFormat sets logging format: 'json' (default) prints every message as JSON object per line, 'text' prints
+// messages in human-readable form.
+func SetFormat(f string) {
+	format = f
+	Logger = Logger.Output(writer())
+}
+
+// SetOutput sets destination of log messages, by default messages are written to stdout. Format set using SetFormat
+// is kept.
+func SetOutput(w io.Writer) {
+	output = w
+	Logger = Logger.Output(writer())
+}
+
+// writer returns writer of log messages accordingly to configured output and format.
+func writer() io.Writer {
+	switch format {
+	case "text":
+		return zerolog.ConsoleWriter{Out: output, NoColor: true, TimeFormat: time.RFC3339}
 	default:
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		return output
 	}
 }
 
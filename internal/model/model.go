@@ -2,7 +2,10 @@ package model
 
 import (
 	"database/sql"
+	"fmt"
 	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/cherts/pgscv/internal/filter"
 	"github.com/jackc/pgproto3/v2"
@@ -33,6 +36,8 @@ type PGResult struct {
 //
 //  collectors:                                                 <- Collectors (root level in YAML)
 //    postgres/archiver:                                        <- CollectorSettings
+//      options:                                                <- CollectorSettings.Options
+//        key: value                                            <- collector-specific option
 //      filters:                                                <- CollectorSettings.Filters
 //        query:                                                <- label
 //          exclude: "(UPDATE|DELETE)"                          <- exclude metrics with labels contains these values
@@ -57,10 +62,81 @@ type CollectorsSettings map[string]CollectorSettings
 
 // CollectorSettings unions all settings related to a single collector.
 type CollectorSettings struct {
+	// Enabled defines whether collector is enabled, collectors are enabled by default.
+	Enabled *bool `yaml:"enabled"`
 	// Filters defines label-based filters applied to metrics.
 	Filters filter.Filters `yaml:"filters"`
 	// Subsystems defines subsystem with user-defined metrics.
 	Subsystems Subsystems `yaml:"subsystems"`
+	// Options defines collector-specific options.
+	Options CollectorOptions `yaml:"options"`
+	// Interval defines how often collector should be run in background, metrics are cached between runs and served
+	// during scrapes. Background run is limited by the interval instead of collector_timeout. Zero means collector
+	// runs on every scrape.
+	Interval time.Duration `yaml:"interval"`
+}
+
+// CollectorOptions defines collector-specific options in key/value form.
+type CollectorOptions map[string]string
+
+// Bool returns boolean value of the option, or default value if option is not specified.
+func (o CollectorOptions) Bool(key string, def bool) (bool, error) {
+	v, ok := o[key]
+	if !ok {
+		return def, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def, fmt.Errorf("invalid value '%s' of option '%s': %s", v, key, err)
+	}
+
+	return b, nil
+}
+
+// Float returns float value of the option, or default value if option is not specified.
+func (o CollectorOptions) Float(key string, def float64) (float64, error) {
+	v, ok := o[key]
+	if !ok {
+		return def, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return def, fmt.Errorf("invalid value '%s' of option '%s': %s", v, key, err)
+	}
+
+	return f, nil
+}
+
+// Int returns integer value of the option, or default value if option is not specified.
+func (o CollectorOptions) Int(key string, def int) (int, error) {
+	v, ok := o[key]
+	if !ok {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return def, fmt.Errorf("invalid value '%s' of option '%s': %s", v, key, err)
+	}
+
+	return i, nil
+}
+
+// Duration returns duration value of the option, or default value if option is not specified.
+func (o CollectorOptions) Duration(key string, def time.Duration) (time.Duration, error) {
+	v, ok := o[key]
+	if !ok {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def, fmt.Errorf("invalid value '%s' of option '%s': %s", v, key, err)
+	}
+
+	return d, nil
 }
 
 // Subsystems unions all subsystems in one place.
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCollectorOptions_Bool(t *testing.T) {
+	o := CollectorOptions{"enabled": "true", "invalid": "invalid"}
+
+	v, err := o.Bool("enabled", false)
+	assert.NoError(t, err)
+	assert.True(t, v)
+
+	v, err = o.Bool("unknown", true)
+	assert.NoError(t, err)
+	assert.True(t, v)
+
+	_, err = o.Bool("invalid", false)
+	assert.Error(t, err)
+}
+
+func TestCollectorOptions_Float(t *testing.T) {
+	o := CollectorOptions{"value": "100.5", "invalid": "invalid"}
+
+	v, err := o.Float("value", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 100.5, v)
+
+	v, err = o.Float("unknown", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(10), v)
+
+	_, err = o.Float("invalid", 0)
+	assert.Error(t, err)
+}
+
+func TestCollectorOptions_Int(t *testing.T) {
+	o := CollectorOptions{"value": "100", "invalid": "1.5"}
+
+	v, err := o.Int("value", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, v)
+
+	v, err = o.Int("unknown", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, v)
+
+	_, err = o.Int("invalid", 0)
+	assert.Error(t, err)
+}
+
+func TestCollectorOptions_Duration(t *testing.T) {
+	o := CollectorOptions{"value": "5m", "invalid": "100"}
+
+	v, err := o.Duration("value", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, v)
+
+	v, err = o.Duration("unknown", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, v)
+
+	_, err = o.Duration("invalid", 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,39 @@
+package pgscv
+
+import (
+	"errors"
+	"runtime"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// BuildInfo defines application's build information, it is injected at link time.
+type BuildInfo struct {
+	Version string
+	Commit  string
+	Branch  string
+}
+
+// registerBuildInfo registers pgscv_build_info metric using passed registerer.
+func registerBuildInfo(info BuildInfo, registerer prometheus.Registerer) error {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "pgscv_build_info",
+		Help: "Labeled information about pgSCV build.",
+		ConstLabels: prometheus.Labels{
+			"version":   info.Version,
+			"commit":    info.Commit,
+			"branch":    info.Branch,
+			"goversion": runtime.Version(),
+		},
+	})
+	g.Set(1)
+
+	if err := registerer.Register(g); err != nil {
+		are := prometheus.AlreadyRegisteredError{}
+		if !errors.As(err, &are) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package pgscv
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_registerBuildInfo(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	info := BuildInfo{Version: "v1.0.0", Commit: "abcdef", Branch: "master"}
+
+	assert.NoError(t, registerBuildInfo(info, reg))
+	// Repeated registration is not an error.
+	assert.NoError(t, registerBuildInfo(info, reg))
+
+	want := `# HELP pgscv_build_info Labeled information about pgSCV build.
+# TYPE pgscv_build_info gauge
+pgscv_build_info{branch="master",commit="abcdef",goversion="` + runtime.Version() + `",version="v1.0.0"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "pgscv_build_info"))
+}
@@ -6,12 +6,17 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/cherts/pgscv/internal/collector"
 	"github.com/cherts/pgscv/internal/http"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/remotewrite"
 	"github.com/cherts/pgscv/internal/service"
+	"github.com/cherts/pgscv/internal/store"
 	"github.com/jackc/pgx/v4"
 	"gopkg.in/yaml.v2"
 )
@@ -22,8 +27,14 @@ const (
 	defaultPostgresDbname    = "postgres"
 	defaultPgbouncerUsername = "pgscv"
 	defaultPgbouncerDbname   = "pgbouncer"
+	defaultProcfsPath        = "/proc"
+	defaultSysfsPath         = "/sys"
+	defaultShutdownTimeout   = 10 * time.Second
 )
 
+// labelNameRE defines valid name of label.
+var labelNameRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
 // Config defines application's configuration.
 type Config struct {
 	NoTrackMode           bool                     `yaml:"no_track_mode"`      // controls tracking sensitive information (query texts, etc)
@@ -34,7 +45,19 @@ type Config struct {
 	CollectorsSettings    model.CollectorsSettings `yaml:"collectors"`         // Collectors settings propagated from main YAML configuration
 	Databases             string                   `yaml:"databases"`          // Regular expression string specifies databases from which metrics should be collected
 	DatabasesRE           *regexp.Regexp           // Regular expression object compiled from Databases
-	AuthConfig            http.AuthConfig          `yaml:"authentication"` // TLS and Basic auth configuration
+	AuthConfig            http.AuthConfig          `yaml:"authentication"`     // TLS and Basic auth configuration
+	ProcfsPath            string                   `yaml:"procfs_path"`        // Path where procfs is mounted, useful when running in container
+	SysfsPath             string                   `yaml:"sysfs_path"`         // Path where sysfs is mounted, useful when running in container
+	CollectorTimeout      time.Duration            `yaml:"collector_timeout"`  // Max time allowed to a single collector for collecting metrics, zero means no timeout
+	Concurrency           int                      `yaml:"concurrency"`        // Max number of collectors running concurrently, zero means no limit
+	DiscoveryInterval     time.Duration            `yaml:"discovery_interval"` // Interval of local Postgres services auto-discovery, zero means discovery is disabled
+	RemoteWrite           remotewrite.Config       `yaml:"remote_write"`       // Settings of pushing metrics using Prometheus remote-write protocol
+	CacheTTL              time.Duration            `yaml:"cache_ttl"`          // Time during which gathered metrics are reused by subsequent scrapes, zero means no caching
+	ShutdownTimeout       time.Duration            `yaml:"shutdown_timeout"`   // Max time allowed to finish in-flight scrapes during shutdown
+	Namespace             string                   `yaml:"namespace"`          // Custom prefix for names of all metrics collected from services, empty means no prefix
+	ExternalLabels        map[string]string        `yaml:"external_labels"`    // Labels attached to all metrics collected from all services
+	ConnPool              store.PoolConfig         `yaml:"conn_pool"`          // Settings of connections pool used by collectors
+	BuildInfo             BuildInfo                `yaml:"-"`                  // Application's build information
 }
 
 // NewConfig creates new config based on config file or return default config if config file is not specified.
@@ -98,6 +121,52 @@ func (c *Config) Validate() error {
 		c.ListenAddress = defaultListenAddress
 	}
 
+	if _, _, err := http.ParseListenAddress(c.ListenAddress); err != nil {
+		return err
+	}
+
+	if c.ProcfsPath == "" {
+		c.ProcfsPath = defaultProcfsPath
+	}
+
+	if c.SysfsPath == "" {
+		c.SysfsPath = defaultSysfsPath
+	}
+
+	if c.CollectorTimeout < 0 {
+		return fmt.Errorf("invalid collector_timeout: %s, must be positive", c.CollectorTimeout)
+	}
+
+	if c.Concurrency < 0 {
+		return fmt.Errorf("invalid concurrency: %d, must be positive", c.Concurrency)
+	}
+
+	if c.DiscoveryInterval < 0 {
+		return fmt.Errorf("invalid discovery_interval: %s, must be positive", c.DiscoveryInterval)
+	}
+
+	if c.CacheTTL < 0 {
+		return fmt.Errorf("invalid cache_ttl: %s, must be positive", c.CacheTTL)
+	}
+
+	if c.Namespace != "" && !labelNameRE.MatchString(c.Namespace) {
+		return fmt.Errorf("invalid namespace: '%s', must match %s", c.Namespace, labelNameRE.String())
+	}
+
+	for name := range c.ExternalLabels {
+		if name == "service_id" || !labelNameRE.MatchString(name) {
+			return fmt.Errorf("invalid external label name '%s'", name)
+		}
+	}
+
+	if c.ShutdownTimeout < 0 {
+		return fmt.Errorf("invalid shutdown_timeout: %s, must be positive", c.ShutdownTimeout)
+	}
+
+	if c.ShutdownTimeout == 0 {
+		c.ShutdownTimeout = defaultShutdownTimeout
+	}
+
 	if c.NoTrackMode {
 		log.Infoln("no-track enabled for [pg_stat_statements.query].")
 	} else {
@@ -137,10 +206,22 @@ func (c *Config) Validate() error {
 					return fmt.Errorf("empty service_type for %s", k)
 				}
 
-				_, err := pgx.ParseConfig(s.Conninfo)
+				cs, err := service.ApplyConnParams(s)
+				if err != nil {
+					return fmt.Errorf("invalid connection settings for %s: %s", k, err)
+				}
+				c.ServicesConnsSettings[k] = cs
+
+				_, err = pgx.ParseConfig(cs.Conninfo)
 				if err != nil {
 					return fmt.Errorf("invalid conninfo for %s: %s", k, err)
 				}
+
+				for name := range s.Labels {
+					if name == "service_id" || !labelNameRE.MatchString(name) {
+						return fmt.Errorf("invalid label name '%s' for %s", name, k)
+					}
+				}
 			}
 		}
 	}
@@ -158,6 +239,18 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	// Validate remote-write settings.
+	err = c.RemoteWrite.Validate()
+	if err != nil {
+		return err
+	}
+
+	// Validate connections pool settings.
+	err = c.ConnPool.Validate()
+	if err != nil {
+		return err
+	}
+
 	// Validate authentication settings.
 	enableAuth, enableTLS, err := c.AuthConfig.Validate()
 	if err != nil {
@@ -176,7 +269,7 @@ func validateCollectorSettings(cs model.CollectorsSettings) error {
 	}
 
 	for csName, settings := range cs {
-		re1 := regexp.MustCompile(`^[a-zA-Z0-9]+/[a-zA-Z0-9]+$`)
+		re1 := regexp.MustCompile(`^[a-zA-Z0-9]+/[a-zA-Z0-9_]+$`)
 		if !re1.MatchString(csName) {
 			return fmt.Errorf("invalid collector name: %s", csName)
 		}
@@ -186,6 +279,10 @@ func validateCollectorSettings(cs model.CollectorsSettings) error {
 			return err
 		}
 
+		if settings.Interval < 0 {
+			return fmt.Errorf("invalid interval for collector %s: %s, must be positive", csName, settings.Interval)
+		}
+
 		// Validate subsystems level
 		for ssName, subsys := range settings.Subsystems {
 			re2 := regexp.MustCompilePOSIX(`^[a-zA-Z0-9_]+$`)
@@ -217,6 +314,15 @@ func validateCollectorSettings(cs model.CollectorsSettings) error {
 					return fmt.Errorf("value and labeled_values cannot be used together for metric '%s'", m.ShortName)
 				}
 
+				for _, l := range m.Labels {
+					if l == m.Value {
+						return fmt.Errorf("column '%s' cannot be used both as value and label for metric '%s'", l, m.ShortName)
+					}
+					if !reMetric.MatchString(l) {
+						return fmt.Errorf("invalid label name '%s' for metric '%s'", l, m.ShortName)
+					}
+				}
+
 				usage := m.Usage
 				switch usage {
 				case "COUNTER", "GAUGE":
@@ -230,6 +336,12 @@ func validateCollectorSettings(cs model.CollectorsSettings) error {
 					return fmt.Errorf("invalid metric usage '%s'", usage)
 				}
 			}
+
+			// Check columns used by metrics are returned by query.
+			err = collector.CheckSubsystemColumns(subsys)
+			if err != nil {
+				return fmt.Errorf("invalid subsystem '%s': %s", ssName, err)
+			}
 		}
 	}
 
@@ -245,6 +357,8 @@ func newConfigFromEnv() (*Config, error) {
 		ServicesConnsSettings: map[string]service.ConnSetting{},
 	}
 
+	var managed bool
+
 	for _, env := range os.Environ() {
 		if !strings.HasPrefix(env, "PGSCV_") &&
 			!strings.HasPrefix(env, "POSTGRES_DSN") &&
@@ -306,10 +420,67 @@ func newConfigFromEnv() (*Config, error) {
 			config.AuthConfig.Username = value
 		case "PGSCV_AUTH_PASSWORD":
 			config.AuthConfig.Password = value
+		case "PGSCV_AUTH_PASSWORD_HASH":
+			config.AuthConfig.PasswordHash = value
+		case "PGSCV_AUTH_BEARER_TOKEN":
+			config.AuthConfig.BearerToken = value
 		case "PGSCV_AUTH_KEYFILE":
 			config.AuthConfig.Keyfile = value
 		case "PGSCV_AUTH_CERTFILE":
 			config.AuthConfig.Certfile = value
+		case "PGSCV_AUTH_CLIENT_CAFILE":
+			config.AuthConfig.ClientCAfile = value
+		case "PGSCV_PROCFS_PATH":
+			config.ProcfsPath = value
+		case "PGSCV_SYSFS_PATH":
+			config.SysfsPath = value
+		case "PGSCV_COLLECTOR_TIMEOUT":
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PGSCV_COLLECTOR_TIMEOUT value '%s': %s", value, err)
+			}
+			config.CollectorTimeout = timeout
+		case "PGSCV_CONCURRENCY":
+			concurrency, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PGSCV_CONCURRENCY value '%s': %s", value, err)
+			}
+			config.Concurrency = concurrency
+		case "PGSCV_DISCOVERY_INTERVAL":
+			interval, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PGSCV_DISCOVERY_INTERVAL value '%s': %s", value, err)
+			}
+			config.DiscoveryInterval = interval
+		case "PGSCV_CACHE_TTL":
+			ttl, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PGSCV_CACHE_TTL value '%s': %s", value, err)
+			}
+			config.CacheTTL = ttl
+		case "PGSCV_SHUTDOWN_TIMEOUT":
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PGSCV_SHUTDOWN_TIMEOUT value '%s': %s", value, err)
+			}
+			config.ShutdownTimeout = timeout
+		case "PGSCV_NAMESPACE":
+			config.Namespace = value
+		case "PGSCV_MANAGED":
+			switch value {
+			case "y", "yes", "Yes", "YES", "t", "true", "True", "TRUE", "1", "on":
+				managed = true
+			default:
+				managed = false
+			}
+		}
+	}
+
+	// Services defined using environment variables have no own settings, all of them are considered as managed.
+	if managed {
+		for id, cs := range config.ServicesConnsSettings {
+			cs.Managed = true
+			config.ServicesConnsSettings[id] = cs
 		}
 	}
 
@@ -3,11 +3,13 @@ package pgscv
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/cherts/pgscv/internal/filter"
 	"github.com/cherts/pgscv/internal/http"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/service"
+	"github.com/cherts/pgscv/internal/store"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -155,6 +157,26 @@ func TestConfig_Validate(t *testing.T) {
 				"pgbouncer:6432": {ServiceType: model.ServiceTypePgbouncer, Conninfo: "host=127.0.0.1 port=6432 dbname=pgbouncer user=pgscv password=pgscv"},
 			}},
 		},
+		{
+			name:  "valid config with external labels",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ExternalLabels: map[string]string{"environment": "prod", "datacenter": "dc1"}},
+		},
+		{
+			name:  "valid config with IPv6 listen address",
+			valid: true,
+			in:    &Config{ListenAddress: "[::1]:8080"},
+		},
+		{
+			name:  "valid config with unix socket listen address",
+			valid: true,
+			in:    &Config{ListenAddress: "unix:/run/pgscv.sock"},
+		},
+		{
+			name:  "invalid config with listen address without port",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1"},
+		},
 		{
 			name:  "invalid config with specified services: empty service type",
 			valid: false,
@@ -176,6 +198,27 @@ func TestConfig_Validate(t *testing.T) {
 				"test": {ServiceType: model.ServiceTypePostgresql, Conninfo: "invalid"},
 			}},
 		},
+		{
+			name:  "valid config with specified services: service labels",
+			valid: true,
+			in: &Config{ListenAddress: "127.0.0.1:8080", ServicesConnsSettings: service.ConnsSettings{
+				"test": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1", Labels: map[string]string{"cluster": "main"}},
+			}},
+		},
+		{
+			name:  "invalid config with specified services: invalid label name",
+			valid: false,
+			in: &Config{ListenAddress: "127.0.0.1:8080", ServicesConnsSettings: service.ConnsSettings{
+				"test": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1", Labels: map[string]string{"invalid-name": "main"}},
+			}},
+		},
+		{
+			name:  "invalid config with specified services: reserved label name",
+			valid: false,
+			in: &Config{ListenAddress: "127.0.0.1:8080", ServicesConnsSettings: service.ConnsSettings{
+				"test": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1", Labels: map[string]string{"service_id": "main"}},
+			}},
+		},
 		{
 			name:  "invalid config: invalid databases string",
 			valid: false,
@@ -186,6 +229,56 @@ func TestConfig_Validate(t *testing.T) {
 			valid: false,
 			in:    &Config{ListenAddress: "127.0.0.1:8080", AuthConfig: http.AuthConfig{Username: "user"}},
 		},
+		{
+			name:  "invalid config: negative collector timeout",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", CollectorTimeout: -1},
+		},
+		{
+			name:  "invalid config: negative concurrency",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", Concurrency: -1},
+		},
+		{
+			name:  "invalid config: negative discovery interval",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", DiscoveryInterval: -1},
+		},
+		{
+			name:  "invalid config: negative cache ttl",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", CacheTTL: -1},
+		},
+		{
+			name:  "invalid config: negative shutdown timeout",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ShutdownTimeout: -1},
+		},
+		{
+			name:  "invalid config: invalid namespace",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", Namespace: "my-namespace"},
+		},
+		{
+			name:  "valid config with connections pool",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ConnPool: store.PoolConfig{MaxOpen: 4, MaxIdle: 2, MaxLifetime: time.Minute}},
+		},
+		{
+			name:  "invalid config: connections pool max_idle greater than max_open",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ConnPool: store.PoolConfig{MaxOpen: 1, MaxIdle: 2}},
+		},
+		{
+			name:  "invalid config: invalid external label name",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ExternalLabels: map[string]string{"data-center": "dc1"}},
+		},
+		{
+			name:  "invalid config: reserved external label name",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ExternalLabels: map[string]string{"service_id": "example"}},
+		},
 		{
 			name:  "invalid config: invalid TLS",
 			valid: false,
@@ -212,6 +305,8 @@ func Test_validateCollectorSettings(t *testing.T) {
 	}{
 		{valid: true, settings: nil},
 		{valid: true, settings: make(map[string]model.CollectorSettings)},
+		{valid: true, settings: map[string]model.CollectorSettings{"postgres/replication_slots": {}}},
+		{valid: true, settings: map[string]model.CollectorSettings{"postgres/schemas": {Interval: 5 * time.Minute}}},
 		{
 			valid: true,
 			settings: map[string]model.CollectorSettings{
@@ -246,6 +341,7 @@ func Test_validateCollectorSettings(t *testing.T) {
 		},
 		// invalid collectors names
 		{valid: false, settings: map[string]model.CollectorSettings{"invalid": {}}},
+		{valid: false, settings: map[string]model.CollectorSettings{"postgres/schemas": {Interval: -1}}},
 		{valid: false, settings: map[string]model.CollectorSettings{"invalid/": {}}},
 		{valid: false, settings: map[string]model.CollectorSettings{"/invalid": {}}},
 		{valid: false, settings: map[string]model.CollectorSettings{"example/inva:lid": {}}},
@@ -293,6 +389,36 @@ func Test_validateCollectorSettings(t *testing.T) {
 				},
 			},
 		},
+		{
+			valid: false, // Value column used as label
+			settings: map[string]model.CollectorSettings{
+				"example/example": {
+					Subsystems: map[string]model.MetricsSubsystem{
+						"example1": {
+							Query: "SELECT 'L1' as label1, 1 as value1",
+							Metrics: model.Metrics{
+								{ShortName: "v1", Usage: "COUNTER", Value: "value1", Labels: []string{"label1", "value1"}, Description: "v1 description"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			valid: false, // Invalid label name
+			settings: map[string]model.CollectorSettings{
+				"example/example": {
+					Subsystems: map[string]model.MetricsSubsystem{
+						"example1": {
+							Query: "SELECT 'L1' as \"label:1\", 1 as value1",
+							Metrics: model.Metrics{
+								{ShortName: "v1", Usage: "COUNTER", Value: "value1", Labels: []string{"label:1"}, Description: "v1 description"},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			valid: false, // Empty metric descriptor
 			settings: map[string]model.CollectorSettings{
@@ -365,6 +491,21 @@ func Test_validateCollectorSettings(t *testing.T) {
 				},
 			},
 		},
+		{
+			valid: false, // Label column is not returned by query
+			settings: map[string]model.CollectorSettings{
+				"example/example": {
+					Subsystems: map[string]model.MetricsSubsystem{
+						"example1": {
+							Query: "SELECT 'L1' as label1, 1 as value1",
+							Metrics: model.Metrics{
+								{ShortName: "v1", Usage: "COUNTER", Value: "value1", Labels: []string{"label2"}, Description: "description"},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -404,6 +545,15 @@ func Test_newConfigFromEnv(t *testing.T) {
 				"PGSCV_AUTH_PASSWORD":      "pass",
 				"PGSCV_AUTH_KEYFILE":       "keyfile.key",
 				"PGSCV_AUTH_CERTFILE":      "certfile.cert",
+				"PGSCV_PROCFS_PATH":        "/host/proc",
+				"PGSCV_SYSFS_PATH":         "/host/sys",
+				"PGSCV_COLLECTOR_TIMEOUT":  "10s",
+				"PGSCV_CONCURRENCY":        "1",
+				"PGSCV_DISCOVERY_INTERVAL": "1m",
+				"PGSCV_CACHE_TTL":          "5s",
+				"PGSCV_SHUTDOWN_TIMEOUT":   "15s",
+				"PGSCV_NAMESPACE":          "acme",
+				"PGSCV_MANAGED":            "on",
 			},
 			want: &Config{
 				ListenAddress:     "127.0.0.1:12345",
@@ -411,12 +561,12 @@ func Test_newConfigFromEnv(t *testing.T) {
 				Databases:         "exampledb",
 				DisableCollectors: []string{"example/1", "example/2", "example/3"},
 				ServicesConnsSettings: map[string]service.ConnSetting{
-					"postgres":  {ServiceType: model.ServiceTypePostgresql, Conninfo: "example_dsn"},
-					"EXAMPLE1":  {ServiceType: model.ServiceTypePostgresql, Conninfo: "example_dsn"},
-					"pgbouncer": {ServiceType: model.ServiceTypePgbouncer, Conninfo: "example_dsn"},
-					"EXAMPLE2":  {ServiceType: model.ServiceTypePgbouncer, Conninfo: "example_dsn"},
-					"patroni":   {ServiceType: model.ServiceTypePatroni, BaseURL: "example_url"},
-					"EXAMPLE3":  {ServiceType: model.ServiceTypePatroni, BaseURL: "example_url"},
+					"postgres":  {ServiceType: model.ServiceTypePostgresql, Conninfo: "example_dsn", Managed: true},
+					"EXAMPLE1":  {ServiceType: model.ServiceTypePostgresql, Conninfo: "example_dsn", Managed: true},
+					"pgbouncer": {ServiceType: model.ServiceTypePgbouncer, Conninfo: "example_dsn", Managed: true},
+					"EXAMPLE2":  {ServiceType: model.ServiceTypePgbouncer, Conninfo: "example_dsn", Managed: true},
+					"patroni":   {ServiceType: model.ServiceTypePatroni, BaseURL: "example_url", Managed: true},
+					"EXAMPLE3":  {ServiceType: model.ServiceTypePatroni, BaseURL: "example_url", Managed: true},
 				},
 				AuthConfig: http.AuthConfig{
 					Username: "user",
@@ -424,7 +574,15 @@ func Test_newConfigFromEnv(t *testing.T) {
 					Keyfile:  "keyfile.key",
 					Certfile: "certfile.cert",
 				},
-				Defaults: map[string]string{},
+				ProcfsPath:        "/host/proc",
+				SysfsPath:         "/host/sys",
+				CollectorTimeout:  10 * time.Second,
+				Concurrency:       1,
+				DiscoveryInterval: time.Minute,
+				CacheTTL:          5 * time.Second,
+				ShutdownTimeout:   15 * time.Second,
+				Namespace:         "acme",
+				Defaults:          map[string]string{},
 			},
 		},
 		{
@@ -439,6 +597,26 @@ func Test_newConfigFromEnv(t *testing.T) {
 			valid:   false, // Invalid patroni URL key
 			envvars: map[string]string{"PATRONI_URL_": "example_dsn"},
 		},
+		{
+			valid:   false, // Invalid concurrency
+			envvars: map[string]string{"PGSCV_CONCURRENCY": "invalid"},
+		},
+		{
+			valid:   false, // Invalid collector timeout
+			envvars: map[string]string{"PGSCV_COLLECTOR_TIMEOUT": "invalid"},
+		},
+		{
+			valid:   false, // Invalid discovery interval
+			envvars: map[string]string{"PGSCV_DISCOVERY_INTERVAL": "invalid"},
+		},
+		{
+			valid:   false, // Invalid cache TTL
+			envvars: map[string]string{"PGSCV_CACHE_TTL": "invalid"},
+		},
+		{
+			valid:   false, // Invalid shutdown timeout
+			envvars: map[string]string{"PGSCV_SHUTDOWN_TIMEOUT": "invalid"},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -3,31 +3,58 @@ package pgscv
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/cherts/pgscv/internal/http"
 	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/remotewrite"
 	"github.com/cherts/pgscv/internal/service"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
 	"sync"
 )
 
 // Start is the application's starting point.
 func Start(ctx context.Context, config *Config) error {
+	return Run(ctx, config, nil)
+}
+
+// Run starts the application and applies configs received from reload channel. Configs should be validated using
+// Config.Validate and Check before sending. Services and collectors are replaced with ones defined in received config
+// while HTTP listener and remote-write keep running with initial settings. If services could not be set up using new
+// config, the application continues running with the previous one.
+func Run(ctx context.Context, config *Config, reload <-chan *Config) error {
 	log.Debug("start application")
 
 	serviceRepo := service.NewRepository()
 
-	serviceConfig := service.Config{
-		NoTrackMode:        config.NoTrackMode,
-		ConnDefaults:       config.Defaults,
-		ConnsSettings:      config.ServicesConnsSettings,
-		DatabasesRE:        config.DatabasesRE,
-		DisabledCollectors: config.DisableCollectors,
-		CollectorsSettings: config.CollectorsSettings,
-	}
+	serviceConfig := newServiceConfig(config)
 
-	if len(config.ServicesConnsSettings) == 0 {
+	if len(config.ServicesConnsSettings) == 0 && config.DiscoveryInterval == 0 {
 		return errors.New("no services defined")
 	}
 
+	// register self-metrics regardless of services and collectors
+	if err := registerBuildInfo(config.BuildInfo, prometheus.DefaultRegisterer); err != nil {
+		return err
+	}
+
+	// setup connections pool used by collectors
+	poolConfig := config.ConnPool
+	store.SetPoolConfig(poolConfig)
+	defer store.ClosePools()
+
+	poolCollector := store.NewPoolCollector()
+	if err := registerPoolCollector(poolConfig, prometheus.DefaultRegisterer, poolCollector); err != nil {
+		return err
+	}
+
 	// fulfill service repo using passed services
 	serviceRepo.AddServicesFromConfig(serviceConfig)
 
@@ -37,6 +64,25 @@ func Start(ctx context.Context, config *Config) error {
 		return err
 	}
 
+	// Services, their config and collectors are replaced on reload, the lock protects them from collectors status
+	// readers and metrics gatherers.
+	var repoMu sync.RWMutex
+	collectorsStatus := func() interface{} {
+		repoMu.RLock()
+		defer repoMu.RUnlock()
+		return serviceRepo.CollectorsStatus(serviceConfig)
+	}
+	gatherer := lockedGatherer{mu: &repoMu, gatherer: prometheus.DefaultGatherer}
+
+	// setup remote-write if enabled
+	var writer *remotewrite.Writer
+	if config.RemoteWrite.Enabled() {
+		writer, err = remotewrite.NewWriter(config.RemoteWrite, gatherer, prometheus.DefaultRegisterer)
+		if err != nil {
+			return err
+		}
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	var wg sync.WaitGroup
 
@@ -46,37 +92,345 @@ func Start(ctx context.Context, config *Config) error {
 	// Start HTTP metrics listener.
 	wg.Add(1)
 	go func() {
-		if err := runMetricsListener(ctx, config); err != nil {
+		if err := runMetricsListener(ctx, config, gatherer, collectorsStatus); err != nil {
 			errCh <- err
 		}
 		wg.Done()
 	}()
 
-	// Waiting for errors or context cancelling.
+	// Start pushing metrics to remote-write endpoint.
+	if writer != nil {
+		wg.Add(1)
+		go func() {
+			writer.Run(ctx)
+			wg.Done()
+		}()
+	}
+
+	// Start auto-discovery of local services.
+	stopDiscovery := startDiscovery(ctx, serviceRepo, serviceConfig)
+
+	// Waiting for errors, configs reloads or context cancelling.
 	for {
 		select {
 		case <-ctx.Done():
 			log.Info("exit signaled, stop application")
 			cancel()
+			stopDiscovery()
 			wg.Wait()
+			log.Info("all components stopped")
 			return nil
 		case e := <-errCh:
 			cancel()
+			stopDiscovery()
 			wg.Wait()
 			return e
+		case newConfig := <-reload:
+			log.Info("reload configuration")
+			stopDiscovery()
+
+			newServiceConfig := newServiceConfig(newConfig)
+			newRepo, err := newServices(newServiceConfig)
+			if err == nil {
+				// Scrapes are paused while collectors are swapped, and never observe partially registered services.
+				repoMu.Lock()
+				err = swapServices(serviceRepo, serviceConfig, newRepo, newServiceConfig)
+				if err == nil {
+					serviceRepo, serviceConfig = newRepo, newServiceConfig
+				}
+				repoMu.Unlock()
+			}
+			if err != nil {
+				log.Errorf("reload configuration failed: %s; continue with previous configuration", err)
+				stopDiscovery = startDiscovery(ctx, serviceRepo, serviceConfig)
+				continue
+			}
+
+			stopDiscovery = startDiscovery(ctx, serviceRepo, serviceConfig)
+
+			// Pooled connections are kept unless pool settings are changed, only pools of removed services are closed.
+			if newConfig.ConnPool != poolConfig {
+				poolConfig = newConfig.ConnPool
+				store.SetPoolConfig(poolConfig)
+				store.ClosePools()
+			}
+			if err := registerPoolCollector(poolConfig, prometheus.DefaultRegisterer, poolCollector); err != nil {
+				log.Warnf("register connections pool metrics failed: %s", err)
+			}
+
+			log.Info("configuration reloaded; listener, authentication and remote-write settings require restart to be applied")
+		}
+	}
+}
+
+// startDiscovery starts auto-discovery of local services if it is enabled and returns function which stops discovery
+// and waits until it is finished.
+func startDiscovery(ctx context.Context, repo *service.Repository, config service.Config) func() {
+	if config.DiscoveryInterval == 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		repo.Discovery(ctx, config)
+		close(done)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// newServices creates repo with services defined in passed config and creates their collectors. Collectors are not
+// registered, but they are checked to be registered together without conflicts.
+func newServices(config service.Config) (*service.Repository, error) {
+	if len(config.ConnsSettings) == 0 && config.DiscoveryInterval == 0 {
+		return nil, errors.New("no services defined")
+	}
+
+	repo := service.NewRepository()
+	repo.AddServicesFromConfig(config)
+
+	if err := repo.CreateCollectors(config); err != nil {
+		repo.StopCollectors()
+		return nil, err
+	}
+
+	if err := repo.ValidateCollectors(config); err != nil {
+		repo.StopCollectors()
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// swapServices replaces registered collectors of services from passed repo with collectors of services from new repo,
+// stops replaced collectors and closes pooled connections of services absent in new repo. If new collectors could not
+// be registered, collectors of passed repo are restored and new collectors are stopped.
+func swapServices(repo *service.Repository, config service.Config, newRepo *service.Repository, newConfig service.Config) error {
+	repo.UnregisterCollectors(config)
+
+	if err := newRepo.RegisterCollectors(newConfig); err != nil {
+		newRepo.UnregisterCollectors(newConfig)
+		newRepo.StopCollectors()
+		if err := repo.RegisterCollectors(config); err != nil {
+			log.Errorf("restore collectors failed: %s", err)
+		}
+		return err
+	}
+
+	repo.StopCollectors()
+
+	kept := map[string]bool{}
+	for _, id := range newRepo.ServiceIDs() {
+		kept[id] = true
+	}
+	for _, id := range repo.ServiceIDs() {
+		if !kept[id] {
+			store.CloseServicePools(id)
+		}
+	}
+
+	return nil
+}
+
+// registerPoolCollector registers passed connections pool metrics collector if pooling is enabled, and unregisters it
+// otherwise.
+func registerPoolCollector(cfg store.PoolConfig, registerer prometheus.Registerer, c prometheus.Collector) error {
+	if !cfg.Enabled() {
+		registerer.Unregister(c)
+		return nil
+	}
+
+	if err := registerer.Register(c); err != nil {
+		are := prometheus.AlreadyRegisteredError{}
+		if !errors.As(err, &are) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lockedGatherer gathers metrics using wrapped gatherer under read lock, so gathering waits while collectors are
+// being swapped under write lock.
+type lockedGatherer struct {
+	mu       *sync.RWMutex
+	gatherer prometheus.Gatherer
+}
+
+// Gather implements prometheus.Gatherer.
+func (g lockedGatherer) Gather() ([]*dto.MetricFamily, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.gatherer.Gather()
+}
+
+// Check validates configuration more thoroughly than Config.Validate without starting the application: referenced
+// files should exist and collectors should be created successfully. Summary of services and collectors which would be
+// enabled is written to passed writer. Columns used by custom queries' metrics are checked by Config.Validate, but SQL of
+// custom queries is not checked, because it requires connecting to services, such queries are listed in the summary. Config should be validated using Config.Validate before.
+func Check(config *Config, w io.Writer) error {
+	files := map[string]string{
+		"keyfile":       config.AuthConfig.Keyfile,
+		"certfile":      config.AuthConfig.Certfile,
+		"client_cafile": config.AuthConfig.ClientCAfile,
+	}
+
+	for name, path := range files {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("invalid %s: %s", name, err)
+		}
+	}
+
+	if len(config.ServicesConnsSettings) == 0 && config.DiscoveryInterval == 0 {
+		return errors.New("no services defined")
+	}
+
+	enabled, err := service.CheckCollectors(newServiceConfig(config))
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(enabled))
+	for id := range enabled {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	_, _ = fmt.Fprintf(w, "listen address: %s\n", config.ListenAddress)
+	if config.DiscoveryInterval > 0 {
+		_, _ = fmt.Fprintf(w, "auto-discovery: enabled, interval %s\n", config.DiscoveryInterval)
+	} else {
+		_, _ = fmt.Fprintln(w, "auto-discovery: disabled")
+	}
+	if config.RemoteWrite.Enabled() {
+		_, _ = fmt.Fprintf(w, "remote-write: %s, interval %s\n", config.RemoteWrite.URL, config.RemoteWrite.Interval)
+	}
+
+	for _, id := range ids {
+		stype := model.ServiceTypeSystem
+		if cs, ok := config.ServicesConnsSettings[id]; ok {
+			stype = cs.ServiceType
+			if cs.Managed {
+				stype += ", managed"
+			}
+		}
+		names := "none"
+		if len(enabled[id]) > 0 {
+			names = strings.Join(enabled[id], ", ")
+		}
+		_, _ = fmt.Fprintf(w, "service [%s] (%s): %s\n", id, stype, names)
+	}
+
+	if queries := customQueries(config.CollectorsSettings); len(queries) > 0 {
+		_, _ = fmt.Fprintf(w, "SQL of custom queries is not checked: %s\n", strings.Join(queries, ", "))
+		_, _ = fmt.Fprintln(w, "config is checked, except SQL of custom queries")
+		return nil
+	}
+
+	_, _ = fmt.Fprintln(w, "config is valid")
+	return nil
+}
+
+// customQueries returns sorted names of collectors' subsystems with custom queries, in 'collector/subsystem' form.
+func customQueries(settings model.CollectorsSettings) []string {
+	var names []string
+	for csName, cs := range settings {
+		for ssName, subsys := range cs.Subsystems {
+			if subsys.Query != "" {
+				names = append(names, csName+"/"+ssName)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Collect gathers metrics from all services once and writes them to passed writer in Prometheus text format. HTTP
+// listener, remote-write and periodic auto-discovery are not started, local services are discovered once if
+// auto-discovery is enabled.
+func Collect(config *Config, w io.Writer) error {
+	log.Debug("collect metrics once")
+
+	serviceRepo := service.NewRepository()
+
+	serviceConfig := newServiceConfig(config)
+
+	if len(config.ServicesConnsSettings) == 0 && config.DiscoveryInterval == 0 {
+		return errors.New("no services defined")
+	}
+
+	if err := registerBuildInfo(config.BuildInfo, prometheus.DefaultRegisterer); err != nil {
+		return err
+	}
+
+	serviceRepo.AddServicesFromConfig(serviceConfig)
+
+	if err := serviceRepo.SetupServices(serviceConfig); err != nil {
+		return err
+	}
+
+	if config.DiscoveryInterval > 0 {
+		if err := serviceRepo.DiscoverServices(serviceConfig); err != nil {
+			return err
 		}
 	}
+
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return err
+	}
+
+	enc := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newServiceConfig creates services configuration from application configuration.
+func newServiceConfig(config *Config) service.Config {
+	return service.Config{
+		NoTrackMode:        config.NoTrackMode,
+		ConnDefaults:       config.Defaults,
+		ConnsSettings:      config.ServicesConnsSettings,
+		DatabasesRE:        config.DatabasesRE,
+		DisabledCollectors: config.DisableCollectors,
+		CollectorsSettings: config.CollectorsSettings,
+		ProcfsPath:         config.ProcfsPath,
+		SysfsPath:          config.SysfsPath,
+		CollectorTimeout:   config.CollectorTimeout,
+		Concurrency:        config.Concurrency,
+		DiscoveryInterval:  config.DiscoveryInterval,
+		Namespace:          config.Namespace,
+		ExternalLabels:     config.ExternalLabels,
+	}
 }
 
-// runMetricsListener start HTTP listener accordingly to passed configuration.
-func runMetricsListener(ctx context.Context, config *Config) error {
+// runMetricsListener start HTTP listener accordingly to passed configuration. Metrics are gathered using passed
+// gatherer. Passed function is used for serving state of services' collectors, nil disables it.
+func runMetricsListener(ctx context.Context, config *Config, gatherer prometheus.Gatherer, collectorsStatus func() interface{}) error {
 	srv := http.NewServer(http.ServerConfig{
-		Addr:       config.ListenAddress,
-		AuthConfig: config.AuthConfig,
+		Addr:             config.ListenAddress,
+		AuthConfig:       config.AuthConfig,
+		CacheTTL:         config.CacheTTL,
+		CollectorsStatus: collectorsStatus,
+		Gatherer:         gatherer,
 	})
 
-	errCh := make(chan error)
-	defer close(errCh)
+	// Buffered channel allows listener goroutine to exit when nobody waits for its result.
+	errCh := make(chan error, 1)
 
 	// Run default listener.
 	go func() {
@@ -84,13 +438,21 @@ func runMetricsListener(ctx context.Context, config *Config) error {
 	}()
 
 	// Waiting for errors or context cancelling.
-	for {
-		select {
-		case <-ctx.Done():
-			log.Info("exit signaled, stop metrics listener")
+	select {
+	case <-ctx.Done():
+		log.Infof("exit signaled, stop metrics listener, wait up to %s for in-flight scrapes", config.ShutdownTimeout)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Warnf("in-flight scrapes are not finished in time, connections closed forcibly: %s", err)
 			return nil
-		case err := <-errCh:
-			return err
 		}
+
+		log.Info("metrics listener stopped")
+		return nil
+	case err := <-errCh:
+		return err
 	}
 }
@@ -1,11 +1,13 @@
 package pgscv
 
 import (
+	"bytes"
 	"context"
 	"github.com/cherts/pgscv/internal/http"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/service"
 	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"sync"
@@ -31,6 +33,164 @@ func TestStart(t *testing.T) {
 	assert.NoError(t, Start(ctx, config))
 }
 
+func TestRun(t *testing.T) {
+	config := &Config{
+		ListenAddress: "127.0.0.1:5004",
+		ServicesConnsSettings: map[string]service.ConnSetting{
+			"postgres:5432": {ServiceType: model.ServiceTypePostgresql, Conninfo: store.TestPostgresConnStr},
+		},
+		Namespace: "reload_old",
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	reload := make(chan *Config)
+	errCh := make(chan error)
+	go func() { errCh <- Run(ctx, config, reload) }()
+
+	// Invalid config is rejected, valid one is applied.
+	reload <- &Config{Namespace: "reload_invalid"}
+	reload <- &Config{
+		ServicesConnsSettings: config.ServicesConnsSettings,
+		Namespace:             "reload_new",
+	}
+
+	assert.NoError(t, <-errCh)
+}
+
+func Test_newServices(t *testing.T) {
+	_, err := newServices(service.Config{})
+	assert.Error(t, err)
+
+	config := service.Config{Namespace: "new_services", DiscoveryInterval: time.Minute}
+	repo, err := newServices(config)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"system:0"}, repo.ServiceIDs())
+
+	// Collectors are created but not registered.
+	assert.NoError(t, repo.RegisterCollectors(config))
+	repo.UnregisterCollectors(config)
+}
+
+func Test_swapServices(t *testing.T) {
+	config := service.Config{Namespace: "swap_services"}
+	repo := service.NewRepository()
+	repo.AddServicesFromConfig(config)
+	assert.NoError(t, repo.SetupServices(config))
+
+	// Collectors conflict with already registered ones, previous collectors should be kept registered.
+	conflictRepo, err := newServices(service.Config{Namespace: "swap_services_conflict", DiscoveryInterval: time.Minute})
+	assert.NoError(t, err)
+	conflictConfig := service.Config{Namespace: "swap_services_conflict"}
+	assert.NoError(t, conflictRepo.RegisterCollectors(conflictConfig))
+
+	newConfig := service.Config{Namespace: "swap_services_conflict", DiscoveryInterval: time.Minute}
+	newRepo, err := newServices(newConfig)
+	assert.NoError(t, err)
+	assert.Error(t, swapServices(repo, config, newRepo, newConfig))
+	assert.Error(t, repo.RegisterCollectors(config))
+	conflictRepo.UnregisterCollectors(conflictConfig)
+
+	// New collectors are registered instead of previous ones.
+	newRepo, err = newServices(newConfig)
+	assert.NoError(t, err)
+	assert.NoError(t, swapServices(repo, config, newRepo, newConfig))
+	assert.NoError(t, repo.RegisterCollectors(config))
+	assert.Error(t, newRepo.RegisterCollectors(newConfig))
+
+	repo.UnregisterCollectors(config)
+	newRepo.UnregisterCollectors(newConfig)
+}
+
+func Test_registerPoolCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c := store.NewPoolCollector()
+
+	assert.NoError(t, registerPoolCollector(store.PoolConfig{MaxIdle: 1}, reg, c))
+	assert.NoError(t, registerPoolCollector(store.PoolConfig{MaxIdle: 1}, reg, c))
+	assert.False(t, reg.Register(c) == nil)
+
+	// Pooling is disabled, collector is unregistered.
+	assert.NoError(t, registerPoolCollector(store.PoolConfig{}, reg, c))
+	assert.NoError(t, reg.Register(c))
+}
+
+func Test_lockedGatherer(t *testing.T) {
+	var mu sync.RWMutex
+	g := lockedGatherer{mu: &mu, gatherer: prometheus.NewRegistry()}
+
+	mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		_, err := g.Gather()
+		assert.NoError(t, err)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("gathering should wait for unlock")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	mu.Unlock()
+	<-done
+}
+
+func TestCheck(t *testing.T) {
+	config := &Config{
+		ServicesConnsSettings: map[string]service.ConnSetting{
+			"postgres:5432":  {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1 dbname=pgscv_fixtures user=pgscv"},
+			"pgbouncer:6432": {ServiceType: model.ServiceTypePgbouncer, Conninfo: "host=127.0.0.1 port=6432 dbname=pgbouncer user=pgscv"},
+		},
+		DisableCollectors: []string{"system", "postgres/custom"},
+	}
+	assert.NoError(t, config.Validate())
+
+	var buf bytes.Buffer
+	assert.NoError(t, Check(config, &buf))
+	assert.Contains(t, buf.String(), "listen address: 127.0.0.1:9890\n")
+	assert.Contains(t, buf.String(), "service [pgbouncer:6432] (pgbouncer): pgbouncer/pgscv, pgbouncer/pools, pgbouncer/settings, pgbouncer/stats\n")
+	assert.Contains(t, buf.String(), "service [system:0] (system): none\n")
+	assert.Contains(t, buf.String(), "service [postgres:5432] (postgres): postgres/activity")
+	assert.NotContains(t, buf.String(), "postgres/custom")
+	assert.Contains(t, buf.String(), "config is valid\n")
+
+	// SQL of custom queries is not checked, config is not reported as valid.
+	config.CollectorsSettings = model.CollectorsSettings{
+		"postgres/custom": {Subsystems: model.Subsystems{"example": {Query: "SELECT 1 AS value"}, "empty": {}}},
+	}
+	buf.Reset()
+	assert.NoError(t, Check(config, &buf))
+	assert.Contains(t, buf.String(), "SQL of custom queries is not checked: postgres/custom/example\n")
+	assert.NotContains(t, buf.String(), "config is valid")
+
+	// Referenced files must exist.
+	config.AuthConfig.Keyfile = "testdata/not-exist.key"
+	assert.Error(t, Check(config, io.Discard))
+
+	// Services must be defined.
+	assert.Error(t, Check(&Config{}, io.Discard))
+}
+
+func TestCollect(t *testing.T) {
+	// Use namespace to avoid conflicts with collectors registered by other tests.
+	config := &Config{
+		ServicesConnsSettings: map[string]service.ConnSetting{
+			"postgres:5432": {ServiceType: model.ServiceTypePostgresql, Conninfo: store.TestPostgresConnStr},
+		},
+		Namespace: "collect",
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, Collect(config, &buf))
+	assert.Contains(t, buf.String(), "# TYPE pgscv_build_info gauge\n")
+	assert.Contains(t, buf.String(), "collect_node_")
+
+	// Services must be defined.
+	assert.Error(t, Collect(&Config{}, io.Discard))
+}
+
 func Test_runMetricsListener(t *testing.T) {
 	config := &Config{ListenAddress: "127.0.0.1:5003"}
 	wg := sync.WaitGroup{}
@@ -41,7 +201,7 @@ func Test_runMetricsListener(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 		defer cancel()
 
-		err := runMetricsListener(ctx, config)
+		err := runMetricsListener(ctx, config, prometheus.DefaultGatherer, nil)
 		assert.NoError(t, err)
 		wg.Done()
 	}()
@@ -0,0 +1,176 @@
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// label describes single label of time series.
+type label struct {
+	name  string
+	value string
+}
+
+// sample describes single sample of time series.
+type sample struct {
+	value     float64
+	timestamp int64 // milliseconds since epoch
+}
+
+// timeSeries describes single time series with its labels and samples.
+type timeSeries struct {
+	labels  []label
+	samples []sample
+}
+
+// convertMetricFamilies converts gathered metric families into remote-write time series. Samples without explicit
+// timestamp get passed timestamp (in milliseconds).
+func convertMetricFamilies(families []*dto.MetricFamily, ts int64) []timeSeries {
+	var series []timeSeries
+
+	for _, mf := range families {
+		name := mf.GetName()
+
+		for _, m := range mf.GetMetric() {
+			t := ts
+			if m.TimestampMs != nil {
+				t = m.GetTimestampMs()
+			}
+
+			add := func(suffix string, value float64, extra ...label) {
+				labels := make([]label, 0, len(m.GetLabel())+len(extra)+1)
+				labels = append(labels, label{name: "__name__", value: name + suffix})
+				for _, lp := range m.GetLabel() {
+					labels = append(labels, label{name: lp.GetName(), value: lp.GetValue()})
+				}
+				labels = append(labels, extra...)
+
+				// Remote-write protocol requires labels sorted by name.
+				sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+
+				series = append(series, timeSeries{labels: labels, samples: []sample{{value: value, timestamp: t}}})
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				add("", m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add("", m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add("", m.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					add("", q.GetValue(), label{name: "quantile", value: formatFloat(q.GetQuantile())})
+				}
+				add("_sum", s.GetSampleSum())
+				add("_count", float64(s.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				var hasInf bool
+				for _, b := range h.GetBucket() {
+					if math.IsInf(b.GetUpperBound(), +1) {
+						hasInf = true
+					}
+					add("_bucket", float64(b.GetCumulativeCount()), label{name: "le", value: formatFloat(b.GetUpperBound())})
+				}
+				if !hasInf {
+					add("_bucket", float64(h.GetSampleCount()), label{name: "le", value: "+Inf"})
+				}
+				add("_sum", h.GetSampleSum())
+				add("_count", float64(h.GetSampleCount()))
+			}
+		}
+	}
+
+	return series
+}
+
+// formatFloat formats float value in the same way as Prometheus exposition format does.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, +1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// encodeWriteRequest encodes time series into protobuf-serialized remote-write WriteRequest message:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(series []timeSeries) []byte {
+	var buf []byte
+
+	for _, s := range series {
+		var ts []byte
+
+		for _, l := range s.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+
+		for _, smpl := range s.samples {
+			var sb []byte
+			sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(smpl.value))
+			sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+			sb = protowire.AppendVarint(sb, uint64(smpl.timestamp))
+
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sb)
+		}
+
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, ts)
+	}
+
+	return buf
+}
+
+// snappyEncode encodes data into Snappy block format required by remote-write protocol. Data is stored as a sequence
+// of literals without compression, which is valid Snappy stream and could be decoded by any Snappy decoder.
+func snappyEncode(data []byte) []byte {
+	// Maximum length of a single literal chunk, keeps length encoded in at most 2 bytes.
+	const maxLiteral = 65536
+
+	buf := protowire.AppendVarint(make([]byte, 0, len(data)+len(data)/maxLiteral*3+16), uint64(len(data)))
+
+	for len(data) > 0 {
+		n := len(data)
+		if n > maxLiteral {
+			n = maxLiteral
+		}
+
+		switch l := n - 1; {
+		case l < 60:
+			buf = append(buf, byte(l)<<2)
+		case l < 1<<8:
+			buf = append(buf, 60<<2, byte(l))
+		default:
+			buf = append(buf, 61<<2, byte(l), byte(l>>8))
+		}
+
+		buf = append(buf, data[:n]...)
+		data = data[n:]
+	}
+
+	return buf
+}
@@ -0,0 +1,147 @@
+package remotewrite
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func Test_convertMetricFamilies(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total", Help: "test"}, []string{"b", "a"})
+	counter.WithLabelValues("1", "2").Add(10)
+
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_seconds", Help: "test", Buckets: []float64{1, 5}})
+	histogram.Observe(3)
+
+	summary := prometheus.NewSummary(prometheus.SummaryOpts{Name: "test_summary", Help: "test", Objectives: map[float64]float64{0.5: 0.05}})
+	summary.Observe(2)
+
+	reg.MustRegister(counter, histogram, summary)
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+
+	got := convertMetricFamilies(families, 1000)
+
+	want := []timeSeries{
+		{labels: []label{{"__name__", "test_seconds_bucket"}, {"le", "1"}}, samples: []sample{{0, 1000}}},
+		{labels: []label{{"__name__", "test_seconds_bucket"}, {"le", "5"}}, samples: []sample{{1, 1000}}},
+		{labels: []label{{"__name__", "test_seconds_bucket"}, {"le", "+Inf"}}, samples: []sample{{1, 1000}}},
+		{labels: []label{{"__name__", "test_seconds_sum"}}, samples: []sample{{3, 1000}}},
+		{labels: []label{{"__name__", "test_seconds_count"}}, samples: []sample{{1, 1000}}},
+		{labels: []label{{"__name__", "test_summary"}, {"quantile", "0.5"}}, samples: []sample{{2, 1000}}},
+		{labels: []label{{"__name__", "test_summary_sum"}}, samples: []sample{{2, 1000}}},
+		{labels: []label{{"__name__", "test_summary_count"}}, samples: []sample{{1, 1000}}},
+		{labels: []label{{"__name__", "test_total"}, {"a", "2"}, {"b", "1"}}, samples: []sample{{10, 1000}}},
+	}
+	assert.Equal(t, want, got)
+
+	// Explicit timestamp of metric is preserved.
+	families = []*dto.MetricFamily{{
+		Name:   strPtr("test"),
+		Type:   dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{{Gauge: &dto.Gauge{Value: f64Ptr(1)}, TimestampMs: i64Ptr(500)}},
+	}}
+	assert.Equal(t, []timeSeries{
+		{labels: []label{{"__name__", "test"}}, samples: []sample{{1, 500}}},
+	}, convertMetricFamilies(families, 1000))
+}
+
+func Test_formatFloat(t *testing.T) {
+	assert.Equal(t, "+Inf", formatFloat(math.Inf(+1)))
+	assert.Equal(t, "-Inf", formatFloat(math.Inf(-1)))
+	assert.Equal(t, "NaN", formatFloat(math.NaN()))
+	assert.Equal(t, "0.25", formatFloat(0.25))
+}
+
+func Test_encodeWriteRequest(t *testing.T) {
+	buf := encodeWriteRequest([]timeSeries{
+		{labels: []label{{"__name__", "test"}}, samples: []sample{{1.5, 1000}}},
+	})
+
+	// WriteRequest -> TimeSeries.
+	num, typ, n := protowire.ConsumeTag(buf)
+	assert.Equal(t, protowire.Number(1), num)
+	assert.Equal(t, protowire.BytesType, typ)
+	ts, m := protowire.ConsumeBytes(buf[n:])
+	assert.Equal(t, len(buf), n+m)
+
+	// TimeSeries -> Label.
+	num, _, n = protowire.ConsumeTag(ts)
+	assert.Equal(t, protowire.Number(1), num)
+	lb, m := protowire.ConsumeBytes(ts[n:])
+	ts = ts[n+m:]
+
+	_, _, n = protowire.ConsumeTag(lb)
+	name, m := protowire.ConsumeString(lb[n:])
+	assert.Equal(t, "__name__", name)
+	lb = lb[n+m:]
+	_, _, n = protowire.ConsumeTag(lb)
+	value, _ := protowire.ConsumeString(lb[n:])
+	assert.Equal(t, "test", value)
+
+	// TimeSeries -> Sample.
+	num, _, n = protowire.ConsumeTag(ts)
+	assert.Equal(t, protowire.Number(2), num)
+	sb, _ := protowire.ConsumeBytes(ts[n:])
+
+	_, _, n = protowire.ConsumeTag(sb)
+	v, m := protowire.ConsumeFixed64(sb[n:])
+	assert.Equal(t, 1.5, math.Float64frombits(v))
+	sb = sb[n+m:]
+	_, _, n = protowire.ConsumeTag(sb)
+	tsv, _ := protowire.ConsumeVarint(sb[n:])
+	assert.Equal(t, uint64(1000), tsv)
+}
+
+func Test_snappyEncode(t *testing.T) {
+	assert.Equal(t, []byte{0x03, 0x08, 'a', 'b', 'c'}, snappyEncode([]byte("abc")))
+	assert.Equal(t, []byte{0x00}, snappyEncode(nil))
+
+	for _, size := range []int{59, 60, 61, 256, 257, 65536, 65537, 200000} {
+		data := bytes.Repeat([]byte{'x'}, size)
+		got, err := snappyDecode(snappyEncode(data))
+		assert.NoError(t, err)
+		assert.Equal(t, data, got)
+	}
+}
+
+// snappyDecode decodes Snappy block consisting of literals only.
+func snappyDecode(src []byte) ([]byte, error) {
+	size, n := protowire.ConsumeVarint(src)
+	if n < 0 {
+		return nil, protowire.ParseError(n)
+	}
+	src = src[n:]
+
+	dst := make([]byte, 0, size)
+	for len(src) > 0 {
+		tag := src[0]
+		src = src[1:]
+
+		l := int(tag >> 2)
+		switch l {
+		case 60:
+			l, src = int(src[0]), src[1:]
+		case 61:
+			l, src = int(src[0])|int(src[1])<<8, src[2:]
+		}
+		l++
+
+		dst = append(dst, src[:l]...)
+		src = src[l:]
+	}
+
+	return dst, nil
+}
+
+func strPtr(s string) *string   { return &s }
+func f64Ptr(v float64) *float64 { return &v }
+func i64Ptr(v int64) *int64     { return &v }
@@ -0,0 +1,209 @@
+// Package remotewrite implements pushing metrics to remote storage using Prometheus remote-write protocol.
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultInterval = time.Minute
+	defaultTimeout  = 10 * time.Second
+	// maxRetries defines number of retries of failed request.
+	maxRetries = 3
+	// initialBackoff defines delay before first retry, delay is doubled on every next retry.
+	initialBackoff = time.Second
+)
+
+// Config defines remote-write settings.
+type Config struct {
+	URL         string        `yaml:"url"`          // URL of remote-write endpoint
+	Interval    time.Duration `yaml:"interval"`     // Interval between pushes
+	Timeout     time.Duration `yaml:"timeout"`      // Timeout of single push request
+	Username    string        `yaml:"username"`     // Username used for basic authentication
+	Password    string        `yaml:"password"`     // Password used for basic authentication
+	BearerToken string        `yaml:"bearer_token"` // Bearer token used for authentication, alternative to basic auth
+}
+
+// Enabled returns true if remote-write is configured.
+func (cfg Config) Enabled() bool {
+	return cfg.URL != ""
+}
+
+// Validate checks remote-write settings and set defaults.
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return fmt.Errorf("invalid remote_write url: %s", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid remote_write url: unsupported scheme '%s'", u.Scheme)
+	}
+
+	if cfg.Interval < 0 || cfg.Timeout < 0 {
+		return fmt.Errorf("invalid remote_write interval or timeout: must be positive")
+	}
+
+	if (cfg.Username == "") != (cfg.Password == "") {
+		return fmt.Errorf("invalid remote_write authentication: username and password must be specified together")
+	}
+
+	if cfg.Username != "" && cfg.BearerToken != "" {
+		return fmt.Errorf("invalid remote_write authentication: basic auth and bearer token are mutually exclusive")
+	}
+
+	if cfg.Interval == 0 {
+		cfg.Interval = defaultInterval
+	}
+
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	return nil
+}
+
+// Writer periodically gathers metrics and pushes them to remote-write endpoint.
+type Writer struct {
+	config   Config
+	client   *http.Client
+	gatherer prometheus.Gatherer
+	errors   prometheus.Counter
+	backoff  time.Duration
+}
+
+// NewWriter creates new Writer which pushes metrics gathered from passed gatherer.
+func NewWriter(cfg Config, gatherer prometheus.Gatherer, registerer prometheus.Registerer) (*Writer, error) {
+	errorsTotal := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pgscv_remote_write_errors_total",
+		Help: "Total number of failed remote-write requests.",
+	})
+
+	if err := registerer.Register(errorsTotal); err != nil {
+		are := prometheus.AlreadyRegisteredError{}
+		if !errors.As(err, &are) {
+			return nil, err
+		}
+		errorsTotal = are.ExistingCollector.(prometheus.Counter)
+	}
+
+	return &Writer{
+		config:   cfg,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		gatherer: gatherer,
+		errors:   errorsTotal,
+		backoff:  initialBackoff,
+	}, nil
+}
+
+// Run pushes metrics with configured interval until context is cancelled.
+func (w *Writer) Run(ctx context.Context) {
+	log.Infof("remote-write enabled, push metrics to %s every %s", w.config.URL, w.config.Interval)
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("exit signaled, stop remote-write")
+			return
+		case <-ticker.C:
+			if err := w.push(ctx); err != nil {
+				log.Errorf("remote-write failed: %s", err)
+			}
+		}
+	}
+}
+
+// push gathers metrics and sends them to remote-write endpoint, failed requests are retried with backoff.
+func (w *Writer) push(ctx context.Context) error {
+	families, err := w.gatherer.Gather()
+	if err != nil {
+		// Gather returns all metrics it could collect, continue with them.
+		log.Warnf("gather metrics: %s", err)
+	}
+
+	series := convertMetricFamilies(families, time.Now().UnixMilli())
+	if len(series) == 0 {
+		return nil
+	}
+
+	body := snappyEncode(encodeWriteRequest(series))
+
+	backoff := w.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.send(ctx, body)
+		if err == nil {
+			log.Debugf("remote-write: pushed %d series", len(series))
+			return nil
+		}
+
+		w.errors.Inc()
+
+		if !retry || attempt >= maxRetries {
+			return err
+		}
+
+		log.Warnf("remote-write: %s, retry in %s", err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// send makes single remote-write request and returns error and flag whether the request should be retried.
+func (w *Writer) send(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("User-Agent", "pgscv")
+
+	if w.config.Username != "" {
+		req.SetBasicAuth(w.config.Username, w.config.Password)
+	} else if w.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+w.config.BearerToken)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		// Network errors are worth to retry.
+		return true, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("bad response: %s: %s", resp.Status, bytes.TrimSpace(msg))
+
+	// Server errors and rate limiting are worth to retry, client errors are not.
+	return resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests, err
+}
@@ -0,0 +1,116 @@
+package remotewrite
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	testcases := []struct {
+		valid bool
+		cfg   Config
+		want  Config
+	}{
+		{valid: true, cfg: Config{}, want: Config{}},
+		{
+			valid: true,
+			cfg:   Config{URL: "http://127.0.0.1:9090/api/v1/write"},
+			want:  Config{URL: "http://127.0.0.1:9090/api/v1/write", Interval: defaultInterval, Timeout: defaultTimeout},
+		},
+		{
+			valid: true,
+			cfg:   Config{URL: "https://example.org/write", Interval: time.Second, BearerToken: "token"},
+			want:  Config{URL: "https://example.org/write", Interval: time.Second, Timeout: defaultTimeout, BearerToken: "token"},
+		},
+		{valid: false, cfg: Config{URL: "ftp://example.org"}},
+		{valid: false, cfg: Config{URL: "://invalid"}},
+		{valid: false, cfg: Config{URL: "http://example.org", Interval: -1}},
+		{valid: false, cfg: Config{URL: "http://example.org", Username: "user"}},
+		{valid: false, cfg: Config{URL: "http://example.org", Username: "user", Password: "pass", BearerToken: "token"}},
+	}
+
+	for _, tc := range testcases {
+		err := tc.cfg.Validate()
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, tc.cfg)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestWriter_push(t *testing.T) {
+	var requests int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "pass", pass)
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, body)
+
+		// Fail first request, it should be retried.
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	w, err := NewWriter(Config{URL: srv.URL, Username: "user", Password: "pass", Timeout: time.Second}, reg, reg)
+	assert.NoError(t, err)
+	w.backoff = time.Millisecond
+
+	assert.NoError(t, w.push(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, float64(1), testutil.ToFloat64(w.errors))
+
+	// Writer with the same registerer reuses registered counter.
+	w2, err := NewWriter(Config{URL: srv.URL}, reg, reg)
+	assert.NoError(t, err)
+	assert.Equal(t, w.errors, w2.errors)
+}
+
+func TestWriter_push_failed(t *testing.T) {
+	var requests int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	w, err := NewWriter(Config{URL: srv.URL, BearerToken: "token", Timeout: time.Second}, reg, reg)
+	assert.NoError(t, err)
+	w.backoff = time.Millisecond
+
+	// Client errors are not retried.
+	assert.Error(t, w.push(context.Background()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Equal(t, float64(1), testutil.ToFloat64(w.errors))
+
+	// Unavailable endpoint, retries are exhausted.
+	srv.Close()
+	assert.Error(t, w.push(context.Background()))
+	assert.Equal(t, float64(2+maxRetries), testutil.ToFloat64(w.errors))
+}
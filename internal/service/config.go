@@ -2,6 +2,10 @@ package service
 
 import (
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/cherts/pgscv/internal/model"
@@ -16,11 +20,151 @@ type ConnSetting struct {
 	Conninfo string `yaml:"conninfo"`
 	// BaseURL is the base URL for connecting to HTTP services.
 	BaseURL string `yaml:"baseurl"`
+	// Labels defines extra labels attached to all metrics of the service.
+	Labels map[string]string `yaml:"labels"`
+	// SocketDir defines directory of Unix-domain socket used for connecting to the service, overrides host specified
+	// in conninfo. When neither host nor socket directory is specified, conventional socket directory is used.
+	SocketDir string `yaml:"socket_dir"`
+	// Port defines port used for connecting to the service, overrides port specified in conninfo.
+	Port int `yaml:"port"`
+	// SSLMode defines SSL mode used for connecting to the service, overrides sslmode specified in conninfo.
+	SSLMode string `yaml:"sslmode"`
+	// SSLCert defines path to client SSL certificate.
+	SSLCert string `yaml:"sslcert"`
+	// SSLKey defines path to client SSL private key.
+	SSLKey string `yaml:"sslkey"`
+	// SSLRootCert defines path to SSL certificate authority (CA) certificate used for server certificate verification.
+	SSLRootCert string `yaml:"sslrootcert"`
+	// Managed defines the service is managed by cloud provider (e.g. AWS RDS), its host is not accessible and
+	// collectors unsupported by managed services are disabled.
+	Managed bool `yaml:"managed"`
 }
 
 // ConnsSettings defines a set of all connection settings of exact services.
 type ConnsSettings map[string]ConnSetting
 
+// ApplyConnParams is a public wrapper over applyConnParams.
+func ApplyConnParams(cs ConnSetting) (ConnSetting, error) {
+	return applyConnParams(cs)
+}
+
+// applyConnParams validates socket and TLS settings and puts them into conninfo.
+func applyConnParams(cs ConnSetting) (ConnSetting, error) {
+	// Nothing to do if settings are not specified.
+	if cs.SocketDir == "" && cs.Port == 0 && cs.SSLMode == "" && cs.SSLCert == "" && cs.SSLKey == "" && cs.SSLRootCert == "" {
+		return cs, nil
+	}
+
+	if cs.ServiceType == model.ServiceTypePatroni {
+		return ConnSetting{}, fmt.Errorf("socket and TLS settings are not supported for %s services, use baseurl", cs.ServiceType)
+	}
+
+	err := validateSocketSettings(cs)
+	if err != nil {
+		return ConnSetting{}, err
+	}
+
+	err = validateTLSSettings(cs)
+	if err != nil {
+		return ConnSetting{}, err
+	}
+
+	var port string
+	if cs.Port > 0 {
+		port = strconv.Itoa(cs.Port)
+	}
+
+	params := [][2]string{
+		{"host", cs.SocketDir},
+		{"port", port},
+		{"sslmode", cs.SSLMode},
+		{"sslcert", cs.SSLCert},
+		{"sslkey", cs.SSLKey},
+		{"sslrootcert", cs.SSLRootCert},
+	}
+
+	// Conninfo might be specified in URL or in keyword/value format.
+	if strings.HasPrefix(cs.Conninfo, "postgres://") || strings.HasPrefix(cs.Conninfo, "postgresql://") {
+		u, err := url.Parse(cs.Conninfo)
+		if err != nil {
+			return ConnSetting{}, err
+		}
+
+		q := u.Query()
+		for _, p := range params {
+			if p[1] != "" {
+				q.Set(p[0], p[1])
+			}
+		}
+		u.RawQuery = q.Encode()
+		cs.Conninfo = u.String()
+
+		return cs, nil
+	}
+
+	var b strings.Builder
+	b.WriteString(cs.Conninfo)
+	for _, p := range params {
+		if p[1] != "" {
+			v := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(p[1])
+			fmt.Fprintf(&b, " %s='%s'", p[0], v)
+		}
+	}
+	cs.Conninfo = strings.TrimSpace(b.String())
+
+	return cs, nil
+}
+
+// validateSocketSettings checks unix socket directory and port settings.
+func validateSocketSettings(cs ConnSetting) error {
+	if cs.Port < 0 || cs.Port > 65535 {
+		return fmt.Errorf("invalid port %d", cs.Port)
+	}
+
+	if cs.SocketDir == "" {
+		return nil
+	}
+
+	if !filepath.IsAbs(cs.SocketDir) {
+		return fmt.Errorf("invalid socket_dir '%s': must be absolute path", cs.SocketDir)
+	}
+
+	fi, err := os.Stat(cs.SocketDir)
+	if err != nil {
+		return fmt.Errorf("invalid socket_dir: %s", err)
+	}
+
+	if !fi.IsDir() {
+		return fmt.Errorf("invalid socket_dir '%s': not a directory", cs.SocketDir)
+	}
+
+	return nil
+}
+
+// validateTLSSettings checks TLS settings.
+func validateTLSSettings(cs ConnSetting) error {
+	switch cs.SSLMode {
+	case "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		return fmt.Errorf("invalid sslmode '%s'", cs.SSLMode)
+	}
+
+	if (cs.SSLCert == "") != (cs.SSLKey == "") {
+		return fmt.Errorf("sslcert and sslkey must be specified together")
+	}
+
+	for _, p := range [][2]string{{"sslcert", cs.SSLCert}, {"sslkey", cs.SSLKey}, {"sslrootcert", cs.SSLRootCert}} {
+		if p[1] == "" {
+			continue
+		}
+		if _, err := os.Stat(p[1]); err != nil {
+			return fmt.Errorf("invalid %s: %s", p[0], err)
+		}
+	}
+
+	return nil
+}
+
 // ParsePostgresDSNEnv is a public wrapper over parseDSNEnv.
 func ParsePostgresDSNEnv(key, value string) (string, ConnSetting, error) {
 	return parseDSNEnv("POSTGRES_DSN", strings.Replace(key, "DATABASE_DSN", "POSTGRES_DSN", 1), value)
@@ -3,6 +3,7 @@ package service
 import (
 	"testing"
 
+	"github.com/cherts/pgscv/internal/model"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -92,3 +93,81 @@ func Test_parseURLEnv(t *testing.T) {
 		}
 	}
 }
+
+func Test_applyConnParams(t *testing.T) {
+	testcases := []struct {
+		valid bool
+		in    ConnSetting
+		want  string
+	}{
+		{
+			valid: true,
+			in:    ConnSetting{ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1 user=pgscv"},
+			want:  "host=127.0.0.1 user=pgscv",
+		},
+		{
+			valid: true,
+			in: ConnSetting{
+				ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1 user=pgscv",
+				SSLMode: "verify-full", SSLCert: "testdata/tls/client.crt", SSLKey: "testdata/tls/client.key", SSLRootCert: "testdata/tls/root.crt",
+			},
+			want: "host=127.0.0.1 user=pgscv sslmode='verify-full' sslcert='testdata/tls/client.crt' sslkey='testdata/tls/client.key' sslrootcert='testdata/tls/root.crt'",
+		},
+		{
+			valid: true,
+			in: ConnSetting{
+				ServiceType: model.ServiceTypePgbouncer, Conninfo: "postgres://pgscv@127.0.0.1:6432/pgbouncer?sslmode=disable",
+				SSLMode: "require",
+			},
+			want: "postgres://pgscv@127.0.0.1:6432/pgbouncer?sslmode=require",
+		},
+		{
+			valid: true,
+			in:    ConnSetting{ServiceType: model.ServiceTypePostgresql, Conninfo: "user=pgscv", SocketDir: "/tmp", Port: 5433},
+			want:  "user=pgscv host='/tmp' port='5433'",
+		},
+		{
+			valid: true,
+			in:    ConnSetting{ServiceType: model.ServiceTypePostgresql, SocketDir: "/tmp"},
+			want:  "host='/tmp'",
+		},
+		{
+			valid: false,
+			in:    ConnSetting{ServiceType: model.ServiceTypePostgresql, Conninfo: "user=pgscv", SocketDir: "testdata"},
+		},
+		{
+			valid: false,
+			in:    ConnSetting{ServiceType: model.ServiceTypePostgresql, Conninfo: "user=pgscv", SocketDir: "/nonexistent"},
+		},
+		{
+			valid: false,
+			in:    ConnSetting{ServiceType: model.ServiceTypePostgresql, Conninfo: "user=pgscv", Port: 70000},
+		},
+		{
+			valid: false,
+			in:    ConnSetting{ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1", SSLMode: "invalid"},
+		},
+		{
+			valid: false,
+			in:    ConnSetting{ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1", SSLCert: "testdata/tls/client.crt"},
+		},
+		{
+			valid: false,
+			in:    ConnSetting{ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1", SSLRootCert: "testdata/tls/unknown.crt"},
+		},
+		{
+			valid: false,
+			in:    ConnSetting{ServiceType: model.ServiceTypePatroni, BaseURL: "http://127.0.0.1:8008", SSLMode: "require"},
+		},
+	}
+
+	for _, tc := range testcases {
+		got, err := ApplyConnParams(tc.in)
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got.Conninfo)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
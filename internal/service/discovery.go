@@ -0,0 +1,513 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/jackc/pgx/v4"
+)
+
+// postgresInstance describes local Postgres instance found during processes scan.
+type postgresInstance struct {
+	pid     int    // postmaster process ID
+	datadir string // data directory
+	port    int    // port the instance is listening on
+	host    string // unix socket directory or listen address
+}
+
+// pgbouncerInstance describes local Pgbouncer instance found during processes scan.
+type pgbouncerInstance struct {
+	pid    int    // process ID
+	config string // path to config file
+	port   int    // port the instance is listening on, zero if config file is not available
+	host   string // unix socket directory or listen address
+	user   string // admin (or stats) user
+}
+
+// Discovery is a public wrapper over runDiscovery method.
+func (repo *Repository) Discovery(ctx context.Context, config Config) {
+	repo.runDiscovery(ctx, config)
+}
+
+// DiscoverServices is a public wrapper over discoverServices method.
+func (repo *Repository) DiscoverServices(config Config) error {
+	return repo.discoverServices(config)
+}
+
+// runDiscovery periodically looks for local services and updates the repo until context is cancelled.
+func (repo *Repository) runDiscovery(ctx context.Context, config Config) {
+	log.Infof("auto-discovery of local services enabled, interval %s", config.DiscoveryInterval)
+
+	ticker := time.NewTicker(config.DiscoveryInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := repo.discoverServices(config); err != nil {
+			log.Errorf("auto-discovery failed: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info("exit signaled, stop auto-discovery")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// discoveredService describes service found during processes scan.
+type discoveredService struct {
+	id       string      // service ID
+	settings ConnSetting // connection settings
+	details  string      // human-readable details used in logs
+}
+
+// discoverServices scans local processes for running Postgres and Pgbouncer instances, adds new instances to the repo
+// and removes instances which have been discovered before but are not running anymore.
+func (repo *Repository) discoverServices(config Config) error {
+	log.Debug("auto-discovery: scan local processes")
+
+	services, err := repo.findServices(config)
+	if err != nil {
+		return err
+	}
+
+	var found = map[string]bool{}
+
+	for _, ds := range services {
+		// Several processes might serve the same instance (e.g. pgbouncer with so_reuseport), register it only once.
+		if found[ds.id] {
+			continue
+		}
+		found[ds.id] = true
+
+		// Services defined in the config and services discovered earlier are already monitored.
+		if s, ok := repo.lookupService(ds.id); ok {
+			if !s.Discovered {
+				log.Debugf("auto-discovery: service [%s] is already defined in configuration, skip", ds.id)
+			}
+			continue
+		}
+
+		pgconfig, err := pgx.ParseConfig(ds.settings.Conninfo)
+		if err != nil {
+			log.Warnf("auto-discovery: %s: %s, skip", ds.settings.Conninfo, err)
+			continue
+		}
+
+		db, err := store.NewWithConfig(pgconfig)
+		if err != nil {
+			log.Warnf("auto-discovery: %s: %s, skip", ds.settings.Conninfo, err)
+			continue
+		}
+		db.Close()
+
+		repo.addService(Service{ServiceID: ds.id, ConnSettings: ds.settings, Discovered: true})
+
+		log.Infof("auto-discovery: registered new service [%s], %s", ds.id, ds.details)
+	}
+
+	// Remove discovered services which are not running anymore.
+	for _, id := range repo.getServiceIDs() {
+		s := repo.getService(id)
+		if !s.Discovered || found[id] {
+			continue
+		}
+
+		if s.Collector != nil {
+			registerer(config).Unregister(s.Collector)
+			stopCollector(s)
+		}
+
+		repo.removeService(id)
+		store.CloseServicePools(id)
+		log.Infof("auto-discovery: service [%s] is not running anymore, removed", id)
+	}
+
+	return repo.setupServices(config)
+}
+
+// findServices looks for local Postgres and Pgbouncer instances and returns services which could be registered.
+func (repo *Repository) findServices(config Config) ([]discoveredService, error) {
+	var services []discoveredService
+
+	postgres, err := findPostgresInstances(config.ProcfsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inst := range postgres {
+		cs, err := newDiscoveredConnSetting(model.ServiceTypePostgresql, inst.host, inst.port,
+			config.ConnDefaults["postgres_username"], config.ConnDefaults["postgres_dbname"],
+		)
+		if err != nil {
+			log.Warnf("auto-discovery: postgres process %d: %s, skip", inst.pid, err)
+			continue
+		}
+
+		services = append(services, discoveredService{
+			id:       fmt.Sprintf("%s:%d", model.ServiceTypePostgresql, inst.port),
+			settings: cs,
+			details:  fmt.Sprintf("pid %d, data directory %s", inst.pid, inst.datadir),
+		})
+	}
+
+	pgbouncers, err := findPgbouncerInstances(config.ProcfsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inst := range pgbouncers {
+		// Config file is not reachable, e.g. pgbouncer is running in a container. Such instance could be monitored only
+		// using connection settings defined in the configuration.
+		if inst.port == 0 {
+			if repo.hasConfiguredService(model.ServiceTypePgbouncer) {
+				log.Debugf("auto-discovery: config of pgbouncer process %d is not available, use configured services", inst.pid)
+			} else {
+				log.Warnf("auto-discovery: config of pgbouncer process %d is not available and no pgbouncer services configured, skip", inst.pid)
+			}
+			continue
+		}
+
+		user := inst.user
+		if user == "" {
+			user = config.ConnDefaults["pgbouncer_username"]
+		}
+
+		cs, err := newDiscoveredConnSetting(model.ServiceTypePgbouncer, inst.host, inst.port, user, config.ConnDefaults["pgbouncer_dbname"])
+		if err != nil {
+			log.Warnf("auto-discovery: pgbouncer process %d: %s, skip", inst.pid, err)
+			continue
+		}
+
+		services = append(services, discoveredService{
+			id:       fmt.Sprintf("%s:%d", model.ServiceTypePgbouncer, inst.port),
+			settings: cs,
+			details:  fmt.Sprintf("pid %d, config file %s", inst.pid, inst.config),
+		})
+	}
+
+	return services, nil
+}
+
+// newDiscoveredConnSetting returns connection settings for discovered instance. Unix-domain socket is used when socket
+// directory is known, otherwise instance is connected through listen address.
+func newDiscoveredConnSetting(stype, host string, port int, user, dbname string) (ConnSetting, error) {
+	cs := ConnSetting{ServiceType: stype, Conninfo: fmt.Sprintf("user=%s dbname=%s", user, dbname), Port: port}
+
+	if filepath.IsAbs(host) {
+		cs.SocketDir = host
+	} else {
+		cs.Conninfo = fmt.Sprintf("host=%s %s", host, cs.Conninfo)
+	}
+
+	return applyConnParams(cs)
+}
+
+// findPostgresInstances walks through processes in procfs and returns Postgres instances found.
+func findPostgresInstances(procfs string) ([]postgresInstance, error) {
+	dirs, err := os.ReadDir(procfs)
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []postgresInstance
+
+	for _, d := range dirs {
+		pid, err := strconv.Atoi(d.Name())
+		if err != nil {
+			continue
+		}
+
+		comm, ppid, err := readProcessStat(filepath.Join(procfs, d.Name(), "stat"))
+		if err != nil {
+			// Process might exit during the scan, just skip it.
+			continue
+		}
+
+		if !isPostgresComm(comm) {
+			continue
+		}
+
+		// Postmaster is the only postgres process whose parent is not postgres process.
+		parentComm, _, err := readProcessStat(filepath.Join(procfs, strconv.Itoa(ppid), "stat"))
+		if err == nil && isPostgresComm(parentComm) {
+			continue
+		}
+
+		datadir, err := filepath.EvalSymlinks(filepath.Join(procfs, d.Name(), "cwd"))
+		if err != nil {
+			log.Warnf("auto-discovery: read data directory of process %d failed: %s, skip", pid, err)
+			continue
+		}
+
+		inst, err := readPostmasterPid(filepath.Join(datadir, "postmaster.pid"))
+		if err != nil {
+			log.Warnf("auto-discovery: read postmaster.pid of process %d failed: %s, skip", pid, err)
+			continue
+		}
+
+		// Stale postmaster.pid left from another process.
+		if inst.pid != pid {
+			log.Warnf("auto-discovery: postmaster.pid in %s belongs to process %d, expected %d, skip", datadir, inst.pid, pid)
+			continue
+		}
+
+		instances = append(instances, inst)
+	}
+
+	return instances, nil
+}
+
+// findPgbouncerInstances walks through processes in procfs and returns Pgbouncer instances found.
+func findPgbouncerInstances(procfs string) ([]pgbouncerInstance, error) {
+	dirs, err := os.ReadDir(procfs)
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []pgbouncerInstance
+
+	for _, d := range dirs {
+		pid, err := strconv.Atoi(d.Name())
+		if err != nil {
+			continue
+		}
+
+		comm, _, err := readProcessStat(filepath.Join(procfs, d.Name(), "stat"))
+		if err != nil || comm != "pgbouncer" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(procfs, d.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+
+		inst := pgbouncerInstance{pid: pid, config: parsePgbouncerCmdline(string(data))}
+		if inst.config == "" {
+			log.Warnf("auto-discovery: config file of pgbouncer process %d not found in command line, skip", pid)
+			continue
+		}
+
+		// Relative path is relative to process working directory.
+		path := inst.config
+		if !filepath.IsAbs(path) {
+			cwd, err := filepath.EvalSymlinks(filepath.Join(procfs, d.Name(), "cwd"))
+			if err != nil {
+				log.Warnf("auto-discovery: read working directory of process %d failed: %s, skip", pid, err)
+				continue
+			}
+			path = filepath.Join(cwd, path)
+		}
+
+		// Config file might be inside of process mount namespace (e.g. in a container), try process root as well.
+		ini, err := readPgbouncerIni(path)
+		if err != nil && filepath.IsAbs(inst.config) {
+			ini, err = readPgbouncerIni(filepath.Join(procfs, d.Name(), "root", inst.config))
+		}
+
+		if err != nil {
+			log.Debugf("auto-discovery: read pgbouncer config %s failed: %s", inst.config, err)
+			instances = append(instances, inst)
+			continue
+		}
+
+		inst.port, inst.host, inst.user = ini.port, ini.host, ini.user
+		instances = append(instances, inst)
+	}
+
+	return instances, nil
+}
+
+// parsePgbouncerCmdline parses pgbouncer command line and returns path to config file. Config file is the only
+// positional argument, all other arguments are options ('-u' is the only option with value).
+func parsePgbouncerCmdline(cmdline string) string {
+	args := strings.Split(strings.TrimRight(cmdline, "\x00"), "\x00")
+	if len(args) < 2 {
+		return ""
+	}
+
+	var config string
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "-u" || args[i] == "--user":
+			i++
+		case strings.HasPrefix(args[i], "-"):
+			continue
+		default:
+			config = args[i]
+		}
+	}
+
+	return config
+}
+
+// readPgbouncerIni reads pgbouncer config file and returns instance properties required for connecting.
+func readPgbouncerIni(path string) (pgbouncerInstance, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return pgbouncerInstance{}, err
+	}
+	defer func() { _ = file.Close() }()
+
+	// Defaults used by pgbouncer when values are not specified.
+	var (
+		port       = 6432
+		socketdir  = "/tmp"
+		listenAddr string
+		adminUser  string
+		statsUser  string
+		section    string
+	)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.Trim(line, "[]"))
+			continue
+		}
+
+		if section != "pgbouncer" {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch key {
+		case "listen_port":
+			port, err = strconv.Atoi(value)
+			if err != nil {
+				return pgbouncerInstance{}, fmt.Errorf("invalid input, parse listen_port '%s' failed: %w", value, err)
+			}
+		case "unix_socket_dir":
+			socketdir = value
+		case "listen_addr":
+			listenAddr = strings.TrimSpace(strings.Split(value, ",")[0])
+		case "admin_users":
+			adminUser = strings.TrimSpace(strings.Split(value, ",")[0])
+		case "stats_users":
+			statsUser = strings.TrimSpace(strings.Split(value, ",")[0])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return pgbouncerInstance{}, err
+	}
+
+	inst := pgbouncerInstance{port: port, user: adminUser}
+	if inst.user == "" {
+		inst.user = statsUser
+	}
+
+	// Prefer unix socket, fall back to listen address when socket is disabled.
+	switch {
+	case socketdir != "":
+		inst.host = socketdir
+	case listenAddr == "":
+		return pgbouncerInstance{}, fmt.Errorf("invalid input: no unix_socket_dir and listen_addr")
+	case listenAddr == "*" || listenAddr == "0.0.0.0" || listenAddr == "::":
+		inst.host = "127.0.0.1"
+	default:
+		inst.host = listenAddr
+	}
+
+	return inst, nil
+}
+
+// isPostgresComm returns true if passed process name belongs to Postgres.
+func isPostgresComm(comm string) bool {
+	return comm == "postgres" || comm == "postmaster"
+}
+
+// readProcessStat reads process stat file and returns process name and parent process ID.
+func readProcessStat(path string) (string, int, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return "", 0, err
+	}
+
+	// Process name is enclosed in parentheses and might contain spaces and parentheses itself.
+	line := string(data)
+	start, end := strings.IndexByte(line, '('), strings.LastIndexByte(line, ')')
+	if start < 0 || end < start {
+		return "", 0, fmt.Errorf("invalid input: '%s'", strings.TrimSpace(line))
+	}
+
+	// Fields after process name: state, ppid, ...
+	fields := strings.Fields(line[end+1:])
+	if len(fields) < 2 {
+		return "", 0, fmt.Errorf("invalid input: '%s': too few values", strings.TrimSpace(line))
+	}
+
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid input, parse '%s' failed: %w", fields[1], err)
+	}
+
+	return line[start+1 : end], ppid, nil
+}
+
+// readPostmasterPid reads postmaster.pid file and returns instance properties.
+func readPostmasterPid(path string) (postgresInstance, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return postgresInstance{}, err
+	}
+
+	// Lines of postmaster.pid: pid, data directory, start timestamp, port, socket directory, listen address, shmem key, status.
+	lines := strings.Split(string(data), "\n")
+	if len(lines) < 6 {
+		return postgresInstance{}, fmt.Errorf("invalid input: too few lines")
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err != nil {
+		return postgresInstance{}, fmt.Errorf("invalid input, parse pid '%s' failed: %w", lines[0], err)
+	}
+
+	if _, err := strconv.ParseInt(strings.TrimSpace(lines[2]), 10, 64); err != nil {
+		return postgresInstance{}, fmt.Errorf("invalid input, parse start time '%s' failed: %w", lines[2], err)
+	}
+
+	port, err := strconv.Atoi(strings.TrimSpace(lines[3]))
+	if err != nil {
+		return postgresInstance{}, fmt.Errorf("invalid input, parse port '%s' failed: %w", lines[3], err)
+	}
+
+	inst := postgresInstance{pid: pid, datadir: strings.TrimSpace(lines[1]), port: port}
+
+	// Prefer unix socket, fall back to the first listen address when socket is not available.
+	if socketdir := strings.Split(strings.TrimSpace(lines[4]), ",")[0]; socketdir != "" {
+		inst.host = socketdir
+	} else {
+		switch addr := strings.Split(strings.TrimSpace(lines[5]), ",")[0]; addr {
+		case "":
+			return postgresInstance{}, fmt.Errorf("invalid input: no socket directory and listen address")
+		case "*", "0.0.0.0", "::":
+			inst.host = "127.0.0.1"
+		default:
+			inst.host = addr
+		}
+	}
+
+	return inst, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_findPostgresInstances(t *testing.T) {
+	got, err := findPostgresInstances("testdata/proc")
+	assert.NoError(t, err)
+	assert.Equal(t, []postgresInstance{
+		{pid: 1373, datadir: "/var/lib/postgresql/12/main", port: 5432, host: "/var/run/postgresql"},
+	}, got)
+
+	_, err = findPostgresInstances("testdata/invalid")
+	assert.Error(t, err)
+}
+
+func Test_readProcessStat(t *testing.T) {
+	comm, ppid, err := readProcessStat("testdata/proc/1400/stat")
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres", comm)
+	assert.Equal(t, 1373, ppid)
+
+	_, _, err = readProcessStat("testdata/proc/invalid/stat")
+	assert.Error(t, err)
+}
+
+func Test_readPostmasterPid(t *testing.T) {
+	testcases := []struct {
+		valid bool
+		file  string
+		want  postgresInstance
+	}{
+		{
+			valid: true,
+			file:  "testdata/postmaster.pid.d/valid.golden",
+			want:  postgresInstance{pid: 1373, datadir: "/var/lib/postgresql/12/main", port: 5432, host: "/var/run/postgresql"},
+		},
+		{
+			valid: true,
+			file:  "testdata/postmaster.pid.d/valid-unix.golden",
+			want:  postgresInstance{pid: 593, datadir: "/var/lib/postgresql/12/main", port: 5432, host: "/var/run/postgresql"},
+		},
+		{valid: false, file: "testdata/postmaster.pid.d/invalid.golden"},
+		{valid: false, file: "testdata/postmaster.pid.d/invalid-port.golden"},
+		{valid: false, file: "testdata/postmaster.pid.d/invalid-ts.golden"},
+		{valid: false, file: "testdata/postmaster.pid.d/unknown.golden"},
+	}
+
+	for _, tc := range testcases {
+		got, err := readPostmasterPid(tc.file)
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func Test_findPgbouncerInstances(t *testing.T) {
+	got, err := findPgbouncerInstances("testdata/proc")
+	assert.NoError(t, err)
+	assert.Equal(t, []pgbouncerInstance{
+		{pid: 3000, config: "pgbouncer.ini", port: 6433, host: "/var/run/postgresql", user: "pgbouncer"},
+		{pid: 3001, config: "/etc/pgbouncer/pgbouncer.ini"}, // config is not reachable
+	}, got)
+
+	_, err = findPgbouncerInstances("testdata/invalid")
+	assert.Error(t, err)
+}
+
+func Test_parsePgbouncerCmdline(t *testing.T) {
+	testcases := []struct {
+		in   string
+		want string
+	}{
+		{in: "/usr/sbin/pgbouncer\x00-d\x00/etc/pgbouncer/pgbouncer.ini\x00", want: "/etc/pgbouncer/pgbouncer.ini"},
+		{in: "pgbouncer\x00-u\x00postgres\x00-R\x00pgbouncer.ini\x00", want: "pgbouncer.ini"},
+		{in: "pgbouncer\x00-d\x00", want: ""},
+		{in: "pgbouncer", want: ""},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, parsePgbouncerCmdline(tc.in))
+	}
+}
+
+func Test_readPgbouncerIni(t *testing.T) {
+	got, err := readPgbouncerIni("testdata/pgbouncer/pgbouncer.ini")
+	assert.NoError(t, err)
+	assert.Equal(t, pgbouncerInstance{port: 6433, host: "/var/run/postgresql", user: "pgbouncer"}, got)
+
+	for _, f := range []string{
+		"testdata/pgbouncer/invalid.ini",
+		"testdata/pgbouncer/nosocket.ini",
+		"testdata/pgbouncer/unknown.ini",
+	} {
+		_, err = readPgbouncerIni(f)
+		assert.Error(t, err)
+	}
+}
+
+func Test_newDiscoveredConnSetting(t *testing.T) {
+	got, err := newDiscoveredConnSetting("postgres", "/tmp", 5432, "pgscv", "postgres")
+	assert.NoError(t, err)
+	assert.Equal(t, ConnSetting{ServiceType: "postgres", Conninfo: "user=pgscv dbname=postgres host='/tmp' port='5432'", SocketDir: "/tmp", Port: 5432}, got)
+
+	got, err = newDiscoveredConnSetting("pgbouncer", "127.0.0.1", 6432, "pgscv", "pgbouncer")
+	assert.NoError(t, err)
+	assert.Equal(t, ConnSetting{ServiceType: "pgbouncer", Conninfo: "host=127.0.0.1 user=pgscv dbname=pgbouncer port='6432'", Port: 6432}, got)
+
+	_, err = newDiscoveredConnSetting("postgres", "/nonexistent", 5432, "pgscv", "postgres")
+	assert.Error(t, err)
+}
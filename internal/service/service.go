@@ -3,6 +3,8 @@ package service
 import (
 	"fmt"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -29,6 +31,8 @@ type Service struct {
 	// Prometheus-based metrics collector associated with the service. Each 'service' has its own dedicated collector instance
 	// which implements a service-specific set of metric collectors.
 	Collector Collector
+	// Discovered is true when service has been found by auto-discovery rather than defined in configuration.
+	Discovered bool
 }
 
 // Config defines service's configuration.
@@ -42,6 +46,20 @@ type Config struct {
 	DisabledCollectors []string
 	// CollectorsSettings defines all collector settings propagated from main YAML configuration.
 	CollectorsSettings model.CollectorsSettings
+	// ProcfsPath defines path where procfs is mounted.
+	ProcfsPath string
+	// SysfsPath defines path where sysfs is mounted.
+	SysfsPath string
+	// CollectorTimeout defines max time allowed to a single collector for collecting metrics.
+	CollectorTimeout time.Duration
+	// Concurrency defines max number of collectors running concurrently.
+	Concurrency int
+	// DiscoveryInterval defines how often local Postgres services should be discovered, zero disables discovery.
+	DiscoveryInterval time.Duration
+	// Namespace defines custom prefix for names of all metrics collected from services, empty means no prefix.
+	Namespace string
+	// ExternalLabels defines labels attached to all metrics collected from all services.
+	ExternalLabels map[string]string
 }
 
 // Collector is an interface for prometheus.Collector.
@@ -75,6 +93,42 @@ func (repo *Repository) SetupServices(config Config) error {
 	return repo.setupServices(config)
 }
 
+// CreateCollectors is a public wrapper on createCollectors method.
+func (repo *Repository) CreateCollectors(config Config) error {
+	_, err := repo.createCollectors(config)
+	return err
+}
+
+// ValidateCollectors is a public wrapper on validateCollectors method.
+func (repo *Repository) ValidateCollectors(config Config) error {
+	return repo.validateCollectors(config)
+}
+
+// RegisterCollectors is a public wrapper on registerCollectors method.
+func (repo *Repository) RegisterCollectors(config Config) error {
+	return repo.registerCollectors(config)
+}
+
+// UnregisterCollectors is a public wrapper on unregisterCollectors method.
+func (repo *Repository) UnregisterCollectors(config Config) {
+	repo.unregisterCollectors(config)
+}
+
+// StopCollectors is a public wrapper on stopCollectors method.
+func (repo *Repository) StopCollectors() {
+	repo.stopCollectors()
+}
+
+// ServiceIDs is a public wrapper on getServiceIDs method.
+func (repo *Repository) ServiceIDs() []string {
+	return repo.getServiceIDs()
+}
+
+// CollectorsStatus is a public wrapper on collectorsStatus method.
+func (repo *Repository) CollectorsStatus(config Config) []Status {
+	return repo.collectorsStatus(config)
+}
+
 /* Private methods of Repository */
 
 // addService adds service to the repo.
@@ -92,6 +146,33 @@ func (repo *Repository) getService(id string) Service {
 	return s
 }
 
+// lookupService returns the service from repo with specified ID and flag whether the service exists.
+func (repo *Repository) lookupService(id string) (Service, bool) {
+	repo.RLock()
+	s, ok := repo.Services[id]
+	repo.RUnlock()
+	return s, ok
+}
+
+// removeService removes the service with specified ID from the repo.
+func (repo *Repository) removeService(id string) {
+	repo.Lock()
+	delete(repo.Services, id)
+	repo.Unlock()
+}
+
+// hasConfiguredService returns true if the repo has service of specified type defined in configuration.
+func (repo *Repository) hasConfiguredService(stype string) bool {
+	repo.RLock()
+	defer repo.RUnlock()
+	for _, s := range repo.Services {
+		if s.ConnSettings.ServiceType == stype && !s.Discovered {
+			return true
+		}
+	}
+	return false
+}
+
 // totalServices returns the number of services registered in the repo.
 func (repo *Repository) totalServices() int {
 	repo.RLock()
@@ -175,54 +256,291 @@ func (repo *Repository) addServicesFromConfig(config Config) {
 	}
 }
 
-// setupServices attaches metrics exporters to the services in the repo.
+// setupServices attaches metrics exporters to the services in the repo and registers them.
 func (repo *Repository) setupServices(config Config) error {
 	log.Debug("config: setting up services")
 
+	ids, err := repo.createCollectors(config)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := registerer(config).Register(repo.getService(id).Collector); err != nil {
+			return fmt.Errorf("service [%s]: register collector failed: %s", id, err)
+		}
+		log.Debugf("service configured [%s]", id)
+	}
+
+	return nil
+}
+
+// createCollectors attaches metrics exporters to the services in the repo which have no exporters yet, exporters are
+// not registered. Returns IDs of services with created exporters.
+func (repo *Repository) createCollectors(config Config) ([]string, error) {
+	var created []string
 	for _, id := range repo.getServiceIDs() {
 		var service = repo.getService(id)
-		if service.Collector == nil {
-			factories := collector.Factories{}
-			collectorConfig := collector.Config{
-				NoTrackMode: config.NoTrackMode,
-				ServiceType: service.ConnSettings.ServiceType,
-				ConnString:  service.ConnSettings.Conninfo,
-				Settings:    config.CollectorsSettings,
-				DatabasesRE: config.DatabasesRE,
-			}
+		if service.Collector != nil {
+			continue
+		}
 
-			switch service.ConnSettings.ServiceType {
-			case model.ServiceTypeSystem:
-				factories.RegisterSystemCollectors(config.DisabledCollectors)
-			case model.ServiceTypePostgresql:
-				factories.RegisterPostgresCollectors(config.DisabledCollectors)
-			case model.ServiceTypePgbouncer:
-				factories.RegisterPgbouncerCollectors(config.DisabledCollectors)
-			case model.ServiceTypePatroni:
-				factories.RegisterPatroniCollectors(config.DisabledCollectors)
-				collectorConfig.BaseURL = service.ConnSettings.BaseURL
-			default:
-				continue
-			}
+		disabled := disabledCollectors(config, service.ConnSettings.Managed)
+		factories, ok := newFactories(service.ConnSettings.ServiceType, disabled)
+		if !ok {
+			continue
+		}
 
-			mc, err := collector.NewPgscvCollector(service.ServiceID, factories, collectorConfig)
-			if err != nil {
-				return err
-			}
-			service.Collector = mc
+		log.Infof("service [%s]: enabled collectors: %s; disabled collectors: %s",
+			id, strings.Join(factories.Names(), ", "), strings.Join(disabled, ", "),
+		)
+
+		mc, err := collector.NewPgscvCollector(service.ServiceID, factories, newCollectorConfig(config, service.ConnSettings))
+		if err != nil {
+			return nil, err
+		}
+		service.Collector = mc
+
+		// Put updated service into repo.
+		repo.addService(service)
+		created = append(created, id)
+	}
+
+	return created, nil
+}
+
+// validateCollectors checks collectors of all services in the repo could be registered together, using a scratch
+// registry. It allows to check collectors before replacing already registered ones.
+func (repo *Repository) validateCollectors(config Config) error {
+	reg := prometheus.NewRegistry()
+
+	for _, id := range repo.getServiceIDs() {
+		s := repo.getService(id)
+		if s.Collector == nil {
+			continue
+		}
+
+		if err := wrapRegisterer(config, reg).Register(s.Collector); err != nil {
+			return fmt.Errorf("service [%s]: register collector failed: %s", id, err)
+		}
+	}
 
-			// Register collector.
-			prometheus.MustRegister(service.Collector)
+	return nil
+}
+
+// registerCollectors registers collectors of all configured services in the repo. It is used for restoring collectors
+// unregistered using unregisterCollectors.
+func (repo *Repository) registerCollectors(config Config) error {
+	for _, id := range repo.getServiceIDs() {
+		s := repo.getService(id)
+		if s.Collector == nil {
+			continue
+		}
 
-			// Put updated service into repo.
-			repo.addService(service)
-			log.Debugf("service configured [%s]", id)
+		if err := registerer(config).Register(s.Collector); err != nil {
+			return fmt.Errorf("service [%s]: register collector failed: %s", id, err)
 		}
 	}
 
 	return nil
 }
 
+// unregisterCollectors unregisters collectors of all services in the repo, services are kept in the repo.
+func (repo *Repository) unregisterCollectors(config Config) {
+	for _, id := range repo.getServiceIDs() {
+		s := repo.getService(id)
+		if s.Collector == nil {
+			continue
+		}
+
+		registerer(config).Unregister(s.Collector)
+		log.Debugf("service [%s]: collector unregistered", id)
+	}
+}
+
+// stopCollectors stops collectors of all services in the repo, the repo should not be used after that.
+func (repo *Repository) stopCollectors() {
+	for _, id := range repo.getServiceIDs() {
+		stopCollector(repo.getService(id))
+	}
+}
+
+// stopCollector stops background work of the service's collector, if any.
+func stopCollector(s Service) {
+	if mc, ok := s.Collector.(*collector.PgscvCollector); ok {
+		mc.Stop()
+	}
+}
+
+// Status describes state of the service and its collectors, it is used for diagnostic purposes.
+type Status struct {
+	ServiceID   string `json:"service_id"`
+	ServiceType string `json:"service_type"`
+	collector.Status
+}
+
+// collectorsStatus returns state of collectors of all services in the repo sorted by service ID. Collectors of the
+// service's type disabled in configuration are returned too.
+func (repo *Repository) collectorsStatus(config Config) []Status {
+	ids := repo.getServiceIDs()
+	sort.Strings(ids)
+
+	list := make([]Status, 0, len(ids))
+	for _, id := range ids {
+		s, ok := repo.lookupService(id)
+		if !ok {
+			continue
+		}
+
+		mc, ok := s.Collector.(*collector.PgscvCollector)
+		if !ok {
+			continue
+		}
+
+		st := Status{ServiceID: id, ServiceType: s.ConnSettings.ServiceType, Status: mc.Status()}
+		for _, name := range disabledCollectors(config, s.ConnSettings.Managed) {
+			if strings.HasPrefix(name, st.ServiceType+"/") {
+				st.Collectors = append(st.Collectors, collector.CollectorStatus{Name: name, Enabled: false})
+			}
+		}
+		sort.Slice(st.Collectors, func(i, j int) bool { return st.Collectors[i].Name < st.Collectors[j].Name })
+
+		list = append(list, st)
+	}
+
+	return list
+}
+
+// CheckCollectors creates collectors for system service and services specified in config without connecting to
+// services and returns names of collectors enabled for each service. It is used for validating collectors settings
+// before starting the application.
+func CheckCollectors(config Config) (map[string][]string, error) {
+	services := map[string]ConnSetting{"system:0": {ServiceType: model.ServiceTypeSystem}}
+	for id, cs := range config.ConnsSettings {
+		services[id] = cs
+	}
+
+	enabled := map[string][]string{}
+	for id, cs := range services {
+		factories, ok := newFactories(cs.ServiceType, disabledCollectors(config, cs.Managed))
+		if !ok {
+			return nil, fmt.Errorf("service [%s]: unknown service type '%s'", id, cs.ServiceType)
+		}
+
+		_, err := collector.NewPgscvCollector(id, factories, newCollectorConfig(config, cs))
+		if err != nil {
+			return nil, fmt.Errorf("service [%s]: %s", id, err)
+		}
+
+		enabled[id] = factories.Names()
+	}
+
+	return enabled, nil
+}
+
+// newFactories returns collectors factories for passed service type, false is returned for unknown service type.
+func newFactories(stype string, disabled []string) (collector.Factories, bool) {
+	factories := collector.Factories{}
+
+	switch stype {
+	case model.ServiceTypeSystem:
+		factories.RegisterSystemCollectors(disabled)
+	case model.ServiceTypePostgresql:
+		factories.RegisterPostgresCollectors(disabled)
+	case model.ServiceTypePgbouncer:
+		factories.RegisterPgbouncerCollectors(disabled)
+	case model.ServiceTypePatroni:
+		factories.RegisterPatroniCollectors(disabled)
+	default:
+		return nil, false
+	}
+
+	return factories, true
+}
+
+// newCollectorConfig creates collectors configuration for service with passed connection settings.
+func newCollectorConfig(config Config, cs ConnSetting) collector.Config {
+	return collector.Config{
+		NoTrackMode:      config.NoTrackMode,
+		ServiceType:      cs.ServiceType,
+		ConnString:       cs.Conninfo,
+		BaseURL:          cs.BaseURL,
+		Labels:           mergeLabels(config.ExternalLabels, cs.Labels),
+		Settings:         config.CollectorsSettings,
+		DatabasesRE:      config.DatabasesRE,
+		ProcfsPath:       config.ProcfsPath,
+		SysfsPath:        config.SysfsPath,
+		CollectorTimeout: config.CollectorTimeout,
+		Concurrency:      config.Concurrency,
+		Managed:          cs.Managed,
+	}
+}
+
+// mergeLabels merges external labels with service's labels, service's labels take precedence.
+func mergeLabels(external, service map[string]string) map[string]string {
+	if len(external) == 0 {
+		return service
+	}
+
+	merged := make(map[string]string, len(external)+len(service))
+	for k, v := range external {
+		merged[k] = v
+	}
+	for k, v := range service {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// registerer returns registerer used for services' collectors. If namespace is configured, names of all metrics
+// registered using this registerer are prefixed with namespace.
+func registerer(config Config) prometheus.Registerer {
+	return wrapRegisterer(config, prometheus.DefaultRegisterer)
+}
+
+// wrapRegisterer wraps passed registerer with configured namespace prefix, if any.
+func wrapRegisterer(config Config, reg prometheus.Registerer) prometheus.Registerer {
+	if config.Namespace == "" {
+		return reg
+	}
+
+	return prometheus.WrapRegistererWithPrefix(config.Namespace+"_", reg)
+}
+
+// disabledCollectors returns list of collectors disabled using 'disable_collectors' or collectors settings, and
+// opt-in collectors which are disabled by default. For managed service, collectors unsupported by managed services are
+// disabled too. Collectors explicitly enabled in collectors settings are removed from the list.
+func disabledCollectors(config Config, managed bool) []string {
+	candidates := make([]string, 0, len(config.DisabledCollectors)+len(collector.OptInCollectors)+len(collector.ManagedUnsupportedCollectors))
+	candidates = append(candidates, config.DisabledCollectors...)
+	candidates = append(candidates, collector.OptInCollectors...)
+	if managed {
+		candidates = append(candidates, collector.ManagedUnsupportedCollectors...)
+	}
+
+	var disabled []string
+	for _, name := range candidates {
+		if s, ok := config.CollectorsSettings[name]; ok && s.Enabled != nil && *s.Enabled {
+			continue
+		}
+		if slices.Contains(disabled, name) {
+			continue
+		}
+		disabled = append(disabled, name)
+	}
+
+	for name, s := range config.CollectorsSettings {
+		if s.Enabled != nil && !*s.Enabled && !slices.Contains(disabled, name) {
+			disabled = append(disabled, name)
+		}
+	}
+
+	sort.Strings(disabled)
+
+	return disabled
+}
+
 // attemptRequest tries to make a real HTTP request using passed URL string.
 func attemptRequest(baseurl string) error {
 	url := baseurl + "/health"
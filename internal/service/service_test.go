@@ -1,6 +1,7 @@
 package service
 
 import (
+	"github.com/cherts/pgscv/internal/collector"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
@@ -120,3 +121,144 @@ func TestRepository_setupServices(t *testing.T) {
 		prometheus.Unregister(s.Collector)
 	}
 }
+
+func TestRepository_setupServices_conflict(t *testing.T) {
+	config := Config{}
+
+	r1 := NewRepository()
+	r1.addServicesFromConfig(config)
+	assert.NoError(t, r1.setupServices(config))
+	defer r1.unregisterCollectors(config)
+
+	// Collectors of the same service are already registered, error is returned instead of panic.
+	r2 := NewRepository()
+	r2.addServicesFromConfig(config)
+	assert.Error(t, r2.setupServices(config))
+}
+
+func TestRepository_validateCollectors(t *testing.T) {
+	config := Config{}
+
+	r := NewRepository()
+	r.addServicesFromConfig(config)
+	ids, err := r.createCollectors(config)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"system:0"}, ids)
+
+	// Created collectors are not registered, and they are validated using scratch registry.
+	assert.NoError(t, r.validateCollectors(config))
+	assert.NoError(t, r.registerCollectors(config))
+	assert.NoError(t, r.validateCollectors(config))
+	r.unregisterCollectors(config)
+
+	// Collectors are created only once.
+	ids, err = r.createCollectors(config)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestRepository_collectorsStatus(t *testing.T) {
+	config := Config{DisabledCollectors: []string{"postgres/locks", "system/cpu"}}
+
+	r := NewRepository()
+	for id, stype := range map[string]string{"system:0": model.ServiceTypeSystem, "test": model.ServiceTypePostgresql} {
+		factories, ok := newFactories(stype, disabledCollectors(config, false))
+		assert.True(t, ok)
+
+		cs := ConnSetting{ServiceType: stype}
+		mc, err := collector.NewPgscvCollector(id, factories, newCollectorConfig(config, cs))
+		assert.NoError(t, err)
+
+		r.addService(Service{ServiceID: id, ConnSettings: cs, Collector: mc})
+	}
+
+	list := r.collectorsStatus(config)
+	assert.Len(t, list, 2)
+	assert.Equal(t, "system:0", list[0].ServiceID)
+	assert.Equal(t, "test", list[1].ServiceID)
+	assert.Equal(t, model.ServiceTypePostgresql, list[1].ServiceType)
+
+	// Disabled collectors are listed for services of their type only.
+	var found bool
+	for _, st := range list[1].Collectors {
+		assert.NotEqual(t, "system/cpu", st.Name)
+		if st.Name == "postgres/locks" {
+			found = true
+			assert.False(t, st.Enabled)
+		}
+		if st.Name == "postgres/activity" {
+			assert.True(t, st.Enabled)
+		}
+	}
+	assert.True(t, found)
+}
+
+func Test_disabledCollectors(t *testing.T) {
+	enabled, disabled := true, false
+
+	config := Config{
+		DisabledCollectors: []string{"system/cpu", "postgres/locks", "postgres/activity"},
+		CollectorsSettings: model.CollectorsSettings{
+			"postgres/activity": {Enabled: &enabled},
+			"postgres/tables":   {Enabled: &disabled},
+			"postgres/indexes":  {},
+		},
+	}
+
+	assert.Equal(t, []string{"postgres/locks", "postgres/tables", "system/cpu", "system/smart"}, disabledCollectors(config, false))
+	assert.Equal(t, []string{"system/smart"}, disabledCollectors(Config{}, false))
+
+	// Opt-in collector explicitly enabled.
+	config = Config{CollectorsSettings: model.CollectorsSettings{"system/smart": {Enabled: &enabled}}}
+	assert.Nil(t, disabledCollectors(config, false))
+
+	// Collectors unsupported by managed services.
+	assert.Equal(t, []string{"postgres/logs", "system/smart"}, disabledCollectors(Config{}, true))
+}
+
+func TestCheckCollectors_managed(t *testing.T) {
+	config := Config{
+		ConnsSettings: ConnsSettings{
+			"postgres:5432": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1 dbname=pgscv_fixtures user=pgscv", Managed: true},
+			"postgres:5433": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1 port=5433 dbname=pgscv_fixtures user=pgscv"},
+		},
+	}
+
+	// Collectors unsupported by managed services are disabled only for managed service, system service is kept.
+	enabled, err := CheckCollectors(config)
+	assert.NoError(t, err)
+	assert.Contains(t, enabled, "system:0")
+	assert.NotContains(t, enabled["postgres:5432"], "postgres/logs")
+	assert.Contains(t, enabled["postgres:5432"], "postgres/activity")
+	assert.Contains(t, enabled["postgres:5433"], "postgres/logs")
+}
+
+func Test_mergeLabels(t *testing.T) {
+	assert.Nil(t, mergeLabels(nil, nil))
+	assert.Equal(t, map[string]string{"a": "1"}, mergeLabels(nil, map[string]string{"a": "1"}))
+	assert.Equal(t,
+		map[string]string{"environment": "prod", "cluster": "main"},
+		mergeLabels(map[string]string{"environment": "prod", "cluster": "default"}, map[string]string{"cluster": "main"}),
+	)
+}
+
+func Test_registerer(t *testing.T) {
+	assert.Equal(t, prometheus.DefaultRegisterer, registerer(Config{}))
+
+	config := Config{Namespace: "acme"}
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_registerer_total", Help: "Test counter."})
+	assert.NoError(t, registerer(config).Register(c))
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.NoError(t, err)
+
+	var found bool
+	for _, mf := range families {
+		if mf.GetName() == "acme_test_registerer_total" {
+			found = true
+		}
+	}
+	assert.True(t, found)
+
+	assert.True(t, registerer(config).Unregister(c))
+}
@@ -0,0 +1,455 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgconn/stmtcache"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pgbouncerDbname defines name of Pgbouncer admin console database which supports only simple query protocol.
+const pgbouncerDbname = "pgbouncer"
+
+// PoolConfig defines settings of connections pool used by collectors.
+type PoolConfig struct {
+	MaxOpen                int           `yaml:"max_open"`                 // Max number of open connections per database, zero means no limit
+	MaxIdle                int           `yaml:"max_idle"`                 // Max number of idle connections kept per database, zero disables pooling
+	MaxLifetime            time.Duration `yaml:"max_lifetime"`             // Max time a connection may be reused, zero means no limit
+	MaxIdleTime            time.Duration `yaml:"max_idle_time"`            // Max time a connection may be kept idle, by default 5 minutes
+	StatementCacheCapacity int           `yaml:"statement_cache_capacity"` // Number of statements cached per connection, zero disables caching
+	StatementCacheMode     string        `yaml:"statement_cache_mode"`     // Statements caching mode: 'describe' (default) or 'prepare'
+}
+
+const (
+	// defaultPoolMaxIdleTime defines max time a connection may be kept idle if it's not configured.
+	defaultPoolMaxIdleTime = 5 * time.Minute
+	// poolEvictInterval defines how often idle connections and unused pools are looked for eviction.
+	poolEvictInterval = 30 * time.Second
+)
+
+const (
+	// StatementCacheModeDescribe defines caching of statements descriptions only, statements are executed unnamed.
+	// It is compatible with Pgbouncer running in transaction or statement pooling mode.
+	StatementCacheModeDescribe = "describe"
+	// StatementCacheModePrepare defines caching of named prepared statements. It is incompatible with Pgbouncer running
+	// in transaction or statement pooling mode, where prepared statements are not available in subsequent transactions.
+	StatementCacheModePrepare = "prepare"
+)
+
+// Enabled returns true if connections pooling is configured.
+func (cfg PoolConfig) Enabled() bool {
+	return cfg.MaxOpen > 0 || cfg.MaxIdle > 0
+}
+
+// maxIdleTime returns max time a connection may be kept idle.
+func (cfg PoolConfig) maxIdleTime() time.Duration {
+	if cfg.MaxIdleTime == 0 {
+		return defaultPoolMaxIdleTime
+	}
+	return cfg.MaxIdleTime
+}
+
+// Validate checks pool settings.
+func (cfg PoolConfig) Validate() error {
+	if cfg.MaxOpen < 0 || cfg.MaxIdle < 0 || cfg.MaxLifetime < 0 || cfg.MaxIdleTime < 0 || cfg.StatementCacheCapacity < 0 {
+		return fmt.Errorf("invalid conn_pool settings: must be positive")
+	}
+
+	if cfg.MaxOpen > 0 && cfg.MaxIdle > cfg.MaxOpen {
+		return fmt.Errorf("invalid conn_pool settings: max_idle %d is greater than max_open %d", cfg.MaxIdle, cfg.MaxOpen)
+	}
+
+	switch cfg.StatementCacheMode {
+	case "", StatementCacheModeDescribe, StatementCacheModePrepare:
+	default:
+		return fmt.Errorf("invalid conn_pool settings: unknown statement_cache_mode '%s'", cfg.StatementCacheMode)
+	}
+
+	return nil
+}
+
+// poolKey identifies pool of connections to a single database of the service.
+type poolKey struct {
+	service string
+	conn    string
+}
+
+// pools keeps connections pools created for each database of services and settings used for creating them.
+var pools = struct {
+	sync.Mutex
+	config    PoolConfig
+	m         map[poolKey]*pool
+	waits     map[string]int // number of blocked acquires of evicted pools, per service
+	evictedAt time.Time      // last time when pools have been looked for eviction
+}{m: map[poolKey]*pool{}, waits: map[string]int{}}
+
+// serviceContextKey is the key for service ID stored in context.
+type serviceContextKey struct{}
+
+// WithService returns copy of passed context which tells the connections are made by collectors of passed service.
+// Only such connections are pooled.
+func WithService(ctx context.Context, serviceID string) context.Context {
+	return context.WithValue(ctx, serviceContextKey{}, serviceID)
+}
+
+// SetPoolConfig sets settings of connections pools, it should be called before any connection is created.
+func SetPoolConfig(cfg PoolConfig) {
+	pools.Lock()
+	pools.config = cfg
+	pools.Unlock()
+}
+
+// ClosePools closes idle connections of all pools. Connections which are in use are closed when released.
+func ClosePools() {
+	closeServicesPools(func(string) bool { return true })
+}
+
+// CloseServicePools closes pools of passed service, it should be used when service is removed. Connections which are
+// in use are closed when released.
+func CloseServicePools(serviceID string) {
+	closeServicesPools(func(s string) bool { return s == serviceID })
+}
+
+// closeServicesPools closes pools of services matched by passed function.
+func closeServicesPools(match func(string) bool) {
+	pools.Lock()
+	list := make([]*pool, 0, len(pools.m))
+	for key, p := range pools.m {
+		if match(key.service) {
+			list = append(list, p)
+			delete(pools.m, key)
+		}
+	}
+	for service := range pools.waits {
+		if match(service) {
+			delete(pools.waits, service)
+		}
+	}
+	pools.Unlock()
+
+	for _, p := range list {
+		p.close()
+	}
+}
+
+// getPool returns pool for database specified in passed config, nil is returned when pooling is disabled or
+// connection is not made on behalf of a service.
+func getPool(ctx context.Context, config *pgx.ConnConfig) *pool {
+	service, _ := ctx.Value(serviceContextKey{}).(string)
+	if service == "" {
+		return nil
+	}
+
+	evictPools(time.Now())
+
+	pools.Lock()
+	defer pools.Unlock()
+
+	if !pools.config.Enabled() {
+		return nil
+	}
+
+	// Collectors create configs from the same connection string and change database, take it into account.
+	key := poolKey{service: service, conn: fmt.Sprintf("%s dbname=%s", config.ConnString(), config.Database)}
+
+	p, ok := pools.m[key]
+	if !ok {
+		p = newPool(pools.config)
+		pools.m[key] = p
+	}
+
+	return p
+}
+
+// evictPools closes connections which have been idle too long, and removes pools which have not been used too
+// long, e.g. pools of dropped databases. Pools are checked not often than poolEvictInterval.
+func evictPools(now time.Time) {
+	pools.Lock()
+	if now.Sub(pools.evictedAt) < poolEvictInterval {
+		pools.Unlock()
+		return
+	}
+	pools.evictedAt = now
+
+	list := make(map[poolKey]*pool, len(pools.m))
+	for key, p := range pools.m {
+		list[key] = p
+	}
+	pools.Unlock()
+
+	for key, p := range list {
+		if !p.evictIdle(now) {
+			continue
+		}
+
+		pools.Lock()
+		if pools.m[key] == p {
+			delete(pools.m, key)
+			_, _, waits := p.stats()
+			pools.waits[key.service] += waits
+		}
+		pools.Unlock()
+	}
+}
+
+// pool keeps idle connections to a single database for reusing them by subsequent scrapes.
+type pool struct {
+	config PoolConfig
+	sem    chan struct{} // limits number of open connections, nil means no limit
+
+	mu     sync.Mutex
+	idle   []*DB
+	inUse  int
+	waits  int       // number of times acquiring has been blocked due to max_open limit
+	usedAt time.Time // last time when connection has been acquired or released
+	closed bool      // pool has been closed, released connections are not kept
+}
+
+// newPool creates new pool.
+func newPool(cfg PoolConfig) *pool {
+	p := &pool{config: cfg, usedAt: time.Now()}
+	if cfg.MaxOpen > 0 {
+		p.sem = make(chan struct{}, cfg.MaxOpen)
+	}
+	return p
+}
+
+// acquire returns idle connection or creates new one. When max_open connections are in use, it blocks until any
+// connection is released or passed context is done.
+func (p *pool) acquire(ctx context.Context, config *pgx.ConnConfig) (*DB, error) {
+	if p.sem != nil {
+		select {
+		case p.sem <- struct{}{}:
+		default:
+			p.mu.Lock()
+			p.waits++
+			p.mu.Unlock()
+
+			select {
+			case p.sem <- struct{}{}:
+			case <-ctx.Done():
+				return nil, fmt.Errorf("wait for connection failed: %w", ctx.Err())
+			}
+		}
+	}
+
+	// Context could be done while waiting, don't occupy the slot in that case.
+	if err := ctx.Err(); err != nil {
+		p.releaseSem()
+		return nil, fmt.Errorf("wait for connection failed: %w", err)
+	}
+
+	p.mu.Lock()
+	p.usedAt = time.Now()
+	for len(p.idle) > 0 {
+		db := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+
+		if db.conn.IsClosed() || p.expired(db) || p.idleExpired(db, p.usedAt) {
+			db.close()
+			continue
+		}
+
+		p.inUse++
+		p.mu.Unlock()
+		return db, nil
+	}
+	p.inUse++
+	p.mu.Unlock()
+
+	db, err := connect(ctx, config, newStatementCache(config, p.config))
+	if err != nil {
+		p.mu.Lock()
+		p.inUse--
+		p.mu.Unlock()
+		p.releaseSem()
+		return nil, err
+	}
+
+	db.pool = p
+	db.createdAt = time.Now()
+
+	return db, nil
+}
+
+// release puts connection back to idle connections or closes it if pool is full or connection is not reusable.
+func (p *pool) release(db *DB) {
+	p.mu.Lock()
+	p.inUse--
+	p.usedAt = time.Now()
+	keep := !p.closed && !db.conn.IsClosed() && !db.conn.PgConn().IsBusy() && !p.expired(db) && len(p.idle) < p.config.MaxIdle
+	if keep {
+		db.releasedAt = p.usedAt
+		p.idle = append(p.idle, db)
+	}
+	p.mu.Unlock()
+
+	if !keep {
+		db.close()
+	}
+
+	p.releaseSem()
+}
+
+// releaseSem frees a slot for opening connection.
+func (p *pool) releaseSem() {
+	if p.sem != nil {
+		<-p.sem
+	}
+}
+
+// expired returns true if connection has exceeded its max lifetime.
+func (p *pool) expired(db *DB) bool {
+	return p.config.MaxLifetime > 0 && time.Since(db.createdAt) > p.config.MaxLifetime
+}
+
+// idleExpired returns true if connection has been idle longer than max idle time.
+func (p *pool) idleExpired(db *DB, now time.Time) bool {
+	return now.Sub(db.releasedAt) > p.config.maxIdleTime()
+}
+
+// evictIdle closes connections which have been idle longer than max idle time. If the pool has no connections and
+// has not been used longer than max idle time, it's marked closed and true is returned.
+func (p *pool) evictIdle(now time.Time) bool {
+	p.mu.Lock()
+	var expired []*DB
+	idle := p.idle[:0]
+	for _, db := range p.idle {
+		if p.idleExpired(db, now) {
+			expired = append(expired, db)
+		} else {
+			idle = append(idle, db)
+		}
+	}
+	p.idle = idle
+
+	unused := len(p.idle) == 0 && p.inUse == 0 && now.Sub(p.usedAt) > p.config.maxIdleTime()
+	if unused {
+		p.closed = true
+	}
+	p.mu.Unlock()
+
+	for _, db := range expired {
+		db.close()
+	}
+
+	return unused
+}
+
+// close marks the pool closed and closes all idle connections. Connections which are in use are closed when released.
+func (p *pool) close() {
+	p.mu.Lock()
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, db := range idle {
+		db.close()
+	}
+}
+
+// stats returns number of idle and in use connections and number of blocked acquires.
+func (p *pool) stats() (int, int, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle), p.inUse, p.waits
+}
+
+// newStatementCache returns function which creates statements cache for connection accordingly to passed settings.
+// Nil is returned if caching is disabled or not supported by database. Unless 'prepare' mode is configured, only
+// statements descriptions are cached, this is safe for Postgres services behind Pgbouncer.
+func newStatementCache(config *pgx.ConnConfig, cfg PoolConfig) pgx.BuildStatementCacheFunc {
+	if cfg.StatementCacheCapacity == 0 || config.Database == pgbouncerDbname {
+		return nil
+	}
+
+	mode := stmtcache.ModeDescribe
+	if cfg.StatementCacheMode == StatementCacheModePrepare {
+		mode = stmtcache.ModePrepare
+	}
+
+	return func(conn *pgconn.PgConn) stmtcache.Cache {
+		return stmtcache.New(conn, mode, cfg.StatementCacheCapacity)
+	}
+}
+
+// PoolCollector exposes utilization of connections pools.
+type PoolCollector struct {
+	connections *prometheus.Desc
+	max         *prometheus.Desc
+	waits       *prometheus.Desc
+}
+
+// NewPoolCollector creates new PoolCollector.
+func NewPoolCollector() *PoolCollector {
+	return &PoolCollector{
+		connections: prometheus.NewDesc(
+			"pgscv_conn_pool_connections",
+			"Number of connections in collectors connections pools by state.",
+			[]string{"service_id", "state"}, nil,
+		),
+		max: prometheus.NewDesc(
+			"pgscv_conn_pool_max_open",
+			"Max number of open connections per database in collectors connections pools, zero means no limit.",
+			nil, nil,
+		),
+		waits: prometheus.NewDesc(
+			"pgscv_conn_pool_waits_total",
+			"Total number of times acquiring connection has been blocked due to max open connections limit.",
+			[]string{"service_id"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.connections
+	ch <- c.max
+	ch <- c.waits
+}
+
+// poolStats defines utilization of service's connections pools.
+type poolStats struct {
+	idle  int
+	inUse int
+	waits int
+}
+
+// Collect implements prometheus.Collector.
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	pools.Lock()
+	maxOpen := pools.config.MaxOpen
+	list := make(map[poolKey]*pool, len(pools.m))
+	for key, p := range pools.m {
+		list[key] = p
+	}
+	stats := make(map[string]*poolStats, len(pools.waits))
+	for service, waits := range pools.waits {
+		stats[service] = &poolStats{waits: waits}
+	}
+	pools.Unlock()
+
+	for key, p := range list {
+		s, ok := stats[key.service]
+		if !ok {
+			s = &poolStats{}
+			stats[key.service] = s
+		}
+
+		idle, inUse, waits := p.stats()
+		s.idle, s.inUse, s.waits = s.idle+idle, s.inUse+inUse, s.waits+waits
+	}
+
+	for service, s := range stats {
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(s.idle), service, "idle")
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(s.inUse), service, "in_use")
+		ch <- prometheus.MustNewConstMetric(c.waits, prometheus.CounterValue, float64(s.waits), service)
+	}
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(maxOpen))
+}
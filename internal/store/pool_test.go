@@ -0,0 +1,187 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn/stmtcache"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolConfig_Validate(t *testing.T) {
+	testcases := []struct {
+		valid bool
+		in    PoolConfig
+	}{
+		{valid: true, in: PoolConfig{}},
+		{valid: true, in: PoolConfig{MaxOpen: 4, MaxIdle: 2, MaxLifetime: time.Minute, StatementCacheCapacity: 64}},
+		{valid: true, in: PoolConfig{MaxIdle: 2}},
+		{valid: false, in: PoolConfig{MaxOpen: -1}},
+		{valid: false, in: PoolConfig{MaxLifetime: -1}},
+		{valid: false, in: PoolConfig{MaxIdleTime: -1}},
+		{valid: false, in: PoolConfig{MaxOpen: 1, MaxIdle: 2}},
+		{valid: true, in: PoolConfig{StatementCacheCapacity: 64, StatementCacheMode: "prepare"}},
+		{valid: false, in: PoolConfig{StatementCacheCapacity: 64, StatementCacheMode: "invalid"}},
+	}
+
+	for _, tc := range testcases {
+		if tc.valid {
+			assert.NoError(t, tc.in.Validate())
+		} else {
+			assert.Error(t, tc.in.Validate())
+		}
+	}
+}
+
+func TestPool(t *testing.T) {
+	SetPoolConfig(PoolConfig{MaxOpen: 1, MaxIdle: 1})
+	defer func() {
+		ClosePools()
+		SetPoolConfig(PoolConfig{})
+	}()
+
+	config, err := pgx.ParseConfig("host=127.0.0.1 port=1 user=pgscv dbname=pgscv_fixtures connect_timeout=1")
+	assert.NoError(t, err)
+
+	// Connections which are not made on behalf of a service are not pooled.
+	assert.Nil(t, getPool(context.Background(), config))
+
+	ctx := WithService(context.Background(), "test:0")
+
+	// Failed connection should free the slot, otherwise the second attempt would block forever.
+	for i := 0; i < 2; i++ {
+		_, err = NewWithConfigContext(ctx, config)
+		assert.Error(t, err)
+	}
+
+	p := getPool(ctx, config)
+	assert.NotNil(t, p)
+	idle, inUse, waits := p.stats()
+	assert.Equal(t, 0, idle)
+	assert.Equal(t, 0, inUse)
+	assert.Equal(t, 0, waits)
+
+	// Pools of other services are separate.
+	assert.NotSame(t, p, getPool(WithService(context.Background(), "test:1"), config))
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(NewPoolCollector())
+
+	want := `# HELP pgscv_conn_pool_connections Number of connections in collectors connections pools by state.
+# TYPE pgscv_conn_pool_connections gauge
+pgscv_conn_pool_connections{service_id="test:0",state="idle"} 0
+pgscv_conn_pool_connections{service_id="test:0",state="in_use"} 0
+pgscv_conn_pool_connections{service_id="test:1",state="idle"} 0
+pgscv_conn_pool_connections{service_id="test:1",state="in_use"} 0
+# HELP pgscv_conn_pool_max_open Max number of open connections per database in collectors connections pools, zero means no limit.
+# TYPE pgscv_conn_pool_max_open gauge
+pgscv_conn_pool_max_open 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want), "pgscv_conn_pool_connections", "pgscv_conn_pool_max_open"))
+
+	// Pools of removed service are dropped.
+	CloseServicePools("test:1")
+	assert.True(t, p == getPool(ctx, config))
+	pools.Lock()
+	assert.Len(t, pools.m, 1)
+	pools.Unlock()
+}
+
+func Test_evictPools(t *testing.T) {
+	SetPoolConfig(PoolConfig{MaxIdle: 1, MaxIdleTime: time.Minute})
+	defer func() {
+		ClosePools()
+		SetPoolConfig(PoolConfig{})
+	}()
+
+	config, err := pgx.ParseConfig("host=127.0.0.1 port=1 user=pgscv dbname=pgscv_fixtures connect_timeout=1")
+	assert.NoError(t, err)
+
+	p := getPool(WithService(context.Background(), "test:0"), config)
+	p.mu.Lock()
+	p.waits = 2
+	p.mu.Unlock()
+
+	// Recently used pool is kept.
+	evictPools(time.Now().Add(poolEvictInterval))
+	pools.Lock()
+	assert.Len(t, pools.m, 1)
+	pools.Unlock()
+
+	// Pool which has not been used longer than max idle time is removed, its blocked acquires are still accounted.
+	evictPools(time.Now().Add(2 * time.Minute))
+	pools.Lock()
+	assert.Len(t, pools.m, 0)
+	assert.Equal(t, 2, pools.waits["test:0"])
+	pools.Unlock()
+	assert.True(t, p.closed)
+}
+
+func TestPool_acquire_timeout(t *testing.T) {
+	p := newPool(PoolConfig{MaxOpen: 1})
+	config, err := pgx.ParseConfig("host=127.0.0.1 port=1 user=pgscv dbname=pgscv_fixtures connect_timeout=1")
+	assert.NoError(t, err)
+
+	// Occupy the only slot, acquiring should not block longer than context allows.
+	p.sem <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = p.acquire(ctx, config)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, p.sem, 1)
+
+	_, _, waits := p.stats()
+	assert.Equal(t, 1, waits)
+
+	// Cancelled context doesn't occupy released slot.
+	<-p.sem
+	_, err = p.acquire(ctx, config)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, p.sem, 0)
+}
+
+func TestClosePools(t *testing.T) {
+	SetPoolConfig(PoolConfig{MaxOpen: 2, MaxIdle: 2})
+	defer func() {
+		ClosePools()
+		SetPoolConfig(PoolConfig{})
+	}()
+
+	config, err := pgx.ParseConfig(TestPostgresConnStr)
+	assert.NoError(t, err)
+
+	db, err := NewWithConfigContext(WithService(context.Background(), "test:0"), config)
+	assert.NoError(t, err)
+	p := db.pool
+
+	// Connection which is in use while pools are closed, is closed when released instead of being kept idle.
+	ClosePools()
+	db.Close()
+
+	idle, inUse, _ := p.stats()
+	assert.Equal(t, 0, idle)
+	assert.Equal(t, 0, inUse)
+	assert.True(t, db.conn.IsClosed())
+}
+
+func Test_newStatementCache(t *testing.T) {
+	config, err := pgx.ParseConfig(TestPostgresConnStr)
+	assert.NoError(t, err)
+	assert.Nil(t, newStatementCache(config, PoolConfig{}))
+	assert.NotNil(t, newStatementCache(config, PoolConfig{StatementCacheCapacity: 16}))
+
+	// Statements descriptions are cached by default, named prepared statements are used only when requested.
+	assert.Equal(t, stmtcache.ModeDescribe, newStatementCache(config, PoolConfig{StatementCacheCapacity: 16})(nil).Mode())
+	assert.Equal(t, stmtcache.ModePrepare, newStatementCache(config, PoolConfig{StatementCacheCapacity: 16, StatementCacheMode: "prepare"})(nil).Mode())
+
+	config, err = pgx.ParseConfig(TestPgbouncerConnStr)
+	assert.NoError(t, err)
+	assert.Nil(t, newStatementCache(config, PoolConfig{StatementCacheCapacity: 16}))
+}
@@ -3,7 +3,10 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
+
 	"github.com/jackc/pgx/v4"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -27,25 +30,55 @@ const (
 	dataTypeNumeric uint32 = 1700
 )
 
+// ErrConnect is returned when connection to the service cannot be established, e.g. due to network, TLS or
+// authentication problems.
+var ErrConnect = errors.New("connect failed")
+
 // DB is the database representation
 type DB struct {
-	conn *pgx.Conn // database connection object
+	conn       *pgx.Conn // database connection object
+	pool       *pool     // pool the connection should be returned to, nil if connection is not pooled
+	createdAt  time.Time // time when connection has been established
+	releasedAt time.Time // time when connection has been returned to the pool
 }
 
 // New creates new connection to Postgres/Pgbouncer using passed DSN
 func New(connString string) (*DB, error) {
+	return NewContext(context.Background(), connString)
+}
+
+// NewContext creates new connection to Postgres/Pgbouncer using passed DSN, connecting is cancelled when passed
+// context is done.
+func NewContext(ctx context.Context, connString string) (*DB, error) {
 	config, err := pgx.ParseConfig(connString)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewWithConfig(config)
+	return NewWithConfigContext(ctx, config)
 }
 
 // NewWithConfig creates new connection to Postgres/Pgbouncer using passed Config.
 func NewWithConfig(config *pgx.ConnConfig) (*DB, error) {
+	return NewWithConfigContext(context.Background(), config)
+}
+
+// NewWithConfigContext is like NewWithConfig, but connecting or waiting for connection from the pool is cancelled
+// when passed context is done. Connections are pooled only when context is created using WithService.
+func NewWithConfigContext(ctx context.Context, config *pgx.ConnConfig) (*DB, error) {
+	if p := getPool(ctx, config); p != nil {
+		return p.acquire(ctx, config)
+	}
+
+	return connect(ctx, config, nil)
+}
+
+// connect establishes new connection using passed Config. Statements are cached when cache function is passed,
+// otherwise simple protocol is used.
+func connect(ctx context.Context, config *pgx.ConnConfig, cache pgx.BuildStatementCacheFunc) (*DB, error) {
 	// Enable simple protocol for compatibility with Pgbouncer.
-	config.PreferSimpleProtocol = true
+	config.PreferSimpleProtocol = cache == nil
+	config.BuildStatementCache = cache
 
 	// Using simple protocol requires explicit options to be set.
 	config.RuntimeParams = map[string]string{
@@ -53,9 +86,9 @@ func NewWithConfig(config *pgx.ConnConfig) (*DB, error) {
 		"client_encoding":             "UTF8",
 	}
 
-	conn, err := pgx.ConnectConfig(context.Background(), config)
+	conn, err := pgx.ConnectConfig(ctx, config)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrConnect, err)
 	}
 
 	return &DB{conn: conn}, nil
@@ -64,10 +97,21 @@ func NewWithConfig(config *pgx.ConnConfig) (*DB, error) {
 /* public db methods */
 
 // Query is a wrapper on private query() method.
-func (db *DB) Query(query string) (*model.PGResult, error) { return db.query(query) }
+func (db *DB) Query(query string) (*model.PGResult, error) { return db.query(context.Background(), query) }
 
-// Close is wrapper on private close() method.
-func (db *DB) Close() { db.close() }
+// QueryContext is a wrapper on private query() method, query is cancelled when passed context is done.
+func (db *DB) QueryContext(ctx context.Context, query string) (*model.PGResult, error) {
+	return db.query(ctx, query)
+}
+
+// Close returns connection to the pool if connection is pooled, otherwise closes it.
+func (db *DB) Close() {
+	if db.pool != nil {
+		db.pool.release(db)
+		return
+	}
+	db.close()
+}
 
 // Conn provides access to public methods of *pgx.Conn struct
 func (db *DB) Conn() *pgx.Conn { return db.conn }
@@ -75,8 +119,9 @@ func (db *DB) Conn() *pgx.Conn { return db.conn }
 /* private db methods */
 
 // Query method executes passed query and wraps result into model.PGResult struct.
-func (db *DB) query(query string) (*model.PGResult, error) {
-	rows, err := db.Conn().Query(context.Background(), query)
+func (db *DB) query(ctx context.Context, query string) (*model.PGResult, error) {
+	// Request results in text format, it is required when prepared statements are used.
+	rows, err := db.Conn().Query(ctx, query, pgx.QueryResultFormats{pgx.TextFormatCode})
 	if err != nil {
 		return nil, err
 	}
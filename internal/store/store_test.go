@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/jackc/pgproto3/v2"
@@ -8,6 +9,7 @@ import (
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -52,7 +54,7 @@ func TestNewWithConfig(t *testing.T) {
 			assert.NoError(t, err)
 			assert.NotNil(t, db)
 		} else {
-			assert.Error(t, err)
+			assert.ErrorIs(t, err, ErrConnect)
 			assert.Nil(t, db)
 		}
 	}
@@ -112,6 +114,22 @@ func TestDB_Query(t *testing.T) {
 	}
 }
 
+func TestDB_QueryContext(t *testing.T) {
+	db := NewTest(t)
+	defer db.Close()
+
+	res, err := db.QueryContext(context.Background(), "SELECT 1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Nrows)
+
+	// Query is cancelled when context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = db.QueryContext(ctx, "SELECT pg_sleep(5)")
+	assert.Error(t, err)
+}
+
 func TestDB_Close(t *testing.T) {
 	db := NewTest(t)
 	assert.NotNil(t, db)
@@ -122,7 +140,7 @@ func TestDB_Close(t *testing.T) {
 func TestExample(t *testing.T) {
 	db := NewTest(t)
 	q := "select relkind::char as relkind from pg_class where relname in ('pg_class')"
-	_, err := db.query(q)
+	_, err := db.query(context.Background(), q)
 	fmt.Println(err)
 	//fmt.Println(res.Rows)
 }